@@ -9,6 +9,8 @@ import (
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
 	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/monitor"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 	"github.com/favonia/cloudflare-ddns/internal/setter"
@@ -18,6 +20,9 @@ import (
 const (
 	IntervalUnit     = time.Second
 	IntervalLargeGap = time.Second * 10
+
+	// TokenWatchInterval is the interval between two checks of whether the API token was revoked.
+	TokenWatchInterval = time.Hour
 )
 
 // signalWait returns false if the alarm is triggered before other signals come.
@@ -41,16 +46,38 @@ func welcome(ppfmt pp.PP) {
 	}
 }
 
+// flushCache flushes the API cache so that the next update will see manual changes made elsewhere.
+func flushCache(ppfmt pp.PP, h api.Handle) {
+	h.FlushCache()
+	ppfmt.Infof(pp.EmojiSignal, "Cache flushed due to SIGHUP; next update will re-fetch all records")
+}
+
+// watchToken starts watching the API token (if the handle supports it) and returns the function to stop it.
+// Once the token is revoked, SIGTERM is sent to chanSignal so that the tool shuts down cleanly.
+func watchToken(ctx context.Context, ppfmt pp.PP, h api.Handle, chanSignal chan<- os.Signal) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
+	if ch, ok := h.(*api.CloudflareHandle); ok {
+		invalid := ch.StartTokenWatcher(ctx, ppfmt, TokenWatchInterval)
+		go func() {
+			select {
+			case <-invalid:
+				chanSignal <- syscall.SIGTERM
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return cancel
+}
+
 func initConfig(ctx context.Context, ppfmt pp.PP) (*config.Config, api.Handle, setter.Setter) {
 	c := config.Default()
 	bye := func() {
 		// Usually, this is called only after initConfig,
 		// but we are exiting early.
 		monitor.StartAll(ctx, ppfmt, c.Monitors)
-
-		ppfmt.Noticef(pp.EmojiBye, "Bye!")
 		monitor.ExitStatusAll(ctx, ppfmt, c.Monitors, 1)
-		os.Exit(1)
+
+		ppfmt.Fatalf(pp.EmojiBye, "Bye!")
 	}
 
 	// Read the config
@@ -62,13 +89,25 @@ func initConfig(ctx context.Context, ppfmt pp.PP) (*config.Config, api.Handle, s
 	c.Print(ppfmt)
 
 	// Get the handler
-	h, ok := c.Auth.New(ctx, ppfmt, c.CacheExpiration)
+	h, ok := c.Auth.New(ctx, ppfmt, c.CacheExpiration, c.UpdateTimeout)
 	if !ok {
 		bye()
 	}
 
+	// Look up the zones in advance and check them for potential problems
+	if ch, ok := h.(*api.CloudflareHandle); ok {
+		var domains []domain.Domain
+		for _, ipNet := range ipnet.All() {
+			if c.Provider[ipNet] != nil {
+				domains = append(domains, c.Domains[ipNet]...)
+			}
+		}
+		ch.WarmZoneCache(ctx, ppfmt, domains)
+		ch.DiagnoseZones(ctx, ppfmt, domains)
+	}
+
 	// Get the setter
-	s, ok := setter.New(ppfmt, h)
+	s, ok := setter.New(ppfmt, h, c.MaxRecordsPerDomain, c.IP6PrefixLength, c.IP6HostSuffix, c.RecordCommentTemplate)
 	if !ok {
 		bye()
 	}
@@ -82,6 +121,10 @@ func main() { //nolint:funlen
 		ppfmt.Noticef(pp.EmojiUserError, "Bye!")
 		return
 	}
+	if !config.ReadEmoji("EMOJI", &ppfmt) {
+		ppfmt.Noticef(pp.EmojiUserError, "Bye!")
+		return
+	}
 	if !ppfmt.IsEnabledFor(pp.Info) {
 		ppfmt.Noticef(pp.EmojiMute, "Quiet mode enabled")
 	}
@@ -94,7 +137,7 @@ func main() { //nolint:funlen
 	// Print the current privileges
 	printPriviledges(ppfmt)
 
-	// Catch SIGINT and SIGTERM
+	// Catch SIGINT and SIGTERM, and SIGHUP for reloading the configuration
 	chanSignal := make(chan os.Signal, 1)
 	signal.Notify(chanSignal, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
@@ -104,6 +147,13 @@ func main() { //nolint:funlen
 	// Read the config and get the handler and the setter
 	c, h, s := initConfig(ctx, ppfmt)
 
+	// The updater remembers the last updates of the domains for their update intervals
+	st := updater.NewState()
+
+	// Shut down cleanly, as if SIGTERM was caught, once the API token is revoked.
+	// The watcher also warns about the upcoming expiry of the token.
+	stopWatching := watchToken(ctx, ppfmt, h, chanSignal)
+
 	// Start the tool now
 	monitor.StartAll(ctx, ppfmt, c.Monitors)
 
@@ -116,7 +166,10 @@ mainLoop:
 
 		// Update the IP
 		if !first || c.UpdateOnStart {
-			if updater.UpdateIPs(ctx, ppfmt, c, s) {
+			if ch, ok := h.(*api.CloudflareHandle); ok {
+				ch.ClearUpdateEvents()
+			}
+			if updater.UpdateIPs(ctx, ppfmt, c, st, s) {
 				monitor.SuccessAll(ctx, ppfmt, c.Monitors)
 			} else {
 				monitor.FailureAll(ctx, ppfmt, c.Monitors)
@@ -166,10 +219,13 @@ mainLoop:
 		switch sig.(syscall.Signal) { //nolint:forcetypeassert
 		case syscall.SIGHUP:
 			ppfmt.Noticef(pp.EmojiSignal, "Caught signal: %v", sig)
-			h.FlushCache()
+			flushCache(ppfmt, h)
 
 			ppfmt.Noticef(pp.EmojiRepeatOnce, "Restarting . . .")
+			stopWatching()
 			c, h, s = initConfig(ctx, ppfmt)
+			st = updater.NewState()
+			stopWatching = watchToken(ctx, ppfmt, h, chanSignal)
 			continue mainLoop
 
 		case syscall.SIGINT, syscall.SIGTERM:
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestFlushCache(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockHandle := mocks.NewMockHandle(mockCtrl)
+	gomock.InOrder(
+		mockHandle.EXPECT().FlushCache().Return(0),
+		mockPP.EXPECT().Infof(pp.EmojiSignal, "Cache flushed due to SIGHUP; next update will re-fetch all records"),
+	)
+	flushCache(mockPP, mockHandle)
+}
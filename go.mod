@@ -7,7 +7,6 @@ retract [v0.0.0, v1.99.0]
 require (
 	github.com/cloudflare/cloudflare-go v0.53.0
 	github.com/golang/mock v1.6.0
-	github.com/jellydator/ttlcache/v3 v3.0.0
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/stretchr/testify v1.8.1
 	golang.org/x/net v0.1.0
@@ -21,7 +20,6 @@ require (
 	github.com/hashicorp/go-retryablehttp v0.7.1 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
 	golang.org/x/text v0.4.0 // indirect
 	golang.org/x/time v0.1.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -10,9 +10,10 @@ import (
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
 
-//go:generate mockgen -destination=../mocks/mock_api.go -package=mocks . Handle
+//go:generate mockgen -destination=../mocks/mock_api.go -package=mocks . Handle,Auth
 
 // A Handle represents a generic API to update DNS records. Currently, the only implementation is Cloudflare.
+// The IP networks given to a Handle must be specific; ipnet.Any is not accepted.
 type Handle interface {
 	// List DNS records.
 	ListRecords(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type) (map[string]netip.Addr, bool)
@@ -20,15 +21,154 @@ type Handle interface {
 	DeleteRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type, id string) bool
 	// Update one DNS record.
 	UpdateRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type, id string, ip netip.Addr) bool
-	// Create one DNS record.
+	// Create one DNS record, with an optional comment.
 	CreateRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type,
-		ip netip.Addr, ttl TTL, proxied bool) (string, bool)
-	// Flush the API cache.
-	FlushCache()
+		ip netip.Addr, ttl TTL, proxied bool, comment string) (string, bool)
+	// Create one DNS record unless a record already points to the IP address. It returns the ID of
+	// the existing or new record and whether the record was created.
+	EnsureRecordExists(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type,
+		ip netip.Addr, ttl TTL, proxied bool) (id string, created bool, ok bool)
+	// Update several DNS records, attempting all of them even if some fail.
+	BatchUpdate(ctx context.Context, ppfmt pp.PP, updates []RecordUpdate) ([]RecordUpdateResult, bool)
+	// Describe the account and the API token in use.
+	Describe(ctx context.Context, ppfmt pp.PP) (AccountInfo, bool)
+	// Flush the API cache and return the number of evicted entries.
+	FlushCache() int
+}
+
+// An ExtendedHandle represents an API with optional features beyond Handle, such as managing
+// records of other types. Currently, only CloudflareHandle implements it; the wrappers of Handle do not.
+type ExtendedHandle interface {
+	Handle
+
+	// List HTTPS records (RFC 9460), mapping IDs to contents. The content of an HTTPS record
+	// is its full RDATA in the presentation format, such as `1 . alpn="h2"`.
+	ListHTTPSRecords(ctx context.Context, ppfmt pp.PP, domain domain.Domain) (map[string]string, bool)
+	// Delete one HTTPS record.
+	DeleteHTTPSRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, id string) bool
+	// Update one HTTPS record.
+	UpdateHTTPSRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, id string, content string) bool
+	// Create one HTTPS record.
+	CreateHTTPSRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, content string, ttl TTL) (string, bool)
+
+	// List DNS records of the type, such as TXT or MX, mapping IDs to contents.
+	ListRecordsByType(ctx context.Context, ppfmt pp.PP, domain domain.Domain, recordType string) (map[string]string, bool)
+
+	// Create or update the PTR record of the IP address so that it points to the hostname. The reverse zone
+	// (such as "2.1.in-addr.arpa") must be managed by the same account.
+	UpdatePTRRecord(ctx context.Context, ppfmt pp.PP, ip netip.Addr, hostname string, ttl TTL) bool
+
+	// Replace all the tags of one DNS record (available on Enterprise plans). An empty list removes all the tags.
+	SetRecordTags(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type, id string, tags []string) bool
+
+	// Check whether the DNS records of the domain still point to the expected IP address.
+	DetectDrift(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type, expectedIP netip.Addr,
+	) (drifted bool, currentIP netip.Addr, ok bool)
+
+	// Check whether one DNS record is proxied by Cloudflare.
+	GetRecordProxied(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type, id string,
+	) (proxied bool, ok bool)
+
+	// List DNS records page by page, so that large sets of records do not have to be held in memory at once.
+	// See CloudflareHandle.ListRecordsPaginated.
+	ListRecordsPaginated(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipNet ipnet.Type, pageSize int,
+	) (<-chan RecordPage, <-chan error)
+}
+
+// A RecordPage is one page of DNS records, mapping IDs to IP addresses. Pages are numbered from 1.
+type RecordPage struct {
+	Page    int
+	Records map[string]netip.Addr
+}
+
+// A RecordUpdate describes an update of an existing DNS record to a new IP address.
+type RecordUpdate struct {
+	Domain domain.Domain
+	IPNet  ipnet.Type
+	ID     string
+	IP     netip.Addr
+}
+
+// A RecordUpdateResult is the outcome of a RecordUpdate. Err is nil if and only if Success is true.
+type RecordUpdateResult struct {
+	Domain  domain.Domain
+	Success bool
+	Err     error
+}
+
+// An AccountInfo describes the account and the API token in use. Unknown fields are empty.
+type AccountInfo struct {
+	AccountID   string
+	AccountName string
+	TokenID     string
+	TokenName   string
+}
+
+// A NewRecord describes a new DNS record. An empty comment means no comment.
+type NewRecord struct {
+	Domain  domain.Domain
+	IPNet   ipnet.Type
+	IP      netip.Addr
+	TTL     TTL
+	Proxied bool
+	Comment string
+}
+
+// An UpdateAction is the kind of change made to a DNS record.
+type UpdateAction int
+
+const (
+	// ActionNoOp means the record was already up to date.
+	ActionNoOp UpdateAction = iota
+	// ActionCreated means a new record was created.
+	ActionCreated
+	// ActionUpdated means an existing record was changed.
+	ActionUpdated
+	// ActionDeleted means an existing record was deleted.
+	ActionDeleted
+)
+
+func (a UpdateAction) String() string {
+	switch a {
+	case ActionNoOp:
+		return "no-op"
+	case ActionCreated:
+		return "created"
+	case ActionUpdated:
+		return "updated"
+	case ActionDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// An UpdateEvent records an attempt to change a DNS record. OldIP is invalid if the previous
+// IP address was not known (or there was none), and NewIP is invalid for deletions.
+type UpdateEvent struct {
+	Domain    domain.Domain
+	IPNet     ipnet.Type
+	OldIP     netip.Addr
+	NewIP     netip.Addr
+	RecordID  string
+	Action    UpdateAction
+	Success   bool
+	Timestamp time.Time
+}
+
+// A RecordDetail contains the details of a DNS record.
+type RecordDetail struct {
+	ID      string
+	Type    string
+	Name    string
+	Content string
+	TTL     TTL
+	Proxied bool
 }
 
 // An Auth contains authentication information.
 type Auth interface {
 	// Use the authentication information to create a Handle.
-	New(context.Context, pp.PP, time.Duration) (Handle, bool)
+	// The first duration is the cache expiration and the second one is the timeout of the setup.
+	New(ctx context.Context, ppfmt pp.PP, cacheExpiration time.Duration, timeout time.Duration) (Handle, bool)
 }
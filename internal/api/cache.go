@@ -0,0 +1,78 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+)
+
+type cacheItem[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// cache is a simple key-value cache whose items expire after a fixed duration according to a Clock.
+// Expired items are removed lazily.
+type cache[K comparable, V any] struct {
+	mutex      sync.Mutex
+	clock      clock.Clock
+	expiration time.Duration
+	items      map[K]cacheItem[V]
+}
+
+func newCache[K comparable, V any](c clock.Clock, expiration time.Duration) *cache[K, V] {
+	return &cache[K, V]{
+		mutex:      sync.Mutex{},
+		clock:      c,
+		expiration: expiration,
+		items:      map[K]cacheItem[V]{},
+	}
+}
+
+// Get returns the value of an item that has not expired.
+// An item expires exactly when the expiration duration has passed since it was set.
+func (c *cache[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	if !c.clock.Now().Before(item.expiresAt) {
+		delete(c.items, key)
+		var zero V
+		return zero, false
+	}
+
+	return item.value, true
+}
+
+// Set adds or replaces an item.
+func (c *cache[K, V]) Set(key K, value V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items[key] = cacheItem[V]{value: value, expiresAt: c.clock.Now().Add(c.expiration)}
+}
+
+// Delete removes an item.
+func (c *cache[K, V]) Delete(key K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.items, key)
+}
+
+// DeleteAll removes all items and returns the number of removed items, including the expired ones.
+func (c *cache[K, V]) DeleteAll() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	n := len(c.items)
+	c.items = map[K]cacheItem[V]{}
+	return n
+}
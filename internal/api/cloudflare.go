@@ -2,48 +2,292 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
 	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudflare/cloudflare-go"
-	"github.com/jellydator/ttlcache/v3"
+	"golang.org/x/net/publicsuffix"
 
+	"github.com/favonia/cloudflare-ddns/internal/clock"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
 
 type Cache = struct {
-	listRecords  map[ipnet.Type]*ttlcache.Cache[string, map[string]netip.Addr]
-	activeZones  *ttlcache.Cache[string, []string]
-	zoneOfDomain *ttlcache.Cache[string, string]
+	listRecords  map[ipnet.Type]*cache[string, map[string]netip.Addr]
+	activeZones  *cache[string, []string]
+	zoneOfDomain *cache[string, string]
+	zoneName     *cache[string, string]
+	listByType   *cache[recordKey, map[string]string]
+	recordDetail *cache[string, RecordDetail] // the details of the records by their IDs
 }
 
-func newCache[K comparable, V any](cacheExpiration time.Duration) *ttlcache.Cache[K, V] {
-	cache := ttlcache.New(
-		ttlcache.WithDisableTouchOnHit[K, V](),
-		ttlcache.WithTTL[K, V](cacheExpiration),
-	)
+type CloudflareHandle struct {
+	cf                  *cloudflare.API
+	httpClient          *http.Client // the HTTP client of cf, for the endpoints cloudflare-go does not support
+	accountID           string
+	tokenExpiryWarning  time.Duration
+	tokenVerifyPath     string
+	rejectCloudflareIPs bool
+	usesAPIKey          bool       // whether the handle uses the legacy API key instead of an API token
+	useBatchAPI         bool       // whether to try the batch endpoint for BatchUpdate
+	rateLimit           *rateLimit // the rate limit reported by the most recent response, shared by clones
+	rateLimitWarning    int        // the threshold of remaining requests for the warnings of RateLimitStatus
+	retry               RetryConfig
+	cache               Cache
+	clock               clock.Clock // the clock for the timestamps of the events
+	resolver            Resolver    // the resolver for WaitForPropagation
+	propagationInterval time.Duration
+	zoneID              string // the zone of all domains (if not empty), set by CloneForZone
+	eventsMutex         sync.Mutex
+	events              []UpdateEvent // the events of the current update cycle
+}
+
+var _ ExtendedHandle = (*CloudflareHandle)(nil)
+
+// DefaultTokenExpiryWarning is the default length of the period before the expiry of
+// the API token during which warnings will be emitted.
+const DefaultTokenExpiryWarning = time.Hour * 24 * 7
+
+// DefaultStartupRetryInterval is the default interval between attempts to reach the API at startup.
+const DefaultStartupRetryInterval = time.Second * 5
 
-	go cache.Start()
+// DefaultTokenVerifyPath is the path of the token verification endpoint of Cloudflare.
+const DefaultTokenVerifyPath = "/user/tokens/verify"
 
-	return cache
+type CloudflareAuth struct {
+	Token                string
+	AccountID            string
+	BaseURL              string
+	TokenExpiryWarning   time.Duration
+	RejectCloudflareIPs  bool
+	ClientCert           tls.Certificate // the client certificate for mutual TLS (if any)
+	ClientCA             *x509.CertPool  // the CA pool to verify the server (if not the system one)
+	ProxyURL             string          // the HTTP, HTTPS, or SOCKS5 proxy (if any)
+	Clock                clock.Clock     // the clock for the cache expiration (nil means the system clock)
+	TransportConfig      TransportConfig // the connection pooling of the HTTP transport
+	UseBatchAPI          bool            // whether to try the (experimental) batch endpoint for BatchUpdate
+	Retry                RetryConfig     // the retries of failed API calls
+	TokenVerifyPath      string          // the path of the token verification endpoint (empty means the default)
+	StartupTimeout       time.Duration   // how long to wait for an unreachable API at startup (zero means no waiting)
+	StartupRetryInterval time.Duration   // the interval between startup attempts (zero means the default)
+	Resolver             Resolver        // the resolver for WaitForPropagation (nil means net.DefaultResolver)
+	PropagationInterval  time.Duration   // the interval between lookups in WaitForPropagation (zero means the default)
+	AccessClientID       string          // the client ID of a Cloudflare Access service token (if any)
+	AccessClientSecret   string          // the client secret of a Cloudflare Access service token (if any)
+	RateLimitWarning     int             // warn when fewer API requests remain (zero means the default)
 }
 
-type CloudflareHandle struct {
-	cf        *cloudflare.API
-	accountID string
-	cache     Cache
+// A TransportConfig tunes the connection pooling of the HTTP transport. Zero values mean the defaults.
+type TransportConfig struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
 }
 
-type CloudflareAuth struct {
-	Token     string
-	AccountID string
-	BaseURL   string
+// transport returns an HTTP transport for mutual TLS, proxies, and connection pooling.
+// A new transport is created every time so that handles never share connections.
+func (t *CloudflareAuth) transport(ppfmt pp.PP) (*http.Transport, bool) {
+	hasClientCert := len(t.ClientCert.Certificate) > 0
+
+	//nolint:forcetypeassert // http.DefaultTransport is always an *http.Transport
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if t.TransportConfig.MaxIdleConns != 0 {
+		transport.MaxIdleConns = t.TransportConfig.MaxIdleConns
+	}
+	if t.TransportConfig.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = t.TransportConfig.MaxIdleConnsPerHost
+	}
+	if t.TransportConfig.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = t.TransportConfig.IdleConnTimeout
+	}
+
+	if hasClientCert || t.ClientCA != nil {
+		if hasClientCert && t.ClientCert.PrivateKey == nil {
+			ppfmt.Errorf(pp.EmojiUserError, "The client certificate for mutual TLS has no private key")
+			return nil, false
+		}
+
+		//nolint:exhaustruct // Other fields are intentionally omitted
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    t.ClientCA,
+		}
+		if hasClientCert {
+			transport.TLSClientConfig.Certificates = []tls.Certificate{t.ClientCert}
+		}
+	}
+
+	if t.ProxyURL != "" {
+		proxyURL, err := url.Parse(t.ProxyURL)
+		if err != nil {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse the proxy URL %q: %v", t.ProxyURL, err)
+			return nil, false
+		}
+
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			ppfmt.Errorf(pp.EmojiUserError, "The proxy URL %q should use http://, https://, or socks5://", t.ProxyURL)
+			return nil, false
+		}
+
+		if proxyURL.Host == "" {
+			ppfmt.Errorf(pp.EmojiUserError, "The proxy URL %q does not specify a host", t.ProxyURL)
+			return nil, false
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, true
+}
+
+// checkBaseURL checks that the base URL (if any) is an HTTP or HTTPS URL with a host.
+// Otherwise, the mistake would only surface as a cryptic network error later.
+func checkBaseURL(ppfmt pp.PP, baseURL string) bool {
+	if baseURL == "" {
+		return true
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		ppfmt.Errorf(pp.EmojiUserError, "Invalid CF_BASE_URL: %v", err)
+		return false
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		ppfmt.Errorf(pp.EmojiUserError, "Invalid CF_BASE_URL: %q should use http:// or https://", baseURL)
+		return false
+	}
+
+	if u.Host == "" {
+		ppfmt.Errorf(pp.EmojiUserError, "Invalid CF_BASE_URL: %q does not specify a host", baseURL)
+		return false
+	}
+
+	return true
+}
+
+// verifyAPIToken verifies the API token with the verification endpoint at the path.
+// Cloudflare-compatible APIs (such as Workers for Platforms) might place the endpoint elsewhere.
+func verifyAPIToken(ctx context.Context, cf *cloudflare.API, path string) (cloudflare.APITokenVerifyBody, error) {
+	var res cloudflare.APITokenVerifyBody
+
+	raw, err := cf.Raw(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return res, err //nolint:wrapcheck
+	}
+
+	err = json.Unmarshal(raw, &res)
+	return res, err //nolint:wrapcheck
+}
+
+// discoverAccountID looks up the account of the API token from the memberships of its user.
+// Only a single accepted membership is trusted: with several accounts, picking one of them
+// could hide the zones in the others. Most API tokens cannot read memberships anyways.
+func discoverAccountID(ctx context.Context, cf *cloudflare.API) string {
+	raw, err := cf.Raw(ctx, http.MethodGet, "/memberships?status=accepted", nil, nil)
+	if err != nil {
+		return ""
+	}
+
+	var memberships []struct {
+		Account struct {
+			ID string `json:"id"`
+		} `json:"account"`
+	}
+	if err := json.Unmarshal(raw, &memberships); err != nil || len(memberships) != 1 {
+		return ""
+	}
+
+	return memberships[0].Account.ID
+}
+
+// warnTokenExpiry warns about the expiry of the API token if it is coming soon according to the clock.
+// A zero expiry time means the token never expires.
+func warnTokenExpiry(ppfmt pp.PP, c clock.Clock, expiresOn time.Time, window time.Duration) {
+	if expiresOn.IsZero() || expiresOn.Sub(c.Now()) > window {
+		return
+	}
+
+	ppfmt.Warningf(pp.EmojiUserWarning, "The Cloudflare API token will expire at %s; please renew it soon",
+		expiresOn.Format(time.RFC3339))
+}
+
+// verifyAPITokenAtStartup verifies the API token within the deadline of ctx. If StartupTimeout
+// is positive, attempts failing with network errors are repeated until StartupTimeout has passed,
+// so that a daemon started before the network is ready can still work.
+func (t *CloudflareAuth) verifyAPITokenAtStartup(ctx context.Context, ppfmt pp.PP, c clock.Clock,
+	cf *cloudflare.API, path string,
+) (cloudflare.APITokenVerifyBody, error) {
+	interval := t.StartupRetryInterval
+	if interval <= 0 {
+		interval = DefaultStartupRetryInterval
+	}
+	deadline := c.Now().Add(t.StartupTimeout)
+
+	for {
+		res, err := verifyAPIToken(ctx, cf, path)
+		if err == nil || t.StartupTimeout <= 0 || ctx.Err() != nil || !isNetworkError(err) ||
+			!c.Now().Add(interval).Before(deadline) {
+			return res, err
+		}
+
+		ppfmt.Infof(pp.EmojiRepeatOnce, "The Cloudflare API is not reachable yet; retrying in %v . . .", interval)
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-c.After(interval):
+		}
+	}
 }
 
-func (t *CloudflareAuth) New(ctx context.Context, ppfmt pp.PP, cacheExpiration time.Duration) (Handle, bool) {
-	handle, err := cloudflare.NewWithAPIToken(t.Token)
+func (t *CloudflareAuth) New(ctx context.Context, ppfmt pp.PP, cacheExpiration, timeout time.Duration) (Handle, bool) {
+	// All the API calls made at startup share one deadline, which also covers the waiting
+	// for an unreachable API when StartupTimeout is positive.
+	setupTimeout := timeout + t.StartupTimeout
+	ctx, cancel := context.WithTimeout(ctx, setupTimeout)
+	defer cancel()
+
+	if !checkBaseURL(ppfmt, t.BaseURL) {
+		return nil, false
+	}
+
+	transport, ok := t.transport(ppfmt)
+	if !ok {
+		return nil, false
+	}
+
+	roundTripper, ok := t.accessRoundTripper(ppfmt, transport)
+	if !ok {
+		return nil, false
+	}
+
+	limit := &rateLimit{} //nolint:exhaustruct // The zero value is ready to use
+	limitTransport := &rateLimitTransport{next: roundTripper, limit: limit}
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	httpClient := &http.Client{Transport: &statusTransport{next: limitTransport}}
+	options := []cloudflare.Option{cloudflare.HTTPClient(httpClient)}
+	if t.Retry.MaxRetries > 0 {
+		// Our own retries replace the built-in ones, which do not distinguish safe operations.
+		options = append(options, cloudflare.UsingRetryPolicy(0, 0, 0))
+	}
+	handle, err := cloudflare.NewWithAPIToken(t.Token, options...)
 	if err != nil {
 		ppfmt.Errorf(pp.EmojiUserError, "Failed to prepare the Cloudflare authentication: %v", err)
 		return nil, false
@@ -54,33 +298,148 @@ func (t *CloudflareAuth) New(ctx context.Context, ppfmt pp.PP, cacheExpiration t
 		handle.BaseURL = t.BaseURL
 	}
 
+	tokenVerifyPath := t.TokenVerifyPath
+	if tokenVerifyPath == "" {
+		tokenVerifyPath = DefaultTokenVerifyPath
+	}
+
+	var c clock.Clock = clock.Real{}
+	if t.Clock != nil {
+		c = t.Clock
+	}
+
 	// this is not needed, but is helpful for diagnosing the problem
-	if _, err := handle.VerifyAPIToken(ctx); err != nil {
+	res, err := t.verifyAPITokenAtStartup(ctx, ppfmt, c, handle, tokenVerifyPath)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			ppfmt.Errorf(pp.EmojiError, "Failed to verify the Cloudflare API token within %v", setupTimeout)
+			return nil, false
+		}
+
 		ppfmt.Errorf(pp.EmojiUserError, "The Cloudflare API token could not be verified: %v", err)
 		ppfmt.Errorf(pp.EmojiUserError, "Please double-check CF_API_TOKEN or CF_API_TOKEN_FILE")
 		return nil, false
 	}
+	warnTokenExpiry(ppfmt, c, res.ExpiresOn, t.TokenExpiryWarning)
+
+	// Zone lookups are more precise within an account, so try to find one if CF_ACCOUNT_ID is not set.
+	accountID := t.AccountID
+	if accountID == "" {
+		accountID = discoverAccountID(ctx, handle)
+		if accountID != "" {
+			ppfmt.Infof(pp.EmojiConfig, "Using the account %q, the only account accessible with the API token", accountID)
+		}
+	}
+
+	var resolver Resolver = net.DefaultResolver
+	if t.Resolver != nil {
+		resolver = t.Resolver
+	}
+
+	propagationInterval := t.PropagationInterval
+	if propagationInterval <= 0 {
+		propagationInterval = DefaultPropagationInterval
+	}
+
+	rateLimitWarning := t.RateLimitWarning
+	if rateLimitWarning == 0 {
+		rateLimitWarning = DefaultRateLimitWarning
+	}
 
 	return &CloudflareHandle{
-		cf:        handle,
-		accountID: t.AccountID,
-		cache: Cache{
-			listRecords: map[ipnet.Type]*ttlcache.Cache[string, map[string]netip.Addr]{
-				ipnet.IP4: newCache[string, map[string]netip.Addr](cacheExpiration),
-				ipnet.IP6: newCache[string, map[string]netip.Addr](cacheExpiration),
-			},
-			activeZones:  newCache[string, []string](cacheExpiration),
-			zoneOfDomain: newCache[string, string](cacheExpiration),
-		},
+		cf:                  handle,
+		httpClient:          httpClient,
+		accountID:           accountID,
+		tokenExpiryWarning:  t.TokenExpiryWarning,
+		tokenVerifyPath:     tokenVerifyPath,
+		rejectCloudflareIPs: t.RejectCloudflareIPs,
+		usesAPIKey:          false,
+		useBatchAPI:         t.UseBatchAPI,
+		rateLimit:           limit,
+		rateLimitWarning:    rateLimitWarning,
+		retry:               t.Retry,
+		cache:               newHandleCache(c, cacheExpiration),
+		clock:               c,
+		resolver:            resolver,
+		propagationInterval: propagationInterval,
+		zoneID:              "",
+		eventsMutex:         sync.Mutex{},
+		events:              nil,
 	}, true
 }
 
-func (h *CloudflareHandle) FlushCache() {
+// newHandleCache creates empty caches for a handle.
+func newHandleCache(c clock.Clock, cacheExpiration time.Duration) Cache {
+	return Cache{
+		listRecords: map[ipnet.Type]*cache[string, map[string]netip.Addr]{
+			ipnet.IP4: newCache[string, map[string]netip.Addr](c, cacheExpiration),
+			ipnet.IP6: newCache[string, map[string]netip.Addr](c, cacheExpiration),
+		},
+		activeZones:  newCache[string, []string](c, cacheExpiration),
+		zoneOfDomain: newCache[string, string](c, cacheExpiration),
+		zoneName:     newCache[string, string](c, cacheExpiration),
+		listByType:   newCache[recordKey, map[string]string](c, cacheExpiration),
+		recordDetail: newCache[string, RecordDetail](c, cacheExpiration),
+	}
+}
+
+func (h *CloudflareHandle) FlushCache() int {
+	evicted := 0
 	for _, cache := range h.cache.listRecords {
-		cache.DeleteAll()
+		evicted += cache.DeleteAll()
+	}
+	evicted += h.cache.activeZones.DeleteAll()
+	evicted += h.cache.zoneOfDomain.DeleteAll()
+	evicted += h.cache.zoneName.DeleteAll()
+	evicted += h.cache.listByType.DeleteAll()
+	evicted += h.cache.recordDetail.DeleteAll()
+	return evicted
+}
+
+// Describe verifies the API token (if any) and retrieves the account (if the account ID was given).
+// The name of the token is only available when the token can read its own details.
+func (h *CloudflareHandle) Describe(ctx context.Context, ppfmt pp.PP) (AccountInfo, bool) {
+	info := AccountInfo{
+		AccountID:   h.accountID,
+		AccountName: "",
+		TokenID:     "",
+		TokenName:   "",
+	}
+
+	// There is no token to describe when the legacy API key is used.
+	if !h.usesAPIKey {
+		res, err := verifyAPIToken(ctx, h.cf, h.tokenVerifyPath)
+		if err != nil {
+			ppfmt.Warningf(pp.EmojiError, "The Cloudflare API token could not be verified: %v", err)
+			return AccountInfo{}, false //nolint:exhaustruct
+		}
+		info.TokenID = res.ID
+
+		// Most tokens are not allowed to read their own details, so failures are ignored.
+		if token, err := h.cf.GetAPIToken(ctx, res.ID); err == nil {
+			info.TokenName = token.Name
+		}
+	}
+
+	if h.accountID != "" {
+		account, _, err := h.cf.Account(ctx, h.accountID)
+		if err != nil {
+			ppfmt.Warningf(pp.EmojiError, "Failed to retrieve the account %q: %v", h.accountID, err)
+			return AccountInfo{}, false //nolint:exhaustruct
+		}
+		info.AccountName = account.Name
+	}
+
+	return info, true
+}
+
+// describeZone gives the Unicode form of a zone name for logging, warning about labels that cannot be decoded.
+func describeZone(ppfmt pp.PP, name string) string {
+	unicode, err := domain.FQDN(name).Unicode()
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiWarning, "Failed to decode the zone name %q into Unicode: %v", name, err)
 	}
-	h.cache.activeZones.DeleteAll()
-	h.cache.zoneOfDomain.DeleteAll()
+	return unicode
 }
 
 // ActiveZones lists all active zones of the given name.
@@ -91,13 +450,32 @@ func (h *CloudflareHandle) ActiveZones(ctx context.Context, ppfmt pp.PP, name st
 		return []string{}, true
 	}
 
-	if ids := h.cache.activeZones.Get(name); ids != nil {
-		return ids.Value(), true
+	if ids, ok := h.cache.activeZones.Get(name); ok {
+		return ids, true
 	}
 
-	res, err := h.cf.ListZonesContext(ctx, cloudflare.WithZoneFilters(name, h.accountID, ""))
+	var res cloudflare.ZonesResponse
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		res, err = h.cf.ListZonesContext(ctx, cloudflare.WithZoneFilters(name, h.accountID, ""))
+		return err //nolint:wrapcheck
+	})
+	if err != nil && h.accountID != "" {
+		// A token with only zone-scoped permissions cannot list zones within an account,
+		// and Cloudflare responds with 403 Forbidden. In that case, retry without the account ID.
+		var authErr *cloudflare.AuthenticationError
+		if errors.As(err, &authErr) {
+			ppfmt.Warningf(pp.EmojiWarning,
+				"Failed to look up zones named %q within the account specified by CF_ACCOUNT_ID; the API token might be zone-scoped", //nolint:lll
+				describeZone(ppfmt, name))
+			ppfmt.Warningf(pp.EmojiWarning, "Retrying without CF_ACCOUNT_ID . . .")
+			err = h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+				res, err = h.cf.ListZonesContext(ctx, cloudflare.WithZoneFilters(name, "", ""))
+				return err //nolint:wrapcheck
+			})
+		}
+	}
 	if err != nil {
-		ppfmt.Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v", name, err)
+		ppfmt.Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v", describeZone(ppfmt, name), err)
 		return nil, false
 	}
 
@@ -112,33 +490,56 @@ func (h *CloudflareHandle) ActiveZones(ctx context.Context, ppfmt pp.PP, name st
 			"initializing", // the setup was just started?
 			"moved",        // domain registrar not pointing to Cloudflare
 			"pending":      // the setup was not completed
-			ppfmt.Warningf(pp.EmojiWarning, "Zone %q is %q; your Cloudflare setup is incomplete", name, zone.Status)
+			ppfmt.Warningf(pp.EmojiWarning, "Zone %q is %q; your Cloudflare setup is incomplete",
+				describeZone(ppfmt, name), zone.Status)
 			ppfmt.Warningf(pp.EmojiWarning, "Some features might stop working", name, zone.Status)
 			ids = append(ids, zone.ID)
 		case
 			"deleted": // archived, pending/moved for too long
-			ppfmt.Infof(pp.EmojiWarning, "Zone %q is %q and thus skipped", name, zone.Status)
+			ppfmt.Infof(pp.EmojiWarning, "Zone %q is %q and thus skipped", describeZone(ppfmt, name), zone.Status)
 			// skip these
 		default:
-			ppfmt.Warningf(pp.EmojiImpossible, "Zone %q is in an undocumented status %q", name, zone.Status)
+			ppfmt.Warningf(pp.EmojiImpossible, "Zone %q is in an undocumented status %q", describeZone(ppfmt, name), zone.Status)
 			ppfmt.Warningf(pp.EmojiImpossible, "Please report the bug at https://github.com/favonia/cloudflare-ddns/issues/new") //nolint:lll
 			ids = append(ids, zone.ID)
 		}
 	}
 
-	h.cache.activeZones.Set(name, ids, ttlcache.DefaultTTL)
+	h.cache.activeZones.Set(name, ids)
 
 	return ids, true
 }
 
+// isPublicSuffix checks whether a name is an ICANN public suffix (such as "co.uk"), which cannot be a zone.
+// Private suffixes (such as "github.io") are still checked because their owners might use Cloudflare.
+func isPublicSuffix(name string) bool {
+	suffix, icann := publicsuffix.PublicSuffix(name)
+	return icann && suffix == name
+}
+
+// skipPublicSuffixes advances the splitter past all the public suffixes.
+func skipPublicSuffixes(s domain.Splitter) {
+	for s.IsValid() && isPublicSuffix(s.ZoneNameASCII()) {
+		s.Next()
+	}
+}
+
 func (h *CloudflareHandle) ZoneOfDomain(ctx context.Context, ppfmt pp.PP, domain domain.Domain) (string, bool) {
-	if id := h.cache.zoneOfDomain.Get(domain.DNSNameASCII()); id != nil {
-		return id.Value(), true
+	if h.zoneID != "" {
+		return h.zoneID, true
+	}
+
+	if id, ok := h.cache.zoneOfDomain.Get(domain.DNSNameASCII()); ok {
+		return id, true
 	}
 
 zoneSearch:
 	for s := domain.Split(); s.IsValid(); s.Next() {
 		zoneName := s.ZoneNameASCII()
+		if isPublicSuffix(zoneName) {
+			continue zoneSearch
+		}
+
 		zones, ok := h.ActiveZones(ctx, ppfmt, zoneName)
 		if !ok {
 			return "", false
@@ -148,24 +549,110 @@ zoneSearch:
 		case 0: // len(zones) == 0
 			continue zoneSearch
 		case 1: // len(zones) == 1
-			h.cache.zoneOfDomain.Set(domain.DNSNameASCII(), zones[0], ttlcache.DefaultTTL)
+			h.cache.zoneOfDomain.Set(domain.DNSNameASCII(), zones[0])
+			h.cache.zoneName.Set(zones[0], zoneName)
 			return zones[0], true
 		default: // len(zones) > 1
 			ppfmt.Warningf(pp.EmojiImpossible,
-				"Found multiple active zones named %q. Specifying CF_ACCOUNT_ID might help", zoneName)
+				"Found multiple active zones named %q. Specifying CF_ACCOUNT_ID might help", describeZone(ppfmt, zoneName))
 			return "", false
 		}
 	}
 
 	ppfmt.Warningf(pp.EmojiError, "Failed to find the zone of %q", domain.Describe())
+	if h.accountID != "" {
+		h.explainMissingZone(ctx, ppfmt, domain)
+	}
 	return "", false
 }
 
+// CloneForZone returns a Handle that assumes all domains are in the zone with the ID, without looking up
+// their zones. It is meant for scripts that already know the zone. The clone has its own caches and update
+// events: the cached records are keyed by domain names only, and the clone might disagree with the original
+// handle on the zone of a domain.
+func (h *CloudflareHandle) CloneForZone(zoneID string) Handle {
+	return &CloudflareHandle{
+		cf:                  h.cf,
+		httpClient:          h.httpClient,
+		accountID:           h.accountID,
+		tokenExpiryWarning:  h.tokenExpiryWarning,
+		tokenVerifyPath:     h.tokenVerifyPath,
+		rejectCloudflareIPs: h.rejectCloudflareIPs,
+		usesAPIKey:          h.usesAPIKey,
+		useBatchAPI:         h.useBatchAPI,
+		rateLimit:           h.rateLimit,
+		rateLimitWarning:    h.rateLimitWarning,
+		retry:               h.retry,
+		cache:               newHandleCache(h.clock, h.cache.activeZones.expiration),
+		clock:               h.clock,
+		resolver:            h.resolver,
+		propagationInterval: h.propagationInterval,
+		zoneID:              zoneID,
+		eventsMutex:         sync.Mutex{},
+		events:              nil,
+	}
+}
+
+// explainMissingZone checks whether the zone of the domain is outside the account specified by
+// CF_ACCOUNT_ID, so that a zone in another account is not confused with a zone not on Cloudflare.
+// Errors are ignored because this is only for better diagnostics.
+func (h *CloudflareHandle) explainMissingZone(ctx context.Context, ppfmt pp.PP, d domain.Domain) {
+	for s := d.Split(); s.IsValid(); s.Next() {
+		// As in ActiveZones, the DNS root zone is not checked.
+		zoneName := s.ZoneNameASCII()
+		if zoneName == "" || isPublicSuffix(zoneName) {
+			continue
+		}
+
+		res, err := h.cf.ListZonesContext(ctx, cloudflare.WithZoneFilters(zoneName, "", ""))
+		if err != nil {
+			return
+		}
+		for _, zone := range res.Result {
+			if zone.Status != "deleted" {
+				ppfmt.Warningf(pp.EmojiUserError,
+					"Zone %q exists but is not in the account specified by CF_ACCOUNT_ID", describeZone(ppfmt, zoneName))
+				return
+			}
+		}
+	}
+
+	ppfmt.Warningf(pp.EmojiUserError,
+		"No zone accessible with the API token contains %q; is the domain on Cloudflare?", d.Describe())
+}
+
+// ZoneID returns the ID of the zone of the domain, using the cached result if available.
+// It is meant for diagnostics and integration tests; the updater itself uses ZoneOfDomain.
+func (h *CloudflareHandle) ZoneID(ctx context.Context, ppfmt pp.PP, domain domain.Domain) (string, bool) {
+	return h.ZoneOfDomain(ctx, ppfmt, domain)
+}
+
+// ZoneName returns the name of the zone with the ID, which is the reverse of ZoneID.
+func (h *CloudflareHandle) ZoneName(ctx context.Context, ppfmt pp.PP, zoneID string) (string, bool) {
+	if name, ok := h.cache.zoneName.Get(zoneID); ok {
+		return name, true
+	}
+
+	var zone cloudflare.Zone
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		zone, err = h.cf.ZoneDetails(ctx, zoneID)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve the name of the zone %q: %v", zoneID, err)
+		return "", false
+	}
+
+	h.cache.zoneName.Set(zoneID, zone.Name)
+
+	return zone.Name, true
+}
+
 func (h *CloudflareHandle) ListRecords(ctx context.Context, ppfmt pp.PP,
 	domain domain.Domain, ipNet ipnet.Type,
 ) (map[string]netip.Addr, bool) {
-	if rmap := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); rmap != nil {
-		return rmap.Value(), true
+	if rmap, ok := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); ok {
+		return rmap, true
 	}
 
 	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
@@ -173,10 +660,14 @@ func (h *CloudflareHandle) ListRecords(ctx context.Context, ppfmt pp.PP,
 		return nil, false
 	}
 
-	//nolint:exhaustruct // Other fields are intentionally unspecified
-	rs, err := h.cf.DNSRecords(ctx, zone, cloudflare.DNSRecord{
-		Name: domain.DNSNameASCII(),
-		Type: ipNet.RecordType(),
+	var rs []cloudflare.DNSRecord
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		//nolint:exhaustruct // Other fields are intentionally unspecified
+		rs, err = h.cf.DNSRecords(ctx, zone, cloudflare.DNSRecord{
+			Name: domain.DNSNameASCII(),
+			Type: ipNet.RecordType(),
+		})
+		return err //nolint:wrapcheck
 	})
 	if err != nil {
 		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", domain.Describe(), err)
@@ -192,11 +683,103 @@ func (h *CloudflareHandle) ListRecords(ctx context.Context, ppfmt pp.PP,
 		}
 	}
 
-	h.cache.listRecords[ipNet].Set(domain.DNSNameASCII(), rmap, ttlcache.DefaultTTL)
+	h.cache.listRecords[ipNet].Set(domain.DNSNameASCII(), rmap)
 
 	return rmap, true
 }
 
+// ListAllZoneRecords lists all DNS records in a zone, without filtering by names or types.
+// All pages of the results are retrieved.
+func (h *CloudflareHandle) ListAllZoneRecords(ctx context.Context, ppfmt pp.PP, zoneID string) ([]RecordDetail, bool) {
+	var rs []cloudflare.DNSRecord
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		//nolint:exhaustruct // All fields are intentionally unspecified
+		rs, err = h.cf.DNSRecords(ctx, zoneID, cloudflare.DNSRecord{})
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve records in the zone %q: %v", zoneID, err)
+		return nil, false
+	}
+
+	details := make([]RecordDetail, 0, len(rs))
+	for i := range rs {
+		details = append(details, RecordDetail{
+			ID:      rs[i].ID,
+			Type:    rs[i].Type,
+			Name:    rs[i].Name,
+			Content: rs[i].Content,
+			TTL:     TTL(rs[i].TTL),
+			Proxied: rs[i].Proxied != nil && *rs[i].Proxied,
+		})
+	}
+
+	return details, true
+}
+
+// ImportRecords imports the A and AAAA records in an RFC 1035 zone file into Cloudflare.
+// Records already in Cloudflare (with the same domain and IP address) are skipped.
+// Nothing is imported if the zone file cannot be parsed.
+func (h *CloudflareHandle) ImportRecords(ctx context.Context, ppfmt pp.PP, zoneName, zoneFileContent string) bool {
+	records, ok := parseZoneFile(ppfmt, zoneName, zoneFileContent)
+	if !ok {
+		return false
+	}
+
+	allOk := true
+recordLoop:
+	for _, r := range records {
+		rmap, ok := h.ListRecords(ctx, ppfmt, r.domain, r.ipNet)
+		if !ok {
+			allOk = false
+			continue recordLoop
+		}
+
+		for _, ip := range rmap {
+			if ip == r.ip {
+				ppfmt.Infof(pp.EmojiAlreadyDone, "The %s record of %q with %v already exists",
+					r.ipNet.RecordType(), r.domain.Describe(), r.ip)
+				continue recordLoop
+			}
+		}
+
+		id, ok := h.CreateRecord(ctx, ppfmt, r.domain, r.ipNet, r.ip, r.ttl, false, "")
+		if !ok {
+			allOk = false
+			continue recordLoop
+		}
+
+		ppfmt.Noticef(pp.EmojiAddRecord, "Imported a %s record of %q (ID: %s)", r.ipNet.RecordType(), r.domain.Describe(), id)
+	}
+
+	return allOk
+}
+
+// ExportZoneFile exports all DNS records in a zone as a BIND zone file, exactly as Cloudflare formats it.
+func (h *CloudflareHandle) ExportZoneFile(ctx context.Context, ppfmt pp.PP, zoneID string) ([]byte, bool) {
+	var zoneFile string
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		zoneFile, err = h.cf.ZoneExport(ctx, zoneID)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to export the zone %q: %v", zoneID, err)
+		return nil, false
+	}
+
+	return []byte(zoneFile), true
+}
+
+// errorCodeRecordLocked is the error code for changing a read-only record,
+// such as one created for a custom domain of Cloudflare Workers.
+const errorCodeRecordLocked = 81062
+
+// isRecordLocked checks whether the API refused to change a record because it is read-only.
+func isRecordLocked(err error) bool {
+	var cfErr interface{ InternalErrorCodeIs(int) bool }
+	return errors.As(err, &cfErr) && cfErr.InternalErrorCodeIs(errorCodeRecordLocked)
+}
+
 func (h *CloudflareHandle) DeleteRecord(ctx context.Context, ppfmt pp.PP,
 	domain domain.Domain, ipNet ipnet.Type, id string,
 ) bool {
@@ -205,7 +788,19 @@ func (h *CloudflareHandle) DeleteRecord(ctx context.Context, ppfmt pp.PP,
 		return false
 	}
 
-	if err := h.cf.DeleteDNSRecord(ctx, zone, id); err != nil {
+	err := h.withRetries(ctx, ppfmt, operationWrite, func() error {
+		return h.cf.DeleteDNSRecord(ctx, zone, id) //nolint:wrapcheck
+	})
+	h.recordEvent(domain, ipNet, ActionDeleted, id, h.cachedIP(domain, ipNet, id), netip.Addr{}, err == nil)
+	h.cache.listByType.Delete(recordKey{name: domain.DNSNameASCII(), recordType: ipNet.RecordType()})
+	h.cache.recordDetail.Delete(id)
+	if isRecordLocked(err) {
+		ppfmt.Warningf(pp.EmojiUserWarning,
+			"Could not delete the %s record of %q (ID: %s) because it is managed by Cloudflare Workers",
+			ipNet.RecordType(), domain.Describe(), id)
+		return false
+	}
+	if err != nil {
 		ppfmt.Warningf(pp.EmojiError, "Failed to delete a stale %s record of %q (ID: %s): %v",
 			ipNet.RecordType(), domain.Describe(), id, err)
 
@@ -214,8 +809,19 @@ func (h *CloudflareHandle) DeleteRecord(ctx context.Context, ppfmt pp.PP,
 		return false
 	}
 
-	if rmap := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); rmap != nil {
-		delete(rmap.Value(), id)
+	if rmap, ok := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); ok {
+		delete(rmap, id)
+	}
+
+	return true
+}
+
+// checkIP rejects IP addresses owned by Cloudflare if RejectCloudflareIPs is enabled.
+// Pointing a DNS record to Cloudflare itself would create a routing loop.
+func (h *CloudflareHandle) checkIP(ppfmt pp.PP, domain domain.Domain, ip netip.Addr) bool {
+	if h.rejectCloudflareIPs && IsCloudflareIP(ip) {
+		ppfmt.Errorf(pp.EmojiError, "Refused to use %v for %q because it belongs to Cloudflare", ip, domain.Describe())
+		return false
 	}
 
 	return true
@@ -224,6 +830,10 @@ func (h *CloudflareHandle) DeleteRecord(ctx context.Context, ppfmt pp.PP,
 func (h *CloudflareHandle) UpdateRecord(ctx context.Context, ppfmt pp.PP,
 	domain domain.Domain, ipNet ipnet.Type, id string, ip netip.Addr,
 ) bool {
+	if !h.checkIP(ppfmt, domain, ip) {
+		return false
+	}
+
 	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
 	if !ok {
 		return false
@@ -236,7 +846,25 @@ func (h *CloudflareHandle) UpdateRecord(ctx context.Context, ppfmt pp.PP,
 		Content: ip.String(),
 	}
 
-	if err := h.cf.UpdateDNSRecord(ctx, zone, id, payload); err != nil {
+	oldIP := h.cachedIP(domain, ipNet, id)
+	var status int
+	err := h.withRetries(ctx, ppfmt, operationWrite, func() error {
+		status = 0
+		return h.cf.UpdateDNSRecord(withStatusRecorder(ctx, &status), zone, id, payload) //nolint:wrapcheck
+	})
+	if err != nil && status == http.StatusConflict {
+		err = h.resolveUpdateConflict(ctx, ppfmt, domain, ipNet, zone, id, payload, ip, err)
+	}
+	h.recordEvent(domain, ipNet, ActionUpdated, id, oldIP, ip, err == nil)
+	h.cache.listByType.Delete(recordKey{name: domain.DNSNameASCII(), recordType: ipNet.RecordType()})
+	h.cache.recordDetail.Delete(id)
+	if isRecordLocked(err) {
+		ppfmt.Warningf(pp.EmojiUserWarning,
+			"Could not update the %s record of %q (ID: %s) because it is managed by Cloudflare Workers",
+			ipNet.RecordType(), domain.Describe(), id)
+		return false
+	}
+	if err != nil {
 		ppfmt.Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
 			ipNet.RecordType(), domain.Describe(), id, err)
 
@@ -245,43 +873,247 @@ func (h *CloudflareHandle) UpdateRecord(ctx context.Context, ppfmt pp.PP,
 		return false
 	}
 
-	if rmap := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); rmap != nil {
-		rmap.Value()[id] = ip
+	if rmap, ok := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); ok {
+		rmap[id] = ip
 	}
 
 	return true
 }
 
+// CreateRecord creates a DNS record. An empty comment means no comment.
 func (h *CloudflareHandle) CreateRecord(ctx context.Context, ppfmt pp.PP,
-	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl TTL, proxied bool,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl TTL, proxied bool, comment string,
 ) (string, bool) {
+	if !h.checkIP(ppfmt, domain, ip) {
+		return "", false
+	}
+
 	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
 	if !ok {
 		return "", false
 	}
 
-	//nolint:exhaustruct // Other fields are intentionally omitted
-	payload := cloudflare.DNSRecord{
-		Name:    domain.DNSNameASCII(),
-		Type:    ipNet.RecordType(),
-		Content: ip.String(),
-		TTL:     ttl.Int(),
-		Proxied: &proxied,
-	}
+	r := NewRecord{Domain: domain, IPNet: ipNet, IP: ip, TTL: ttl, Proxied: proxied, Comment: comment}
 
-	res, err := h.cf.CreateDNSRecord(ctx, zone, payload)
+	var id string
+	err := h.withRetries(ctx, ppfmt, operationCreate, func() (err error) {
+		id, err = h.createRawRecord(ctx, zone, r)
+		return err
+	})
+	h.cache.listByType.Delete(recordKey{name: domain.DNSNameASCII(), recordType: ipNet.RecordType()})
 	if err != nil {
 		ppfmt.Warningf(pp.EmojiError, "Failed to add a new %s record of %q: %v",
 			ipNet.RecordType(), domain.Describe(), err)
+		h.recordEvent(domain, ipNet, ActionCreated, "", netip.Addr{}, ip, false)
 
 		h.cache.listRecords[ipNet].Delete(domain.DNSNameASCII())
 
 		return "", false
 	}
+	h.recordEvent(domain, ipNet, ActionCreated, id, netip.Addr{}, ip, true)
 
-	if rmap := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); rmap != nil {
-		rmap.Value()[res.Result.ID] = ip
+	if rmap, ok := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); ok {
+		rmap[id] = ip
+	}
+
+	return id, true
+}
+
+// EnsureRecordExists creates a DNS record unless a record of the domain already points to the IP address.
+// If there are several such records, the one with the smallest ID is returned.
+func (h *CloudflareHandle) EnsureRecordExists(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl TTL, proxied bool,
+) (string, bool, bool) {
+	rmap, ok := h.ListRecords(ctx, ppfmt, domain, ipNet)
+	if !ok {
+		return "", false, false
+	}
+
+	matchedIDs := make([]string, 0, len(rmap))
+	for id, rip := range rmap {
+		if rip == ip {
+			matchedIDs = append(matchedIDs, id)
+		}
+	}
+	if len(matchedIDs) > 0 {
+		sort.Strings(matchedIDs)
+		return matchedIDs[0], false, true
+	}
+
+	id, ok := h.CreateRecord(ctx, ppfmt, domain, ipNet, ip, ttl, proxied, "")
+	return id, ok, ok
+}
+
+var (
+	// ErrRecordNotFound means the record to update no longer exists.
+	ErrRecordNotFound = errors.New("the record does not exist")
+	// ErrRecordListFailed means the existing records could not be retrieved.
+	ErrRecordListFailed = errors.New("failed to retrieve the existing records")
+	// ErrRecordUpdateFailed means the record could not be updated.
+	ErrRecordUpdateFailed = errors.New("failed to update the record")
+)
+
+// BatchUpdate checks the existing records of all the updates first and then applies the required changes.
+// Records already pointing to the new IP addresses are left untouched. A failed update does not stop
+// the remaining ones; every failure is logged and reported in the results, which are in the same order
+// as the updates. Note that Cloudflare does not support transactions, so the updates are not atomic.
+//
+// If UseBatchAPI was set, the required changes are first sent to the batch endpoint, falling back to
+// individual calls if the endpoint is not available.
+func (h *CloudflareHandle) BatchUpdate(ctx context.Context, ppfmt pp.PP,
+	updates []RecordUpdate,
+) ([]RecordUpdateResult, bool) {
+	results := make([]RecordUpdateResult, len(updates))
+	for i, u := range updates {
+		results[i] = RecordUpdateResult{Domain: u.Domain, Success: false, Err: nil}
+
+		rmap, ok := h.ListRecords(ctx, ppfmt, u.Domain, u.IPNet)
+		switch {
+		case !ok:
+			results[i].Err = ErrRecordListFailed
+			continue
+		case !rmap[u.ID].IsValid():
+			ppfmt.Warningf(pp.EmojiError, "Failed to update the %s record of %q (ID: %s): %v",
+				u.IPNet.RecordType(), u.Domain.Describe(), u.ID, ErrRecordNotFound)
+			results[i].Err = ErrRecordNotFound
+			continue
+		case rmap[u.ID] == u.IP:
+			h.recordEvent(u.Domain, u.IPNet, ActionNoOp, u.ID, u.IP, u.IP, true)
+			results[i].Success = true
+		}
+	}
+
+	if h.useBatchAPI && h.tryBatchUpdate(ctx, ppfmt, updates, results) {
+		for _, result := range results {
+			if result.Err != nil {
+				return results, false
+			}
+		}
+		return results, true
+	}
+
+	allOk := true
+	for i, u := range updates {
+		if results[i].Err != nil {
+			allOk = false
+			continue
+		}
+		if results[i].Success {
+			continue
+		}
+
+		if !h.UpdateRecord(ctx, ppfmt, u.Domain, u.IPNet, u.ID, u.IP) {
+			results[i].Err = ErrRecordUpdateFailed
+			allOk = false
+			continue
+		}
+		results[i].Success = true
+	}
+
+	return results, allOk
+}
+
+// MaxZoneLookups is the maximum number of zone lookups WarmZoneCache makes at the same time,
+// so that many domains do not flood the Cloudflare API.
+const MaxZoneLookups = 4
+
+// WarmZoneCache looks up the zones of the domains in advance so that later operations will find them cached.
+// The lookups proceed in rounds: each round checks the next possible zone names of all the domains not yet
+// resolved, and the distinct names in the same round are checked concurrently, at most MaxZoneLookups at a time.
+func (h *CloudflareHandle) WarmZoneCache(ctx context.Context, ppfmt pp.PP, domains []domain.Domain) bool {
+	type pending struct {
+		domain   domain.Domain
+		splitter domain.Splitter
+	}
+
+	ok := true
+	resolved := map[domain.Domain]string{} // the zone names found (if any)
+
+	var queue []pending
+	for _, d := range domains {
+		if _, ok := h.cache.zoneOfDomain.Get(d.DNSNameASCII()); ok {
+			continue
+		}
+
+		splitter := d.Split()
+		skipPublicSuffixes(splitter)
+		if splitter.IsValid() {
+			queue = append(queue, pending{domain: d, splitter: splitter})
+		} else {
+			resolved[d] = ""
+		}
+	}
+
+	for len(queue) > 0 {
+		names := map[string]bool{}
+		for _, p := range queue {
+			names[p.splitter.ZoneNameASCII()] = true
+		}
+
+		var (
+			wg        sync.WaitGroup
+			mutex     sync.Mutex
+			results   = map[string][]string{} // nil means the lookup failed
+			semaphore = make(chan struct{}, MaxZoneLookups)
+		)
+		for name := range names {
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(name string) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+				zones, _ := h.ActiveZones(ctx, ppfmt, name)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				results[name] = zones
+			}(name)
+		}
+		wg.Wait()
+
+		next := queue[:0]
+		for _, p := range queue {
+			zones := results[p.splitter.ZoneNameASCII()]
+			switch {
+			case zones == nil: // the lookup failed and was already reported
+				ok = false
+			case len(zones) > 0:
+				resolved[p.domain] = p.splitter.ZoneNameASCII()
+			default:
+				p.splitter.Next()
+				skipPublicSuffixes(p.splitter)
+				if p.splitter.IsValid() {
+					next = append(next, p)
+				} else {
+					resolved[p.domain] = ""
+				}
+			}
+		}
+		queue = next
+	}
+
+	// All the lookups are cached now, and ZoneOfDomain reports the remaining problems.
+	zoneNameSet := map[string]bool{}
+	for _, d := range domains {
+		zoneName, isResolved := resolved[d]
+		if !isResolved {
+			continue
+		}
+		if _, found := h.ZoneOfDomain(ctx, ppfmt, d); !found {
+			ok = false
+			continue
+		}
+		zoneNameSet[zoneName] = true
+	}
+
+	if len(zoneNameSet) > 0 {
+		zoneNames := make([]string, 0, len(zoneNameSet))
+		for zoneName := range zoneNameSet {
+			zoneNames = append(zoneNames, describeZone(ppfmt, zoneName))
+		}
+		sort.Strings(zoneNames)
+		ppfmt.Infof(pp.EmojiInternet, "Pre-fetched the zones: %s", strings.Join(zoneNames, ", "))
 	}
 
-	return res.Result.ID, true
+	return ok
 }
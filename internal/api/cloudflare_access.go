@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// An accessTransport adds the headers of a Cloudflare Access service token to all requests,
+// for APIs behind Cloudflare Access policies.
+type accessTransport struct {
+	next         http.RoundTripper
+	clientID     string
+	clientSecret string
+}
+
+func (t *accessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper should not modify the original request.
+	req = req.Clone(req.Context())
+	req.Header.Set("CF-Access-Client-Id", t.clientID)
+	req.Header.Set("CF-Access-Client-Secret", t.clientSecret)
+	return t.next.RoundTrip(req) //nolint:wrapcheck
+}
+
+// accessRoundTripper wraps the transport to add the headers of the Cloudflare Access service token,
+// if any. The client ID and the client secret must be set together.
+func (t *CloudflareAuth) accessRoundTripper(ppfmt pp.PP, transport http.RoundTripper) (http.RoundTripper, bool) {
+	switch {
+	case t.AccessClientID == "" && t.AccessClientSecret == "":
+		return transport, true
+	case t.AccessClientID == "":
+		ppfmt.Errorf(pp.EmojiUserError, "The client secret of Cloudflare Access is set, but the client ID is not")
+		return nil, false
+	case t.AccessClientSecret == "":
+		ppfmt.Errorf(pp.EmojiUserError, "The client ID of Cloudflare Access is set, but the client secret is not")
+		return nil, false
+	default:
+		return &accessTransport{next: transport, clientID: t.AccessClientID, clientSecret: t.AccessClientSecret}, true
+	}
+}
@@ -0,0 +1,87 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestAccessHeaders(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		clientID     string
+		clientSecret string
+	}{
+		"set":   {"client.access", "secret789"},
+		"unset": {"", ""},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, auth := newServerAuth(t)
+			auth.AccessClientID = tc.clientID
+			auth.AccessClientSecret = tc.clientSecret
+
+			accessCount := 0
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				accessCount++
+				if tc.clientID == "" {
+					require.NotContains(t, r.Header, "Cf-Access-Client-Id")
+					require.NotContains(t, r.Header, "Cf-Access-Client-Secret")
+				} else {
+					require.Equal(t, []string{tc.clientID}, r.Header["Cf-Access-Client-Id"])
+					require.Equal(t, []string{tc.clientSecret}, r.Header["Cf-Access-Client-Secret"])
+				}
+				handleTokensVerify(t, w, r)
+			})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.True(t, ok)
+			require.NotNil(t, h)
+			require.Equal(t, 1, accessCount)
+		})
+	}
+}
+
+func TestAccessHeadersIncomplete(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		clientID     string
+		clientSecret string
+		message      string
+	}{
+		"no-id":     {"", "secret789", "The client secret of Cloudflare Access is set, but the client ID is not"},
+		"no-secret": {"client.access", "", "The client ID of Cloudflare Access is set, but the client secret is not"},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, auth := newServerAuth(t)
+			auth.AccessClientID = tc.clientID
+			auth.AccessClientSecret = tc.clientSecret
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				require.Fail(t, "unexpected API call")
+			})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError, tc.message)
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.False(t, ok)
+			require.Nil(t, h)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+var (
+	// errBatchUnsupported means the batch endpoint is not available (HTTP status 404 or 405).
+	errBatchUnsupported = errors.New("the batch endpoint is not available")
+	// errBatchFailed means the batch endpoint refused the operations.
+	errBatchFailed = errors.New("the batch endpoint refused the operations")
+)
+
+// A batchOperation changes the IP address of an existing DNS record.
+type batchOperation struct {
+	zoneID string
+	id     string
+	ip     netip.Addr
+}
+
+type batchPatch struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+type batchPayload struct {
+	Patches []batchPatch `json:"patches"`
+}
+
+// postBatch sends one batch to the batch endpoint of a zone. cloudflare-go does not support
+// the endpoint and hides the HTTP status of some errors, so the request is made directly.
+func (h *CloudflareHandle) postBatch(ctx context.Context, zoneID string, payload batchPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/zones/%s/dns_records/batch", h.cf.BaseURL, zoneID), bytes.NewReader(body))
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.usesAPIKey {
+		req.Header.Set("X-Auth-Key", h.cf.APIKey)
+		req.Header.Set("X-Auth-Email", h.cf.APIEmail)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+h.cf.APIToken)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return errBatchUnsupported
+	}
+
+	var res cloudflare.Response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("%w (HTTP status %d): %v", errBatchFailed, resp.StatusCode, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest || !res.Success {
+		messages := make([]string, 0, len(res.Errors))
+		for _, e := range res.Errors {
+			messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+		}
+		return fmt.Errorf("%w (HTTP status %d): %s", errBatchFailed, resp.StatusCode, strings.Join(messages, ", "))
+	}
+
+	return nil
+}
+
+// batchWrite applies the operations with one call to the batch endpoint per zone.
+// It returns errBatchUnsupported if the endpoint is not available. The cache is not touched.
+func (h *CloudflareHandle) batchWrite(ctx context.Context, ops []batchOperation) error {
+	var zones []string
+	payloads := map[string]*batchPayload{}
+	for _, op := range ops {
+		if _, ok := payloads[op.zoneID]; !ok {
+			zones = append(zones, op.zoneID)
+			payloads[op.zoneID] = &batchPayload{Patches: nil}
+		}
+		payloads[op.zoneID].Patches = append(payloads[op.zoneID].Patches,
+			batchPatch{ID: op.id, Content: op.ip.String()})
+	}
+
+	for _, zone := range zones {
+		if err := h.postBatch(ctx, zone, *payloads[zone]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tryBatchUpdate applies the pending updates (those without results yet) with the batch endpoint.
+// It returns false if the updates should be done one by one instead.
+func (h *CloudflareHandle) tryBatchUpdate(ctx context.Context, ppfmt pp.PP,
+	updates []RecordUpdate, results []RecordUpdateResult,
+) bool {
+	var (
+		pending []int
+		ops     []batchOperation
+	)
+	for i, u := range updates {
+		if results[i].Err != nil || results[i].Success {
+			continue
+		}
+
+		if !h.checkIP(ppfmt, u.Domain, u.IP) {
+			results[i].Err = ErrRecordUpdateFailed
+			continue
+		}
+
+		zone, ok := h.ZoneOfDomain(ctx, ppfmt, u.Domain)
+		if !ok {
+			results[i].Err = ErrRecordUpdateFailed
+			continue
+		}
+
+		pending = append(pending, i)
+		ops = append(ops, batchOperation{zoneID: zone, id: u.ID, ip: u.IP})
+	}
+
+	if len(ops) == 0 {
+		return true
+	}
+
+	err := h.batchWrite(ctx, ops)
+	switch {
+	case errors.Is(err, errBatchUnsupported):
+		ppfmt.Infof(pp.EmojiExperimental, "The batch endpoint is not available; updating the records one by one")
+		return false
+
+	case err != nil:
+		ppfmt.Warningf(pp.EmojiError, "Failed to update %d records with the batch endpoint: %v", len(ops), err)
+		for _, i := range pending {
+			u := updates[i]
+			h.recordEvent(u.Domain, u.IPNet, ActionUpdated, u.ID, h.cachedIP(u.Domain, u.IPNet, u.ID), u.IP, false)
+			results[i].Err = ErrRecordUpdateFailed
+			h.cache.listRecords[u.IPNet].Delete(u.Domain.DNSNameASCII())
+		}
+		return true
+	}
+
+	for _, i := range pending {
+		u := updates[i]
+		h.recordEvent(u.Domain, u.IPNet, ActionUpdated, u.ID, h.cachedIP(u.Domain, u.IPNet, u.ID), u.IP, true)
+		results[i].Success = true
+		if rmap, ok := h.cache.listRecords[u.IPNet].Get(u.Domain.DNSNameASCII()); ok {
+			rmap[u.ID] = u.IP
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func newBatchHandle(t *testing.T) (*http.ServeMux, api.Handle) {
+	t.Helper()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+	auth.UseBatchAPI = true
+
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
+
+	return mux, h
+}
+
+//nolint:funlen
+func TestBatchUpdateBatchAPI(t *testing.T) {
+	t.Parallel()
+
+	updates := []api.RecordUpdate{
+		{Domain: domain.FQDN("a.test.org"), IPNet: ipnet.IP6, ID: "record1", IP: mustIP("::2")},
+		{Domain: domain.FQDN("b.test.org"), IPNet: ipnet.IP6, ID: "record2", IP: mustIP("::2")},
+		{Domain: domain.FQDN("c.test.org"), IPNet: ipnet.IP6, ID: "record3", IP: mustIP("::2")},
+	}
+	records := map[string]map[string]string{
+		"a.test.org": {"record1": "::1"},
+		"b.test.org": {"record2": "::1"},
+		"c.test.org": {"record3": "::2"},
+	}
+
+	for name, tc := range map[string]struct {
+		batchStatus   int
+		individual    bool
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"success": {http.StatusOK, false, true, nil},
+		"404-fallback": {
+			http.StatusNotFound, true, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiExperimental,
+					"The batch endpoint is not available; updating the records one by one")
+			},
+		},
+		"405-fallback": {
+			http.StatusMethodNotAllowed, true, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiExperimental,
+					"The batch endpoint is not available; updating the records one by one")
+			},
+		},
+		"refused": {
+			http.StatusBadRequest, false, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update %d records with the batch endpoint: %v",
+					2, gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newBatchHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 4)
+
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+
+					name := r.URL.Query().Get("name")
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, name, records[name]))
+					require.NoError(t, err)
+				})
+
+			batchCalls := 0
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/batch", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodPost, r.Method)
+					require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+					batchCalls++
+
+					var payload struct {
+						Patches []struct {
+							ID      string `json:"id"`
+							Content string `json:"content"`
+						} `json:"patches"`
+					}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+					require.Len(t, payload.Patches, 2)
+					require.Equal(t, "record1", payload.Patches[0].ID)
+					require.Equal(t, "::2", payload.Patches[0].Content)
+					require.Equal(t, "record2", payload.Patches[1].ID)
+					require.Equal(t, "::2", payload.Patches[1].Content)
+
+					w.Header().Set("content-type", "application/json")
+					w.WriteHeader(tc.batchStatus)
+					switch tc.batchStatus {
+					case http.StatusOK:
+						fmt.Fprintf(w, `{"success": true, "errors": [], "messages": [], "result": {}}`)
+					default:
+						fmt.Fprintf(w,
+							`{"success": false, "errors": [{"code": 9000, "message": "Bad batch"}], "messages": [], "result": null}`)
+					}
+				})
+
+			individualCalls := 0
+			for _, u := range updates[:2] {
+				u := u
+				mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/%s", mockID("test.org", 0), u.ID),
+					func(w http.ResponseWriter, r *http.Request) {
+						require.Equal(t, http.MethodPatch, r.Method)
+						individualCalls++
+
+						w.Header().Set("content-type", "application/json")
+						err := json.NewEncoder(w).Encode(
+							mockDNSRecordResponse(u.ID, ipnet.IP6, u.Domain.DNSNameASCII(), u.IP.String()))
+						require.NoError(t, err)
+					})
+			}
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			results, ok := h.BatchUpdate(context.Background(), mockPP, updates)
+			require.Equal(t, tc.ok, ok)
+			require.Len(t, results, len(updates))
+			for i, result := range results {
+				require.Equal(t, updates[i].Domain, result.Domain)
+				require.Equal(t, tc.ok || records[updates[i].Domain.DNSNameASCII()][updates[i].ID] == "::2",
+					result.Success)
+			}
+			require.Equal(t, 1, batchCalls)
+			if tc.individual {
+				require.Equal(t, 2, individualCalls)
+			} else {
+				require.Zero(t, individualCalls)
+			}
+			require.True(t, zh.isExhausted())
+		})
+	}
+}
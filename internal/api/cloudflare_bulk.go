@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sync"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A newRecordPayload is the payload to create a DNS record. The DNSRecord type of
+// cloudflare-go does not have the comment field, so the raw API is used.
+type newRecordPayload struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// createRawRecord creates a DNS record in a zone and returns its ID. It does not touch the cache.
+func (h *CloudflareHandle) createRawRecord(ctx context.Context, zoneID string, r NewRecord) (string, error) {
+	payload := newRecordPayload{
+		Name:    r.Domain.DNSNameASCII(),
+		Type:    r.IPNet.RecordType(),
+		Content: r.IP.String(),
+		TTL:     r.TTL.Int(),
+		Proxied: r.Proxied,
+		Comment: r.Comment,
+	}
+
+	raw, err := h.cf.Raw(ctx, http.MethodPost, fmt.Sprintf("/zones/%s/dns_records", zoneID), payload, nil)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	var res struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	return res.ID, nil
+}
+
+// BulkCreateRecords creates many DNS records concurrently, for example, when setting up many domains
+// for the first time. It returns the IDs of the new records in the same order as the input, with the
+// empty string for each record that could not be created.
+func (h *CloudflareHandle) BulkCreateRecords(ctx context.Context, ppfmt pp.PP, records []NewRecord) ([]string, bool) {
+	ok := true
+
+	// Zones are looked up one by one so that each zone is only looked up once.
+	zones := make([]string, len(records))
+	for i, r := range records {
+		if !h.checkIP(ppfmt, r.Domain, r.IP) {
+			ok = false
+			continue
+		}
+
+		zone, found := h.ZoneOfDomain(ctx, ppfmt, r.Domain)
+		if !found {
+			ok = false
+			continue
+		}
+		zones[i] = zone
+	}
+
+	ids := make([]string, len(records))
+	errs := make([]error, len(records))
+	var wg sync.WaitGroup
+	for i, r := range records {
+		if zones[i] == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, r NewRecord) {
+			defer wg.Done()
+			ids[i], errs[i] = h.createRawRecord(ctx, zones[i], r)
+		}(i, r)
+	}
+	wg.Wait()
+
+	// The cache is updated afterwards because the cached record lists are not thread-safe.
+	for i, r := range records {
+		if zones[i] == "" {
+			continue
+		}
+
+		h.recordEvent(r.Domain, r.IPNet, ActionCreated, ids[i], netip.Addr{}, r.IP, errs[i] == nil)
+		h.cache.listByType.Delete(recordKey{name: r.Domain.DNSNameASCII(), recordType: r.IPNet.RecordType()})
+		if errs[i] != nil {
+			ppfmt.Warningf(pp.EmojiError, "Failed to add a new %s record of %q: %v",
+				r.IPNet.RecordType(), r.Domain.Describe(), errs[i])
+			h.cache.listRecords[r.IPNet].Delete(r.Domain.DNSNameASCII())
+			ok = false
+			continue
+		}
+
+		if rmap, found := h.cache.listRecords[r.IPNet].Get(r.Domain.DNSNameASCII()); found {
+			rmap[ids[i]] = r.IP
+		}
+	}
+
+	return ids, ok
+}
@@ -0,0 +1,173 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// handleBulkCreate accepts new records in a zone, refusing the names in failing.
+// It returns the received comments, keyed by record names.
+func handleBulkCreate(t *testing.T, mux *http.ServeMux, zoneID string, failing map[string]bool) map[string]string {
+	t.Helper()
+
+	var mutex sync.Mutex
+	comments := map[string]string{}
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", zoneID), func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+		var record struct {
+			Name    string `json:"name"`
+			Type    string `json:"type"`
+			Content string `json:"content"`
+			TTL     int    `json:"ttl"`
+			Proxied bool   `json:"proxied"`
+			Comment string `json:"comment"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+
+		w.Header().Set("content-type", "application/json")
+		if failing[record.Name] {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w,
+				`{"success": false, "errors": [{"code": 9000, "message": "Bad record"}], "messages": [], "result": null}`)
+			return
+		}
+
+		mutex.Lock()
+		comments[record.Name] = record.Comment
+		mutex.Unlock()
+
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"success":  true,
+			"errors":   []any{},
+			"messages": []any{},
+			"result": map[string]any{
+				"id":      "id-" + record.Name,
+				"name":    record.Name,
+				"type":    record.Type,
+				"content": record.Content,
+				"ttl":     record.TTL,
+				"proxied": record.Proxied,
+			},
+		})
+		require.NoError(t, err)
+	})
+
+	return comments
+}
+
+//nolint:funlen
+func TestBulkCreateRecords(t *testing.T) {
+	t.Parallel()
+
+	records := []api.NewRecord{
+		{
+			Domain: domain.FQDN("a.test.org"), IPNet: ipnet.IP4, IP: mustIP("1.1.1.1"),
+			TTL: api.TTLAuto, Proxied: false, Comment: "",
+		},
+		{
+			Domain: domain.FQDN("b.test.org"), IPNet: ipnet.IP6, IP: mustIP("::1"),
+			TTL: 100, Proxied: true, Comment: "managed by ddns",
+		},
+		{
+			Domain: domain.FQDN("c.test.org"), IPNet: ipnet.IP6, IP: mustIP("::2"),
+			TTL: api.TTLAuto, Proxied: false, Comment: "",
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		failing       map[string]bool
+		expected      []string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"success": {
+			nil,
+			[]string{"id-a.test.org", "id-b.test.org", "id-c.test.org"},
+			true,
+			nil,
+		},
+		"partial-failure": {
+			map[string]bool{"b.test.org": true},
+			[]string{"id-a.test.org", "", "id-c.test.org"},
+			false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to add a new %s record of %q: %v",
+					"AAAA", "b.test.org", gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 4)
+
+			comments := handleBulkCreate(t, mux, mockID("test.org", 0), tc.failing)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ids, ok := h.(*api.CloudflareHandle).BulkCreateRecords(context.Background(), mockPP, records)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, ids)
+			require.True(t, zh.isExhausted())
+			if !tc.failing["b.test.org"] {
+				require.Equal(t, "managed by ddns", comments["b.test.org"])
+			}
+			require.Equal(t, "", comments["a.test.org"])
+		})
+	}
+}
+
+func TestBulkCreateRecordsZoneInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 5)
+
+	handleBulkCreate(t, mux, mockID("test.org", 0), nil)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "a.test.com"),
+		mockPP.EXPECT().Warningf(pp.EmojiUserError,
+			"No zone accessible with the API token contains %q; is the domain on Cloudflare?", "a.test.com"),
+	)
+	ids, ok := h.(*api.CloudflareHandle).BulkCreateRecords(context.Background(), mockPP, []api.NewRecord{
+		{
+			Domain: domain.FQDN("a.test.com"), IPNet: ipnet.IP4, IP: mustIP("1.1.1.1"),
+			TTL: api.TTLAuto, Proxied: false, Comment: "",
+		},
+		{
+			Domain: domain.FQDN("test.org"), IPNet: ipnet.IP4, IP: mustIP("1.1.1.1"),
+			TTL: api.TTLAuto, Proxied: false, Comment: "",
+		},
+	})
+	require.False(t, ok)
+	require.Equal(t, []string{"", "id-test.org"}, ids)
+	require.True(t, zh.isExhausted())
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// commentedRecordsPerPage is the page size when listing records with comments.
+const commentedRecordsPerPage = 100
+
+// A commentedRecord is a DNS record with its comment. The DNSRecord type of
+// cloudflare-go does not have the comment field, so the raw API is used.
+type commentedRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Comment string `json:"comment"`
+}
+
+// listCommentedRecords lists all DNS records in a zone whose comments contain the substring.
+func (h *CloudflareHandle) listCommentedRecords(ctx context.Context, zoneID, commentSubstring string,
+) ([]commentedRecord, error) {
+	var records []commentedRecord
+
+	for page := 1; ; page++ {
+		query := url.Values{
+			"comment.contains": {commentSubstring},
+			"page":             {strconv.Itoa(page)},
+			"per_page":         {strconv.Itoa(commentedRecordsPerPage)},
+		}
+
+		raw, err := h.cf.Raw(ctx, http.MethodGet,
+			fmt.Sprintf("/zones/%s/dns_records?%s", zoneID, query.Encode()), nil, nil)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		var rs []commentedRecord
+		if err := json.Unmarshal(raw, &rs); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		// The substring is checked again in case the filter was ignored by the server.
+		for _, r := range rs {
+			if strings.Contains(r.Comment, commentSubstring) {
+				records = append(records, r)
+			}
+		}
+
+		if len(rs) < commentedRecordsPerPage {
+			return records, nil
+		}
+	}
+}
+
+// DeleteRecordsByComment deletes all DNS records in a zone whose comments contain the substring,
+// for example, to clean up the records created by a specific invocation of some tool.
+// It returns the number of deleted records, which can be positive even if some deletions failed.
+// An empty substring is rejected because it would match every record in the zone.
+func (h *CloudflareHandle) DeleteRecordsByComment(ctx context.Context, ppfmt pp.PP,
+	zoneID, commentSubstring string,
+) (int, bool) {
+	if strings.TrimSpace(commentSubstring) == "" {
+		ppfmt.Errorf(pp.EmojiUserError,
+			"The comment substring to delete records by is empty; it would match all records in the zone %q", zoneID)
+		return 0, false
+	}
+
+	records, err := h.listCommentedRecords(ctx, zoneID, commentSubstring)
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve records in the zone %q: %v", zoneID, err)
+		return 0, false
+	}
+
+	count := 0
+	ok := true
+	for _, r := range records {
+		description := domain.FQDN(r.Name).Describe()
+
+		err := h.cf.DeleteDNSRecord(ctx, zoneID, r.ID)
+		h.cache.listByType.Delete(recordKey{name: r.Name, recordType: r.Type})
+		h.cache.recordDetail.Delete(r.ID)
+		if err != nil {
+			ppfmt.Warningf(pp.EmojiError, "Failed to delete a %s record of %q (ID: %s): %v",
+				r.Type, description, r.ID, err)
+			ok = false
+			continue
+		}
+
+		ppfmt.Infof(pp.EmojiDelRecord, "Deleted a %s record of %q (ID: %s) with the comment %q",
+			r.Type, description, r.ID, r.Comment)
+		count++
+
+		for _, c := range h.cache.listRecords {
+			c.Delete(r.Name)
+		}
+	}
+
+	return count, ok
+}
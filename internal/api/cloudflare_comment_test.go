@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+type commentedRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Comment string `json:"comment"`
+}
+
+//nolint:funlen
+func TestDeleteRecordsByComment(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	zoneID := mockID("test.org", 0)
+
+	// The server ignores the filter to check that the comments are also checked locally.
+	records := []commentedRecord{
+		{"record1", "A", "a.test.org", "127.0.0.1", "created by run-42"},
+		{"record2", "AAAA", "a.test.org", "::1", "created by run-43"},
+		{"record3", "A", "b.test.org", "127.0.0.2", ""},
+		{"record4", "TXT", "b.test.org", "hello", "run-42 (verification)"},
+	}
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", zoneID),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+			require.Equal(t, "run-42", r.URL.Query().Get("comment.contains"))
+			require.Equal(t, "1", r.URL.Query().Get("page"))
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(map[string]any{
+				"success":  true,
+				"errors":   []any{},
+				"messages": []any{},
+				"result":   records,
+			})
+			require.NoError(t, err)
+		})
+
+	deleted := map[string]bool{}
+	for _, record := range records {
+		record := record
+		mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, record.ID),
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodDelete, r.Method)
+				deleted[record.ID] = true
+
+				w.Header().Set("content-type", "application/json")
+				//nolint:exhaustruct // Other fields are intentionally omitted
+				err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(&cloudflare.DNSRecord{ID: record.ID}))
+				require.NoError(t, err)
+			})
+	}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Infof(pp.EmojiDelRecord, "Deleted a %s record of %q (ID: %s) with the comment %q",
+			"A", "a.test.org", "record1", "created by run-42"),
+		mockPP.EXPECT().Infof(pp.EmojiDelRecord, "Deleted a %s record of %q (ID: %s) with the comment %q",
+			"TXT", "b.test.org", "record4", "run-42 (verification)"),
+	)
+	count, ok := h.(*api.CloudflareHandle).DeleteRecordsByComment(context.Background(), mockPP, zoneID, "run-42")
+	require.True(t, ok)
+	require.Equal(t, 2, count)
+	for _, record := range records {
+		require.Equal(t, strings.Contains(record.Comment, "run-42"), deleted[record.ID], record.ID)
+	}
+}
+
+func TestDeleteRecordsByCommentInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records in the zone %q: %v", "zone", gomock.Any())
+	count, ok := h.(*api.CloudflareHandle).DeleteRecordsByComment(context.Background(), mockPP, "zone", "run-42")
+	require.False(t, ok)
+	require.Zero(t, count)
+}
+
+func TestDeleteRecordsByCommentEmpty(t *testing.T) {
+	t.Parallel()
+
+	for name, substring := range map[string]string{
+		"empty":      "",
+		"whitespace": " \t",
+	} {
+		substring := substring
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+			mux.HandleFunc("/zones/zone/dns_records", func(w http.ResponseWriter, r *http.Request) {
+				require.Fail(t, "records should not be listed")
+			})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError,
+				"The comment substring to delete records by is empty; it would match all records in the zone %q", "zone")
+			count, ok := h.(*api.CloudflareHandle).DeleteRecordsByComment(context.Background(), mockPP, "zone", substring)
+			require.False(t, ok)
+			require.Zero(t, count)
+		})
+	}
+}
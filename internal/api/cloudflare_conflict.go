@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// statusKey is the context key of the place to record the HTTP status of a response.
+type statusKey struct{}
+
+// withStatusRecorder returns a context that makes statusTransport record the HTTP status
+// of the responses to status.
+func withStatusRecorder(ctx context.Context, status *int) context.Context {
+	return context.WithValue(ctx, statusKey{}, status)
+}
+
+// A statusTransport records the HTTP status of responses for the requests made with withStatusRecorder.
+// cloudflare-go reports most 4xx responses as RequestError, which hides the HTTP status.
+type statusTransport struct {
+	next http.RoundTripper
+}
+
+func (t *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if status, ok := req.Context().Value(statusKey{}).(*int); ok && err == nil {
+		*status = resp.StatusCode
+	}
+	return resp, err //nolint:wrapcheck
+}
+
+// resolveUpdateConflict handles 409 Conflict of an update of a DNS record, which can happen
+// when another instance of the tool updates the same record at the same time. The records are
+// fetched again, and the update is considered done if the record already has the IP address.
+// Otherwise, the update is retried once. DNS records have no ETags, so there is nothing else to refresh.
+// The original error is returned if the record is gone.
+func (h *CloudflareHandle) resolveUpdateConflict(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, zone, id string, payload cloudflare.DNSRecord, ip netip.Addr, err error,
+) error {
+	ppfmt.Infof(pp.EmojiRepeatOnce,
+		"The %s record of %q (ID: %s) was being changed elsewhere; checking it again",
+		ipNet.RecordType(), domain.Describe(), id)
+
+	h.cache.listRecords[ipNet].Delete(domain.DNSNameASCII())
+	rmap, ok := h.ListRecords(ctx, ppfmt, domain, ipNet)
+	if !ok {
+		return err
+	}
+
+	current, found := rmap[id]
+	switch {
+	case !found:
+		return err
+	case current == ip:
+		return nil
+	default:
+		return h.cf.UpdateDNSRecord(ctx, zone, id, payload) //nolint:wrapcheck
+	}
+}
@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+//nolint:funlen
+func TestUpdateRecordConflict(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		currentRecords     map[string]string
+		patchStatuses      []int
+		expectedOK         bool
+		expectedPatchCount int64
+		prepareMockPP      func(*mocks.MockPP)
+	}{
+		"retried": {
+			map[string]string{"record1": "::1"},
+			[]int{http.StatusConflict, http.StatusOK},
+			true, 2,
+			nil,
+		},
+		"done-elsewhere": {
+			map[string]string{"record1": "::2"},
+			[]int{http.StatusConflict},
+			true, 1,
+			nil,
+		},
+		"gone": {
+			map[string]string{},
+			[]int{http.StatusConflict},
+			false, 1,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
+					"AAAA", "sub.test.org", "record1", gomock.Any())
+			},
+		},
+		"conflict-again": {
+			map[string]string{"record1": "::1"},
+			[]int{http.StatusConflict, http.StatusConflict},
+			false, 2,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
+					"AAAA", "sub.test.org", "record1", gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org", tc.currentRecords))
+					require.NoError(t, err)
+				})
+
+			var patchCount atomic.Int64
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodPatch, r.Method)
+					count := patchCount.Add(1)
+					require.LessOrEqual(t, count, int64(len(tc.patchStatuses)))
+
+					w.Header().Set("content-type", "application/json")
+					if status := tc.patchStatuses[count-1]; status != http.StatusOK {
+						w.WriteHeader(status)
+						err := json.NewEncoder(w).Encode(map[string]any{
+							"success":  false,
+							"errors":   []any{map[string]any{"code": 1000, "message": "conflict"}},
+							"messages": []any{},
+							"result":   nil,
+						})
+						require.NoError(t, err)
+						return
+					}
+
+					err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "sub.test.org", "::2"))
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Infof(pp.EmojiRepeatOnce,
+				"The %s record of %q (ID: %s) was being changed elsewhere; checking it again",
+				"AAAA", "sub.test.org", "record1")
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6,
+				"record1", mustIP("::2"))
+			require.Equal(t, tc.expectedOK, ok)
+			require.Equal(t, tc.expectedPatchCount, patchCount.Load())
+			require.True(t, zh.isExhausted())
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// isForbidden checks whether the error is 403 Forbidden, which cloudflare-go reports as an AuthenticationError.
+// The API tokens created from the "Edit zone DNS" template are forbidden to read most zone settings.
+func isForbidden(err error) bool {
+	var authErr *cloudflare.AuthenticationError
+	return errors.As(err, &authErr)
+}
+
+// DNSSECStatus returns the DNSSEC status of a zone, such as "active", "pending", "disabled", or "error".
+func (h *CloudflareHandle) DNSSECStatus(ctx context.Context, ppfmt pp.PP, zoneID string) (string, bool) {
+	return h.dnssecStatus(ctx, ppfmt, zoneID, false)
+}
+
+// dnssecStatus is DNSSECStatus, except that 403 Forbidden is not logged when quietIfForbidden is true.
+func (h *CloudflareHandle) dnssecStatus(ctx context.Context, ppfmt pp.PP,
+	zoneID string, quietIfForbidden bool,
+) (string, bool) {
+	res, err := h.cf.ZoneDNSSECSetting(ctx, zoneID)
+	if err != nil {
+		if quietIfForbidden && isForbidden(err) {
+			return "", false
+		}
+		ppfmt.Warningf(pp.EmojiError, "Failed to check the DNSSEC status of the zone %q: %v", zoneID, err)
+		return "", false
+	}
+
+	return res.Status, true
+}
+
+// checkDNSSEC warns about DNSSEC statuses that may break the resolution of updated records.
+func (h *CloudflareHandle) checkDNSSEC(ctx context.Context, ppfmt pp.PP, zoneID string, zoneDescription string) {
+	status, ok := h.dnssecStatus(ctx, ppfmt, zoneID, true)
+	if !ok {
+		return
+	}
+
+	switch status {
+	case "error":
+		ppfmt.Warningf(pp.EmojiWarning,
+			"DNSSEC of the zone of %q is in an error state; updated records may fail validation", zoneDescription)
+	case "disabled":
+		ppfmt.Warningf(pp.EmojiUserWarning,
+			"DNSSEC of the zone of %q is disabled; updated records will not be signed", zoneDescription)
+	}
+}
+
+// DevelopmentModeActive checks whether Development Mode (which bypasses the cache of Cloudflare) is on for a zone.
+func (h *CloudflareHandle) DevelopmentModeActive(ctx context.Context, ppfmt pp.PP, zoneID string) (bool, bool) {
+	return h.developmentModeActive(ctx, ppfmt, zoneID, false)
+}
+
+// developmentModeActive is DevelopmentModeActive, except that 403 Forbidden is not logged
+// when quietIfForbidden is true.
+func (h *CloudflareHandle) developmentModeActive(ctx context.Context, ppfmt pp.PP,
+	zoneID string, quietIfForbidden bool,
+) (bool, bool) {
+	res, err := h.cf.ZoneSingleSetting(ctx, zoneID, "development_mode")
+	if err != nil {
+		if quietIfForbidden && isForbidden(err) {
+			return false, false
+		}
+		ppfmt.Warningf(pp.EmojiError, "Failed to check the Development Mode of the zone %q: %v", zoneID, err)
+		return false, false
+	}
+
+	switch res.Value {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	default:
+		ppfmt.Warningf(pp.EmojiImpossible, "Unexpected value of the Development Mode of the zone %q: %v", zoneID, res.Value)
+		return false, false
+	}
+}
+
+// checkDevelopmentMode warns about Development Mode, which is often turned on for debugging and then forgotten.
+func (h *CloudflareHandle) checkDevelopmentMode(ctx context.Context, ppfmt pp.PP,
+	zoneID string, zoneDescription string,
+) {
+	if active, ok := h.developmentModeActive(ctx, ppfmt, zoneID, true); ok && active {
+		ppfmt.Warningf(pp.EmojiUserWarning,
+			"Development Mode of the zone of %q is on; the cache of Cloudflare is bypassed", zoneDescription)
+	}
+}
+
+// DiagnoseZones checks whether the zones of the domains are on hold and whether their settings
+// may cause problems.
+// Each zone is checked only once. Failures to look up the zones are logged but otherwise ignored,
+// and checks forbidden to the API token (which is common for tokens only meant to edit DNS records)
+// are skipped silently.
+func (h *CloudflareHandle) DiagnoseZones(ctx context.Context, ppfmt pp.PP, domains []domain.Domain) {
+	checked := map[string]bool{}
+	for _, d := range domains {
+		zoneID, ok := h.ZoneOfDomain(ctx, ppfmt, d)
+		if !ok || checked[zoneID] {
+			continue
+		}
+		checked[zoneID] = true
+
+		h.zoneHoldStatus(ctx, ppfmt, zoneID, true)
+		h.checkDNSSEC(ctx, ppfmt, zoneID, d.Describe())
+		h.checkDevelopmentMode(ctx, ppfmt, zoneID, d.Describe())
+	}
+}
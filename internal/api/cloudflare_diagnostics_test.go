@@ -0,0 +1,266 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func handleDNSSEC(t *testing.T, mux *http.ServeMux, zoneID string, status string) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dnssec", zoneID), func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"success":  true,
+			"errors":   []any{},
+			"messages": []any{},
+			"result":   map[string]any{"status": status},
+		})
+		require.NoError(t, err)
+	})
+}
+
+func handleDevelopmentMode(t *testing.T, mux *http.ServeMux, zoneID string, value string) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/settings/development_mode", zoneID),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(map[string]any{
+				"success":  true,
+				"errors":   []any{},
+				"messages": []any{},
+				"result":   map[string]any{"id": "development_mode", "value": value},
+			})
+			require.NoError(t, err)
+		})
+}
+
+func TestDNSSECStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range [...]string{"active", "disabled", "error"} {
+		status := status
+		t.Run(status, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+			handleDNSSEC(t, mux, mockID("test.org", 0), status)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			result, ok := h.(*api.CloudflareHandle).DNSSECStatus(context.Background(), mockPP, mockID("test.org", 0))
+			require.True(t, ok)
+			require.Equal(t, status, result)
+		})
+	}
+}
+
+func TestDNSSECStatusInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the DNSSEC status of the zone %q: %v", "zone", gomock.Any())
+	result, ok := h.(*api.CloudflareHandle).DNSSECStatus(context.Background(), mockPP, "zone")
+	require.False(t, ok)
+	require.Empty(t, result)
+}
+
+func TestDiagnoseZonesDNSSEC(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		status        string
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"active": {"active", nil},
+		"disabled": {
+			"disabled",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiUserWarning,
+					"DNSSEC of the zone of %q is disabled; updated records will not be signed", "a.test.org")
+			},
+		},
+		"error": {
+			"error",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning,
+					"DNSSEC of the zone of %q is in an error state; updated records may fail validation", "a.test.org")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 3)
+
+			handleZoneHold(t, mux, mockID("test.org", 0), map[string]any{"hold": false})
+			handleDNSSEC(t, mux, mockID("test.org", 0), tc.status)
+			handleDevelopmentMode(t, mux, mockID("test.org", 0), "off")
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			// Both domains are in the same zone, which should be checked only once.
+			h.(*api.CloudflareHandle).DiagnoseZones(context.Background(), mockPP,
+				[]domain.Domain{domain.FQDN("a.test.org"), domain.FQDN("b.test.org")})
+			require.True(t, zh.isExhausted())
+		})
+	}
+}
+
+func TestDevelopmentModeActive(t *testing.T) {
+	t.Parallel()
+
+	for value, active := range map[string]bool{"on": true, "off": false} {
+		value, active := value, active
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+			handleDevelopmentMode(t, mux, mockID("test.org", 0), value)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			result, ok := h.(*api.CloudflareHandle).DevelopmentModeActive(context.Background(), mockPP,
+				mockID("test.org", 0))
+			require.True(t, ok)
+			require.Equal(t, active, result)
+		})
+	}
+}
+
+func TestDevelopmentModeActiveInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		value         string
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"unexpected": {
+			"maybe",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiImpossible, "Unexpected value of the Development Mode of the zone %q: %v",
+					mockID("test.org", 0), "maybe")
+			},
+		},
+		"missing": {
+			"",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to check the Development Mode of the zone %q: %v",
+					mockID("test.org", 0), gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+			if tc.value != "" {
+				handleDevelopmentMode(t, mux, mockID("test.org", 0), tc.value)
+			}
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+			result, ok := h.(*api.CloudflareHandle).DevelopmentModeActive(context.Background(), mockPP,
+				mockID("test.org", 0))
+			require.False(t, ok)
+			require.False(t, result)
+		})
+	}
+}
+
+func TestDiagnoseZonesDevelopmentMode(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	handleZoneHold(t, mux, mockID("test.org", 0), map[string]any{"hold": false})
+	handleDNSSEC(t, mux, mockID("test.org", 0), "active")
+	handleDevelopmentMode(t, mux, mockID("test.org", 0), "on")
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiUserWarning,
+		"Development Mode of the zone of %q is on; the cache of Cloudflare is bypassed", "sub.test.org")
+	h.(*api.CloudflareHandle).DiagnoseZones(context.Background(), mockPP, []domain.Domain{domain.FQDN("sub.test.org")})
+	require.True(t, zh.isExhausted())
+}
+
+func TestDiagnoseZonesHold(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	handleZoneHold(t, mux, mockID("test.org", 0), map[string]any{"hold": true})
+	handleDNSSEC(t, mux, mockID("test.org", 0), "active")
+	handleDevelopmentMode(t, mux, mockID("test.org", 0), "off")
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Errorf(pp.EmojiUserError,
+		"The zone %q is on hold; updates of its DNS records will fail until the hold is released", "test.org")
+	h.(*api.CloudflareHandle).DiagnoseZones(context.Background(), mockPP, []domain.Domain{domain.FQDN("sub.test.org")})
+	require.True(t, zh.isExhausted())
+}
+
+func TestDiagnoseZonesForbidden(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	forbidden := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, err := w.Write([]byte(
+			`{"success": false, "errors": [{"code": 10000, "message": "Authentication error"}], "messages": [], "result": null}`, //nolint:lll
+		))
+		require.NoError(t, err)
+	}
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/hold", mockID("test.org", 0)), forbidden)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dnssec", mockID("test.org", 0)), forbidden)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/settings/development_mode", mockID("test.org", 0)), forbidden)
+
+	// A token that can only edit DNS records should not cause any warnings.
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h.(*api.CloudflareHandle).DiagnoseZones(context.Background(), mockPP, []domain.Domain{domain.FQDN("sub.test.org")})
+	require.True(t, zh.isExhausted())
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"crypto/tls"
+	"strconv"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+	"github.com/favonia/cloudflare-ddns/internal/file"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// splitQuoted splits a value starting with the quote into the quoted part (including the quotes)
+// and checks that only a comment (if any) follows it.
+func splitQuoted(value string, quote string) (string, bool) {
+	end := strings.LastIndex(value, quote)
+	if end == 0 {
+		return "", false
+	}
+	rest := strings.TrimSpace(value[end+1:])
+	return value[:end+1], rest == "" || strings.HasPrefix(rest, "#")
+}
+
+// parseDotEnvValue unquotes a value in a .env file or strips its trailing comment.
+func parseDotEnvValue(value string) (string, bool) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		quoted, ok := splitQuoted(value, `"`)
+		if !ok {
+			return "", false
+		}
+		unquoted, err := strconv.Unquote(quoted)
+		return unquoted, err == nil
+	case strings.HasPrefix(value, "'"):
+		quoted, ok := splitQuoted(value, "'")
+		if !ok {
+			return "", false
+		}
+		return quoted[1 : len(quoted)-1], true // single-quoted values are taken literally
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), true
+	}
+}
+
+// parseDotEnv parses the content of a .env file: one KEY=VALUE per line, with an optional "export"
+// in front. Empty lines and lines starting with "#" are ignored.
+func parseDotEnv(ppfmt pp.PP, path, content string) (map[string]string, bool) {
+	vars := map[string]string{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse line %d of %q: not in the form KEY=VALUE", i+1, path)
+			return nil, false
+		}
+
+		value, ok := parseDotEnvValue(strings.TrimSpace(value))
+		if !ok {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse line %d of %q: ill-formed quoted value", i+1, path)
+			return nil, false
+		}
+		vars[key] = value
+	}
+	return vars, true
+}
+
+// CloudflareAuthFromDotEnv reads CF_API_TOKEN, CF_ACCOUNT_ID, and CF_BASE_URL from a .env file,
+// which is convenient for local development. Other settings take their default values.
+// A missing CF_API_TOKEN is only a warning because the token might be set in other ways.
+func CloudflareAuthFromDotEnv(ppfmt pp.PP, path string) (*CloudflareAuth, bool) {
+	content, ok := file.ReadString(ppfmt, path)
+	if !ok {
+		return nil, false
+	}
+
+	vars, ok := parseDotEnv(ppfmt, path, content)
+	if !ok {
+		return nil, false
+	}
+
+	if vars["CF_API_TOKEN"] == "" {
+		ppfmt.Warningf(pp.EmojiUserWarning, "CF_API_TOKEN is not set in %q", path)
+	}
+
+	return &CloudflareAuth{
+		Token:                vars["CF_API_TOKEN"],
+		AccountID:            vars["CF_ACCOUNT_ID"],
+		BaseURL:              vars["CF_BASE_URL"],
+		TokenExpiryWarning:   DefaultTokenExpiryWarning,
+		RejectCloudflareIPs:  false,
+		ClientCert:           tls.Certificate{},
+		ClientCA:             nil,
+		ProxyURL:             "",
+		Clock:                clock.Real{},
+		TransportConfig:      TransportConfig{MaxIdleConns: 0, MaxIdleConnsPerHost: 0, IdleConnTimeout: 0},
+		UseBatchAPI:          false,
+		Retry:                RetryConfig{MaxRetries: 0, MinDelay: 0, MaxDelay: 0, SafeRetry: false},
+		TokenVerifyPath:      DefaultTokenVerifyPath,
+		StartupTimeout:       0,
+		StartupRetryInterval: 0,
+		Resolver:             nil,
+		PropagationInterval:  0,
+		AccessClientID:       "",
+		AccessClientSecret:   "",
+		RateLimitWarning:     DefaultRateLimitWarning,
+	}, true
+}
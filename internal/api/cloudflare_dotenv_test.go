@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/file"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+//nolint:paralleltest,funlen // changing global var file.FS
+func TestCloudflareAuthFromDotEnv(t *testing.T) {
+	const path = "test/.env"
+
+	for name, tc := range map[string]struct {
+		content       string
+		ok            bool
+		token         string
+		accountID     string
+		baseURL       string
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"valid": {
+			"CF_API_TOKEN=token\nCF_ACCOUNT_ID=account\nCF_BASE_URL=https://example.com/v4\nOTHER=value\n",
+			true, "token", "account", "https://example.com/v4",
+			nil,
+		},
+		"export": {
+			"export CF_API_TOKEN=token\n",
+			true, "token", "", "",
+			nil,
+		},
+		"quoted": {
+			`CF_API_TOKEN="to ken\"#"` + "\n" + `CF_ACCOUNT_ID='acc\ount'  # comment`,
+			true, "to ken\"#", `acc\ount`, "",
+			nil,
+		},
+		"comments": {
+			"# the token\n\n  # CF_ACCOUNT_ID=account\nCF_API_TOKEN=token # comment\nCF_BASE_URL=url#fragment\n",
+			true, "token", "", "url#fragment",
+			nil,
+		},
+		"missing": {
+			"CF_ACCOUNT_ID=account\n",
+			true, "", "account", "",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiUserWarning, "CF_API_TOKEN is not set in %q", path)
+			},
+		},
+		"ill-formed/line": {
+			"CF_API_TOKEN=token\nCF_ACCOUNT_ID\n",
+			false, "", "", "",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of %q: not in the form KEY=VALUE", 2, path)
+			},
+		},
+		"ill-formed/quote": {
+			`CF_API_TOKEN="token`,
+			false, "", "", "",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of %q: ill-formed quoted value", 1, path)
+			},
+		},
+		"ill-formed/trailing": {
+			`CF_API_TOKEN='token' extra`,
+			false, "", "", "",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of %q: ill-formed quoted value", 1, path)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+
+			file.FS = fstest.MapFS{
+				path: &fstest.MapFile{
+					Data:    []byte(tc.content),
+					Mode:    0o644,
+					ModTime: time.Unix(1234, 5678),
+					Sys:     nil,
+				},
+			}
+			t.Cleanup(func() { file.FS = os.DirFS("/") })
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			auth, ok := api.CloudflareAuthFromDotEnv(mockPP, path)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, tc.token, auth.Token)
+				require.Equal(t, tc.accountID, auth.AccountID)
+				require.Equal(t, tc.baseURL, auth.BaseURL)
+				require.Equal(t, api.DefaultTokenExpiryWarning, auth.TokenExpiryWarning)
+				require.Equal(t, api.DefaultTokenVerifyPath, auth.TokenVerifyPath)
+			} else {
+				require.Nil(t, auth)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // changing global var file.FS
+func TestCloudflareAuthFromDotEnvMissingFile(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	file.FS = fstest.MapFS{}
+	t.Cleanup(func() { file.FS = os.DirFS("/") })
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to read %q: %v", "test/.env", gomock.Any())
+	auth, ok := api.CloudflareAuthFromDotEnv(mockPP, "test/.env")
+	require.False(t, ok)
+	require.Nil(t, auth)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// DetectDrift checks whether the DNS records of the domain were changed by other parties,
+// for example, on the Cloudflare dashboard. The cached records of the domain are flushed first.
+// The records have drifted if there are none or if any of them points to a different IP address;
+// in that case, the different IP address of the record with the smallest ID (or the zero
+// address if there are no records) is returned.
+func (h *CloudflareHandle) DetectDrift(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, expectedIP netip.Addr,
+) (bool, netip.Addr, bool) {
+	h.cache.listRecords[ipNet].Delete(domain.DNSNameASCII())
+
+	rmap, ok := h.ListRecords(ctx, ppfmt, domain, ipNet)
+	if !ok {
+		return false, netip.Addr{}, false
+	}
+
+	if len(rmap) == 0 {
+		ppfmt.Warningf(pp.EmojiWarning, "The %s records of %q are gone; they might have been deleted elsewhere",
+			ipNet.RecordType(), domain.Describe())
+		return true, netip.Addr{}, true
+	}
+
+	ids := make([]string, 0, len(rmap))
+	for id := range rmap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if ip := rmap[id]; ip != expectedIP {
+			ppfmt.Warningf(pp.EmojiWarning,
+				"The %s record of %q (ID: %s) points to %v instead of %v; it might have been changed elsewhere",
+				ipNet.RecordType(), domain.Describe(), id, ip, expectedIP)
+			return true, ip, true
+		}
+	}
+
+	return false, expectedIP, true
+}
@@ -0,0 +1,103 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+//nolint:funlen
+func TestDetectDrift(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		current       map[string]string
+		drifted       bool
+		currentIP     netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"unchanged": {map[string]string{"record1": "::1", "record2": "::1"}, false, mustIP("::1"), nil},
+		"changed": {
+			map[string]string{"record1": "::1", "record2": "::2", "record3": "::3"}, true, mustIP("::2"),
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning,
+					"The %s record of %q (ID: %s) points to %v instead of %v; it might have been changed elsewhere",
+					"AAAA", "sub.test.org", "record2", mustIP("::2"), mustIP("::1"))
+			},
+		},
+		"gone": {
+			map[string]string{}, true, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning, "The %s records of %q are gone; they might have been deleted elsewhere",
+					"AAAA", "sub.test.org")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			// The first listing is cached; the records are then changed elsewhere.
+			records := map[string]string{"record1": "::1"}
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org", records))
+					require.NoError(t, err)
+				})
+
+			ctx := context.Background()
+			d := domain.FQDN("sub.test.org")
+			mockPP := mocks.NewMockPP(mockCtrl)
+			_, ok := h.ListRecords(ctx, mockPP, d, ipnet.IP6)
+			require.True(t, ok)
+
+			records = tc.current
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			drifted, currentIP, ok := h.DetectDrift(ctx, mockPP, d, ipnet.IP6, mustIP("::1"))
+			require.True(t, ok)
+			require.Equal(t, tc.drifted, drifted)
+			require.Equal(t, tc.currentIP, currentIP)
+			require.True(t, zh.isExhausted())
+		})
+	}
+}
+
+func TestDetectDriftFailed(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", "sub.test.org", gomock.Any())
+	drifted, currentIP, ok := h.DetectDrift(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6,
+		mustIP("::1"))
+	require.False(t, ok)
+	require.False(t, drifted)
+	require.Equal(t, netip.Addr{}, currentIP)
+}
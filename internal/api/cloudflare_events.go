@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/netip"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+)
+
+// cachedIP returns the cached IP address of a record, or the invalid address if it is not in the cache.
+func (h *CloudflareHandle) cachedIP(domain domain.Domain, ipNet ipnet.Type, id string) netip.Addr {
+	if rmap, ok := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); ok {
+		return rmap[id]
+	}
+	return netip.Addr{}
+}
+
+// recordEvent adds an event to the current update cycle.
+func (h *CloudflareHandle) recordEvent(domain domain.Domain, ipNet ipnet.Type, action UpdateAction,
+	id string, oldIP, newIP netip.Addr, success bool,
+) {
+	h.eventsMutex.Lock()
+	defer h.eventsMutex.Unlock()
+
+	h.events = append(h.events, UpdateEvent{
+		Domain:    domain,
+		IPNet:     ipNet,
+		OldIP:     oldIP,
+		NewIP:     newIP,
+		RecordID:  id,
+		Action:    action,
+		Success:   success,
+		Timestamp: h.clock.Now(),
+	})
+}
+
+// LastUpdateEvents returns the events of the current (or the last) update cycle, in the order they happened.
+func (h *CloudflareHandle) LastUpdateEvents() []UpdateEvent {
+	h.eventsMutex.Lock()
+	defer h.eventsMutex.Unlock()
+
+	return append([]UpdateEvent(nil), h.events...)
+}
+
+// ClearUpdateEvents forgets all the events. It should be called at the start of each update cycle.
+func (h *CloudflareHandle) ClearUpdateEvents() {
+	h.eventsMutex.Lock()
+	defer h.eventsMutex.Unlock()
+
+	h.events = nil
+}
@@ -0,0 +1,166 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func newHandleWithClock(t *testing.T, now time.Time) (*http.ServeMux, *api.CloudflareHandle) {
+	t.Helper()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+	auth.Clock = clock.NewMock(now)
+
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+
+	return mux, h.(*api.CloudflareHandle) //nolint:forcetypeassert
+}
+
+//nolint:funlen
+func TestUpdateEvents(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	now := time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+	mux, h := newHandleWithClock(t, now)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			switch r.Method {
+			case http.MethodGet:
+				err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org",
+					map[string]string{"record1": "::1", "record2": "::1"}))
+				require.NoError(t, err)
+			case http.MethodPost:
+				err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record3", ipnet.IP6, "sub.test.org", "::3"))
+				require.NoError(t, err)
+			default:
+				require.Fail(t, "unexpected method", r.Method)
+			}
+		})
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPatch, r.Method)
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "sub.test.org", "::2"))
+			require.NoError(t, err)
+		})
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record2", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodDelete, r.Method)
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record2", ipnet.IP6, "sub.test.org", "::1"))
+			require.NoError(t, err)
+		})
+
+	ctx := context.Background()
+	d := domain.FQDN("sub.test.org")
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	_, ok := h.ListRecords(ctx, mockPP, d, ipnet.IP6)
+	require.True(t, ok)
+	require.True(t, h.UpdateRecord(ctx, mockPP, d, ipnet.IP6, "record1", mustIP("::2")))
+	require.True(t, h.DeleteRecord(ctx, mockPP, d, ipnet.IP6, "record2"))
+	id, ok := h.CreateRecord(ctx, mockPP, d, ipnet.IP6, mustIP("::3"), api.TTLAuto, false, "")
+	require.True(t, ok)
+	require.Equal(t, "record3", id)
+	_, ok = h.BatchUpdate(ctx, mockPP, []api.RecordUpdate{{Domain: d, IPNet: ipnet.IP6, ID: "record1", IP: mustIP("::2")}})
+	require.True(t, ok)
+
+	require.Equal(t, []api.UpdateEvent{
+		{
+			Domain: d, IPNet: ipnet.IP6, OldIP: mustIP("::1"), NewIP: mustIP("::2"), RecordID: "record1",
+			Action: api.ActionUpdated, Success: true, Timestamp: now,
+		},
+		{
+			Domain: d, IPNet: ipnet.IP6, OldIP: mustIP("::1"), NewIP: netip.Addr{}, RecordID: "record2",
+			Action: api.ActionDeleted, Success: true, Timestamp: now,
+		},
+		{
+			Domain: d, IPNet: ipnet.IP6, OldIP: netip.Addr{}, NewIP: mustIP("::3"), RecordID: "record3",
+			Action: api.ActionCreated, Success: true, Timestamp: now,
+		},
+		{
+			Domain: d, IPNet: ipnet.IP6, OldIP: mustIP("::2"), NewIP: mustIP("::2"), RecordID: "record1",
+			Action: api.ActionNoOp, Success: true, Timestamp: now,
+		},
+	}, h.LastUpdateEvents())
+
+	h.ClearUpdateEvents()
+	require.Empty(t, h.LastUpdateEvents())
+	require.True(t, zh.isExhausted())
+}
+
+func TestUpdateEventsFailure(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	now := time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+	mux, h := newHandleWithClock(t, now)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	ctx := context.Background()
+	d := domain.FQDN("sub.test.org")
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to add a new %s record of %q: %v", "AAAA", "sub.test.org", gomock.Any())
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to delete a stale %s record of %q (ID: %s): %v",
+		"AAAA", "sub.test.org", "record1", gomock.Any())
+
+	_, ok := h.CreateRecord(ctx, mockPP, d, ipnet.IP6, mustIP("::1"), api.TTLAuto, false, "")
+	require.False(t, ok)
+	require.False(t, h.DeleteRecord(ctx, mockPP, d, ipnet.IP6, "record1"))
+
+	require.Equal(t, []api.UpdateEvent{
+		{
+			Domain: d, IPNet: ipnet.IP6, OldIP: netip.Addr{}, NewIP: mustIP("::1"), RecordID: "",
+			Action: api.ActionCreated, Success: false, Timestamp: now,
+		},
+		{
+			Domain: d, IPNet: ipnet.IP6, OldIP: netip.Addr{}, NewIP: netip.Addr{}, RecordID: "record1",
+			Action: api.ActionDeleted, Success: false, Timestamp: now,
+		},
+	}, h.LastUpdateEvents())
+	require.True(t, zh.isExhausted())
+}
+
+func TestUpdateActionString(t *testing.T) {
+	t.Parallel()
+
+	for action, expected := range map[api.UpdateAction]string{
+		api.ActionNoOp:    "no-op",
+		api.ActionCreated: "created",
+		api.ActionUpdated: "updated",
+		api.ActionDeleted: "deleted",
+		100:               "unknown",
+	} {
+		require.Equal(t, expected, action.String())
+	}
+}
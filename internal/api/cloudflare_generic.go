@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A recordKey identifies the cached records of a domain (in ASCII) and a type.
+type recordKey struct {
+	name       string
+	recordType string
+}
+
+func (h *CloudflareHandle) ListRecordsByType(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, recordType string,
+) (map[string]string, bool) {
+	key := recordKey{name: domain.DNSNameASCII(), recordType: recordType}
+	if rmap, ok := h.cache.listByType.Get(key); ok {
+		return rmap, true
+	}
+
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return nil, false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally unspecified
+	rs, err := h.cf.DNSRecords(ctx, zone, cloudflare.DNSRecord{
+		Name: domain.DNSNameASCII(),
+		Type: recordType,
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve %s records of %q: %v", recordType, domain.Describe(), err)
+		return nil, false
+	}
+
+	rmap := map[string]string{}
+	for i := range rs {
+		rmap[rs[i].ID] = rs[i].Content
+	}
+
+	h.cache.listByType.Set(key, rmap)
+
+	return rmap, true
+}
@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestListRecordsByType(t *testing.T) {
+	t.Parallel()
+
+	for recordType, contents := range map[string]map[string]string{
+		"TXT": {"record1": "v=spf1 -all", "record2": "hello"},
+		"MX":  {"record3": "mail.test.org"},
+	} {
+		recordType, contents := recordType, contents
+		t.Run(recordType, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			accessCount := 1
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+					require.Equal(t, "sub.test.org", r.URL.Query().Get("name"))
+					require.Equal(t, recordType, r.URL.Query().Get("type"))
+					if accessCount <= 0 {
+						return
+					}
+					accessCount--
+
+					rs := make([]cloudflare.DNSRecord, 0, len(contents))
+					for id, content := range contents {
+						//nolint:exhaustruct // Other fields are intentionally omitted
+						rs = append(rs, cloudflare.DNSRecord{ID: id, Type: recordType, Name: "sub.test.org", Content: content})
+					}
+
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(cloudflare.DNSListResponse{
+						Result: rs,
+						ResultInfo: cloudflare.ResultInfo{
+							Page:       1,
+							PerPage:    100,
+							TotalPages: 1,
+							Count:      len(rs),
+							Total:      len(rs),
+							Cursor:     "",
+							Cursors:    cloudflare.ResultInfoCursors{}, //nolint:exhaustruct
+						},
+						Response: cloudflare.Response{
+							Success:  true,
+							Errors:   []cloudflare.ResponseInfo{},
+							Messages: []cloudflare.ResponseInfo{},
+						},
+					})
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			rmap, ok := h.ListRecordsByType(context.Background(), mockPP, domain.FQDN("sub.test.org"), recordType)
+			require.True(t, ok)
+			require.Equal(t, contents, rmap)
+			require.Zero(t, accessCount)
+
+			// cached: no more API calls
+			rmap, ok = h.ListRecordsByType(context.Background(), mockPP, domain.FQDN("sub.test.org"), recordType)
+			require.True(t, ok)
+			require.Equal(t, contents, rmap)
+		})
+	}
+}
+
+func TestListRecordsByTypeInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve %s records of %q: %v", "TXT", "sub.test.org", gomock.Any())
+	rmap, ok := h.ListRecordsByType(context.Background(), mockPP, domain.FQDN("sub.test.org"), "TXT")
+	require.False(t, ok)
+	require.Nil(t, rmap)
+}
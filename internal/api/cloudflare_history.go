@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A RecordHistoryEntry is one change in the history of a DNS record.
+type RecordHistoryEntry struct {
+	Timestamp  time.Time
+	Action     string
+	OldContent string
+	NewContent string
+}
+
+// rawRecordHistoryEntry is the JSON form of RecordHistoryEntry.
+type rawRecordHistoryEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`
+	OldContent string    `json:"old_content"`
+	NewContent string    `json:"new_content"`
+}
+
+// ListRecordHistory retrieves the changes of a DNS record. The history is only available
+// to Enterprise accounts; other accounts get 403 Forbidden.
+func (h *CloudflareHandle) ListRecordHistory(ctx context.Context, ppfmt pp.PP,
+	zoneID, recordID string,
+) ([]RecordHistoryEntry, bool) {
+	var raw json.RawMessage
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		raw, err = h.cf.Raw(ctx, http.MethodGet,
+			fmt.Sprintf("/zones/%s/dns_records/%s/history", zoneID, recordID), nil, nil)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		// cloudflare-go reports 403 Forbidden as an AuthenticationError.
+		var authErr *cloudflare.AuthenticationError
+		if errors.As(err, &authErr) {
+			ppfmt.Warningf(pp.EmojiWarning,
+				"The history of DNS records in the zone %q is not available; it requires an Enterprise plan", zoneID)
+			return nil, false
+		}
+
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve the history of the record %q in the zone %q: %v",
+			recordID, zoneID, err)
+		return nil, false
+	}
+
+	var rs []rawRecordHistoryEntry
+	if err := json.Unmarshal(raw, &rs); err != nil {
+		ppfmt.Warningf(pp.EmojiImpossible, "Failed to parse the history of the record %q in the zone %q: %v",
+			recordID, zoneID, err)
+		return nil, false
+	}
+
+	entries := make([]RecordHistoryEntry, 0, len(rs))
+	for _, r := range rs {
+		entries = append(entries, RecordHistoryEntry(r))
+	}
+	return entries, true
+}
@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func handleRecordHistory(t *testing.T, mux *http.ServeMux, zoneID, recordID string, status int, body string) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/%s/history", zoneID, recordID),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+			w.Header().Set("content-type", "application/json")
+			w.WriteHeader(status)
+			_, err := fmt.Fprint(w, body)
+			require.NoError(t, err)
+		})
+}
+
+func TestListRecordHistory(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	handleRecordHistory(t, mux, mockID("test.org", 0), "record1", http.StatusOK,
+		`{
+			"success": true,
+			"errors": [],
+			"messages": [],
+			"result": [
+				{"timestamp": "2022-11-01T00:00:00Z", "action": "create", "old_content": "", "new_content": "::1"},
+				{"timestamp": "2022-11-02T00:00:00Z", "action": "update", "old_content": "::1", "new_content": "::2"}
+			]
+		}`)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	entries, ok := h.(*api.CloudflareHandle).ListRecordHistory(context.Background(), mockPP,
+		mockID("test.org", 0), "record1")
+	require.True(t, ok)
+	require.Equal(t, []api.RecordHistoryEntry{
+		{
+			Timestamp: time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC),
+			Action:    "create", OldContent: "", NewContent: "::1",
+		},
+		{
+			Timestamp: time.Date(2022, time.November, 2, 0, 0, 0, 0, time.UTC),
+			Action:    "update", OldContent: "::1", NewContent: "::2",
+		},
+	}, entries)
+}
+
+func TestListRecordHistoryInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		status        int
+		body          string
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"forbidden": {
+			http.StatusForbidden,
+			`{"success": false, "errors": [{"code": 10000, "message": "Authentication error"}], "messages": [], "result": null}`,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning,
+					"The history of DNS records in the zone %q is not available; it requires an Enterprise plan",
+					mockID("test.org", 0))
+			},
+		},
+		"not-found": {
+			http.StatusNotFound,
+			`{"success": false, "errors": [{"code": 81044, "message": "Record does not exist."}],
+			  "messages": [], "result": null}`,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve the history of the record %q in the zone %q: %v",
+					"record1", mockID("test.org", 0), gomock.Any())
+			},
+		},
+		"ill-formed": {
+			http.StatusOK,
+			`{"success": true, "errors": [], "messages": [], "result": {"action": "create"}}`,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiImpossible, "Failed to parse the history of the record %q in the zone %q: %v",
+					"record1", mockID("test.org", 0), gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+			handleRecordHistory(t, mux, mockID("test.org", 0), "record1", tc.status, tc.body)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+			entries, ok := h.(*api.CloudflareHandle).ListRecordHistory(context.Background(), mockPP,
+				mockID("test.org", 0), "record1")
+			require.False(t, ok)
+			require.Nil(t, entries)
+		})
+	}
+}
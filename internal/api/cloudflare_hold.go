@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A zoneHold is the result of GET /zones/{zoneID}/hold, which cloudflare-go does not support.
+type zoneHold struct {
+	Hold      bool   `json:"hold"`
+	HoldAfter string `json:"hold_after"`
+}
+
+// ZoneHoldStatus checks whether a zone is on hold. It also returns the time the hold will be released,
+// or nil if the time is not known. An error is printed if the zone is on hold, for the updates of
+// its DNS records will fail.
+func (h *CloudflareHandle) ZoneHoldStatus(ctx context.Context, ppfmt pp.PP, zoneID string) (bool, *time.Time, bool) {
+	return h.zoneHoldStatus(ctx, ppfmt, zoneID, false)
+}
+
+// describeZoneID describes a zone by its name if the name is already cached, and by its ID otherwise.
+func (h *CloudflareHandle) describeZoneID(ppfmt pp.PP, zoneID string) string {
+	if name, ok := h.cache.zoneName.Get(zoneID); ok {
+		return describeZone(ppfmt, name)
+	}
+	return zoneID
+}
+
+// zoneHoldStatus is ZoneHoldStatus, except that 403 Forbidden is not logged when quietIfForbidden is true.
+func (h *CloudflareHandle) zoneHoldStatus(ctx context.Context, ppfmt pp.PP,
+	zoneID string, quietIfForbidden bool,
+) (bool, *time.Time, bool) {
+	zone := h.describeZoneID(ppfmt, zoneID)
+
+	var raw json.RawMessage
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		raw, err = h.cf.Raw(ctx, http.MethodGet, fmt.Sprintf("/zones/%s/hold", zoneID), nil, nil)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		if quietIfForbidden && isForbidden(err) {
+			return false, nil, false
+		}
+		ppfmt.Warningf(pp.EmojiError, "Failed to check whether the zone %q is on hold: %v", zone, err)
+		return false, nil, false
+	}
+
+	var res zoneHold
+	if err := json.Unmarshal(raw, &res); err != nil {
+		ppfmt.Warningf(pp.EmojiImpossible, "Failed to parse the hold status of the zone %q: %v", zone, err)
+		return false, nil, false
+	}
+
+	var holdAfter *time.Time
+	if res.HoldAfter != "" {
+		t, err := time.Parse(time.RFC3339, res.HoldAfter)
+		if err != nil {
+			ppfmt.Warningf(pp.EmojiImpossible, "Failed to parse the release time of the hold on the zone %q: %v", zone, err)
+			return false, nil, false
+		}
+		holdAfter = &t
+	}
+
+	if res.Hold {
+		if holdAfter != nil {
+			ppfmt.Errorf(pp.EmojiUserError,
+				"The zone %q is on hold until %s; updates of its DNS records will fail until the hold is released",
+				zone, holdAfter.Format(time.RFC3339))
+		} else {
+			ppfmt.Errorf(pp.EmojiUserError,
+				"The zone %q is on hold; updates of its DNS records will fail until the hold is released", zone)
+		}
+	}
+
+	return res.Hold, holdAfter, true
+}
@@ -0,0 +1,111 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func handleZoneHold(t *testing.T, mux *http.ServeMux, zoneID string, result map[string]any) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/hold", zoneID), func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"success":  true,
+			"errors":   []any{},
+			"messages": []any{},
+			"result":   result,
+		})
+		require.NoError(t, err)
+	})
+}
+
+//nolint:funlen
+func TestZoneHoldStatus(t *testing.T) {
+	t.Parallel()
+
+	release := time.Date(2099, time.January, 31, 15, 56, 36, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		result            map[string]any
+		expectedOnHold    bool
+		expectedHoldAfter *time.Time
+		prepareMockPP     func(*mocks.MockPP)
+	}{
+		"not-held": {
+			map[string]any{"hold": false, "include_subdomains": false},
+			false, nil,
+			nil,
+		},
+		"held": {
+			map[string]any{"hold": true, "include_subdomains": false},
+			true, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError,
+					"The zone %q is on hold; updates of its DNS records will fail until the hold is released",
+					mockID("test.org", 0))
+			},
+		},
+		"held-until": {
+			map[string]any{"hold": true, "hold_after": "2099-01-31T15:56:36Z", "include_subdomains": false},
+			true, &release,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError,
+					"The zone %q is on hold until %s; updates of its DNS records will fail until the hold is released",
+					mockID("test.org", 0), "2099-01-31T15:56:36Z")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			handleZoneHold(t, mux, mockID("test.org", 0), tc.result)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			onHold, holdAfter, ok := h.(*api.CloudflareHandle).ZoneHoldStatus(context.Background(), mockPP,
+				mockID("test.org", 0))
+			require.True(t, ok)
+			require.Equal(t, tc.expectedOnHold, onHold)
+			require.Equal(t, tc.expectedHoldAfter, holdAfter)
+		})
+	}
+}
+
+func TestZoneHoldStatusFailed(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/hold", mockID("test.org", 0)), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check whether the zone %q is on hold: %v",
+		mockID("test.org", 0), gomock.Any())
+	onHold, holdAfter, ok := h.(*api.CloudflareHandle).ZoneHoldStatus(context.Background(), mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.False(t, onHold)
+	require.Nil(t, holdAfter)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// HTTPSRecordType is the type of HTTPS records (TYPE65).
+const HTTPSRecordType = "HTTPS"
+
+// cutField splits off the first whitespace-separated field.
+func cutField(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// httpsRecordData converts the RDATA of an HTTPS record in the presentation format
+// to the structured data expected by Cloudflare. The SvcParams are kept as they are.
+func httpsRecordData(ppfmt pp.PP, content string) (map[string]any, bool) {
+	priorityString, rest := cutField(content)
+	target, value := cutField(rest)
+
+	priority, err := strconv.ParseUint(priorityString, 10, 16)
+	if err != nil || target == "" {
+		ppfmt.Errorf(pp.EmojiUserError, "Failed to parse the HTTPS record %q", content)
+		return nil, false
+	}
+
+	return map[string]any{
+		"priority": priority,
+		"target":   target,
+		"value":    value,
+	}, true
+}
+
+func (h *CloudflareHandle) ListHTTPSRecords(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain,
+) (map[string]string, bool) {
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return nil, false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally unspecified
+	rs, err := h.cf.DNSRecords(ctx, zone, cloudflare.DNSRecord{
+		Name: domain.DNSNameASCII(),
+		Type: HTTPSRecordType,
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve HTTPS records of %q: %v", domain.Describe(), err)
+		return nil, false
+	}
+
+	rmap := map[string]string{}
+	for i := range rs {
+		rmap[rs[i].ID] = rs[i].Content
+	}
+
+	return rmap, true
+}
+
+func (h *CloudflareHandle) DeleteHTTPSRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, id string,
+) bool {
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return false
+	}
+
+	err := h.cf.DeleteDNSRecord(ctx, zone, id)
+	h.cache.listByType.Delete(recordKey{name: domain.DNSNameASCII(), recordType: HTTPSRecordType})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to delete an HTTPS record of %q (ID: %s): %v",
+			domain.Describe(), id, err)
+		return false
+	}
+
+	return true
+}
+
+func (h *CloudflareHandle) UpdateHTTPSRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, id string, content string,
+) bool {
+	data, ok := httpsRecordData(ppfmt, content)
+	if !ok {
+		return false
+	}
+
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	payload := cloudflare.DNSRecord{
+		Name: domain.DNSNameASCII(),
+		Type: HTTPSRecordType,
+		Data: data,
+	}
+
+	err := h.cf.UpdateDNSRecord(ctx, zone, id, payload)
+	h.cache.listByType.Delete(recordKey{name: domain.DNSNameASCII(), recordType: HTTPSRecordType})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to update an HTTPS record of %q (ID: %s): %v",
+			domain.Describe(), id, err)
+		return false
+	}
+
+	return true
+}
+
+func (h *CloudflareHandle) CreateHTTPSRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, content string, ttl TTL,
+) (string, bool) {
+	data, ok := httpsRecordData(ppfmt, content)
+	if !ok {
+		return "", false
+	}
+
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return "", false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	payload := cloudflare.DNSRecord{
+		Name: domain.DNSNameASCII(),
+		Type: HTTPSRecordType,
+		Data: data,
+		TTL:  ttl.Int(),
+	}
+
+	res, err := h.cf.CreateDNSRecord(ctx, zone, payload)
+	h.cache.listByType.Delete(recordKey{name: domain.DNSNameASCII(), recordType: HTTPSRecordType})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to add a new HTTPS record of %q: %v", domain.Describe(), err)
+		return "", false
+	}
+
+	return res.Result.ID, true
+}
@@ -0,0 +1,225 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func decodeHTTPSData(t *testing.T, r *http.Request) (string, map[string]any) {
+	t.Helper()
+
+	var record cloudflare.DNSRecord
+	err := json.NewDecoder(r.Body).Decode(&record)
+	require.NoError(t, err)
+
+	require.Equal(t, "sub.test.org", record.Name)
+	require.Equal(t, api.HTTPSRecordType, record.Type)
+
+	data, ok := record.Data.(map[string]any)
+	require.True(t, ok)
+
+	return record.ID, data
+}
+
+func TestListHTTPSRecords(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, "sub.test.org", r.URL.Query().Get("name"))
+			require.Equal(t, api.HTTPSRecordType, r.URL.Query().Get("type"))
+
+			response := mockDNSListResponse(ipnet.Any, "sub.test.org", map[string]string{"record1": `1 . alpn="h2"`})
+			response.Result[0].Type = api.HTTPSRecordType
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(response)
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	rs, ok := h.ListHTTPSRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"record1": `1 . alpn="h2"`}, rs)
+}
+
+func TestListHTTPSRecordsInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve HTTPS records of %q: %v", "sub.test.org", gomock.Any())
+	rs, ok := h.ListHTTPSRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	require.False(t, ok)
+	require.Nil(t, rs)
+}
+
+func TestCreateHTTPSRecord(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+
+			_, data := decodeHTTPSData(t, r)
+			require.Equal(t, map[string]any{
+				"priority": float64(1),
+				"target":   ".",
+				"value":    `alpn="h3,h2" ipv4hint=1.2.3.4`,
+			}, data)
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(&cloudflare.DNSRecord{ID: "record1"})) //nolint:exhaustruct,lll
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	id, ok := h.CreateHTTPSRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"),
+		`  1   .  alpn="h3,h2" ipv4hint=1.2.3.4 `, api.TTLAuto)
+	require.True(t, ok)
+	require.Equal(t, "record1", id)
+}
+
+func TestCreateHTTPSRecordInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, content := range map[string]string{
+		"empty":     "",
+		"priority":  `high . alpn="h2"`,
+		"overflow":  `65536 . alpn="h2"`,
+		"no-target": "1",
+	} {
+		content := content
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse the HTTPS record %q", content)
+			id, ok := h.CreateHTTPSRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), content, api.TTLAuto)
+			require.False(t, ok)
+			require.Equal(t, "", id)
+		})
+	}
+}
+
+func TestUpdateHTTPSRecord(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPatch, r.Method)
+
+			_, data := decodeHTTPSData(t, r)
+			require.Equal(t, map[string]any{
+				"priority": float64(2),
+				"target":   "svc.test.org.",
+				"value":    "",
+			}, data)
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(&cloudflare.DNSRecord{ID: "record1"})) //nolint:exhaustruct,lll
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	ok := h.UpdateHTTPSRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), "record1", "2 svc.test.org.")
+	require.True(t, ok)
+}
+
+func TestUpdateHTTPSRecordInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to update an HTTPS record of %q (ID: %s): %v",
+		"sub.test.org", "record1", gomock.Any())
+	ok := h.UpdateHTTPSRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), "record1", "1 .")
+	require.False(t, ok)
+}
+
+func TestDeleteHTTPSRecord(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodDelete, r.Method)
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(&cloudflare.DNSRecord{ID: "record1"})) //nolint:exhaustruct,lll
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	ok := h.DeleteHTTPSRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), "record1")
+	require.True(t, ok)
+}
+
+func TestDeleteHTTPSRecordInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to delete an HTTPS record of %q (ID: %s): %v",
+		"sub.test.org", "record1", gomock.Any())
+	ok := h.DeleteHTTPSRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), "record1")
+	require.False(t, ok)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"net/netip"
+	"sync"
+)
+
+// cloudflareIPFiles contains the IP ranges published at https://www.cloudflare.com/ips-v4
+// and https://www.cloudflare.com/ips-v6. The files should be updated when Cloudflare changes them.
+//
+//go:embed cloudflare-ips/ips-v4 cloudflare-ips/ips-v6
+var cloudflareIPFiles embed.FS
+
+//nolint:gochecknoglobals // the parsed ranges are computed only once
+var (
+	cloudflareIPRangesOnce sync.Once
+	cloudflareIPRanges     []netip.Prefix
+)
+
+func parseCloudflareIPRanges() {
+	for _, name := range [...]string{"cloudflare-ips/ips-v4", "cloudflare-ips/ips-v6"} {
+		content, err := cloudflareIPFiles.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			cloudflareIPRanges = append(cloudflareIPRanges, netip.MustParsePrefix(string(line)))
+		}
+	}
+}
+
+// IsCloudflareIP checks whether the IP address belongs to Cloudflare.
+func IsCloudflareIP(ip netip.Addr) bool {
+	cloudflareIPRangesOnce.Do(parseCloudflareIPRanges)
+
+	ip = ip.Unmap()
+	for _, r := range cloudflareIPRanges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
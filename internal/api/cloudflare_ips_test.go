@@ -0,0 +1,32 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+)
+
+func TestIsCloudflareIP(t *testing.T) {
+	t.Parallel()
+	for ip, expected := range map[string]bool{
+		"104.16.0.1":          true,
+		"172.64.1.1":          true,
+		"173.245.63.255":      true,
+		"::ffff:104.16.0.1":   true,
+		"2606:4700::1111":     true,
+		"2a06:98c0:3600::103": true,
+		"1.1.1.1":             false,
+		"8.8.8.8":             false,
+		"173.245.64.0":        false,
+		"2001:db8::1":         false,
+		"::1":                 false,
+	} {
+		ip, expected := ip, expected
+		t.Run(ip, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, expected, api.IsCloudflareIP(mustIP(ip)))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A CloudflareKeyAuth authenticates with the legacy global API key and its email,
+// as an alternative to API tokens. API tokens should be used whenever possible.
+type CloudflareKeyAuth struct {
+	APIKey    string
+	Email     string
+	AccountID string
+	BaseURL   string
+}
+
+func (t *CloudflareKeyAuth) New(ctx context.Context, ppfmt pp.PP,
+	cacheExpiration, timeout time.Duration,
+) (Handle, bool) {
+	if t.APIKey == "" {
+		ppfmt.Errorf(pp.EmojiUserError, "The Cloudflare API key is empty")
+		return nil, false
+	}
+	if t.Email == "" {
+		ppfmt.Errorf(pp.EmojiUserError, "The email of the Cloudflare API key is empty")
+		return nil, false
+	}
+	if !checkBaseURL(ppfmt, t.BaseURL) {
+		return nil, false
+	}
+
+	// The whole setup (including the key verification) should finish within the timeout.
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// A dedicated client, so that EnableRequestLogging will not touch http.DefaultClient.
+	limit := &rateLimit{} //nolint:exhaustruct // The zero value is ready to use
+	limitTransport := &rateLimitTransport{next: http.DefaultTransport, limit: limit}
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	httpClient := &http.Client{Transport: &statusTransport{next: limitTransport}}
+	handle, err := cloudflare.New(t.APIKey, t.Email, cloudflare.HTTPClient(httpClient))
+	if err != nil {
+		ppfmt.Errorf(pp.EmojiUserError, "Failed to prepare the Cloudflare authentication: %v", err)
+		return nil, false
+	}
+
+	// set the base URL (mostly for testing)
+	if t.BaseURL != "" {
+		handle.BaseURL = t.BaseURL
+	}
+
+	// this is not needed, but is helpful for diagnosing the problem
+	if _, err := handle.UserDetails(ctx); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			ppfmt.Errorf(pp.EmojiError, "Failed to verify the Cloudflare API key within %v", timeout)
+			return nil, false
+		}
+
+		ppfmt.Errorf(pp.EmojiUserError, "The Cloudflare API key could not be verified: %v", err)
+		ppfmt.Errorf(pp.EmojiUserError, "Please double-check the API key and its email")
+		return nil, false
+	}
+
+	return &CloudflareHandle{
+		cf:                  handle,
+		httpClient:          httpClient,
+		accountID:           t.AccountID,
+		tokenExpiryWarning:  0,
+		tokenVerifyPath:     "",
+		rejectCloudflareIPs: false,
+		usesAPIKey:          true,
+		useBatchAPI:         false,
+		rateLimit:           limit,
+		rateLimitWarning:    DefaultRateLimitWarning,
+		retry:               RetryConfig{MaxRetries: 0, MinDelay: 0, MaxDelay: 0, SafeRetry: false},
+		cache:               newHandleCache(clock.Real{}, cacheExpiration),
+		clock:               clock.Real{},
+		resolver:            net.DefaultResolver,
+		propagationInterval: DefaultPropagationInterval,
+		zoneID:              "",
+		eventsMutex:         sync.Mutex{},
+		events:              nil,
+	}, true
+}
@@ -0,0 +1,140 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+const (
+	mockAPIKey = "key789"
+	mockEmail  = "user@example.org"
+)
+
+func newServerKeyAuth(t *testing.T) (*http.ServeMux, *api.CloudflareKeyAuth) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	auth := api.CloudflareKeyAuth{
+		APIKey:    mockAPIKey,
+		Email:     mockEmail,
+		AccountID: mockAccount,
+		BaseURL:   ts.URL,
+	}
+
+	return mux, &auth
+}
+
+func handleUserDetails(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	t.Helper()
+
+	require.Equal(t, http.MethodGet, r.Method)
+	require.Equal(t, []string{mockAPIKey}, r.Header["X-Auth-Key"])
+	require.Equal(t, []string{mockEmail}, r.Header["X-Auth-Email"])
+	require.Empty(t, r.Header["Authorization"])
+
+	w.Header().Set("content-type", "application/json")
+	fmt.Fprintf(w,
+		`{
+			"result": { "id": "%s", "email": "%s" },
+			"success": true,
+			"errors": [],
+			"messages": []
+		}`,
+		mockID("user", 0), mockEmail)
+}
+
+func TestNewKeyValid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerKeyAuth(t)
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		handleUserDetails(t, w, r)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
+
+	auth.AccountID = ""
+	h, ok = auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	info, ok := h.Describe(context.Background(), mockPP)
+	require.True(t, ok)
+	require.Equal(t, api.AccountInfo{}, info) //nolint:exhaustruct
+}
+
+func TestNewKeyEmpty(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		apiKey  string
+		email   string
+		message string
+	}{
+		"key":   {"", mockEmail, "The Cloudflare API key is empty"},
+		"email": {mockAPIKey, "", "The email of the Cloudflare API key is empty"},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			_, auth := newServerKeyAuth(t)
+
+			auth.APIKey = tc.apiKey
+			auth.Email = tc.email
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError, tc.message)
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.False(t, ok)
+			require.Nil(t, h)
+		})
+	}
+}
+
+func TestNewKeyInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerKeyAuth(t)
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w,
+			`{
+				"success": false,
+				"errors": [{ "code": 9103, "message": "Unknown X-Auth-Key or X-Auth-Email" }],
+				"messages": [],
+				"result": null
+			}`)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "The Cloudflare API key could not be verified: %v", gomock.Any()),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Please double-check the API key and its email"),
+	)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.False(t, ok)
+	require.Nil(t, h)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// redacted replaces the secrets in the logged headers.
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are the headers carrying secrets, in their canonical forms.
+//
+//nolint:gochecknoglobals
+var sensitiveHeaders = map[string]bool{
+	"Authorization":           true,
+	"X-Auth-Key":              true,
+	"X-Auth-User-Service-Key": true,
+	"Cf-Access-Client-Secret": true,
+}
+
+// redactHeader hides the secret in the value of a header. The scheme of Authorization
+// (such as "Bearer") is kept for debugging.
+func redactHeader(name, value string) string {
+	if !sensitiveHeaders[name] {
+		return value
+	}
+	if scheme, _, found := strings.Cut(value, " "); found && name == "Authorization" {
+		return scheme + " " + redacted
+	}
+	return redacted
+}
+
+// describeHeaders formats the headers with the secrets redacted, sorted by their names.
+func describeHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range header[name] {
+			fields = append(fields, name+": "+redactHeader(name, value))
+		}
+	}
+	return strings.Join(fields, "; ")
+}
+
+// A loggingTransport logs all requests and the status codes of their responses.
+type loggingTransport struct {
+	next  http.RoundTripper
+	ppfmt pp.PP
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.ppfmt.IsEnabledFor(pp.Debug) {
+		return t.next.RoundTrip(req) //nolint:wrapcheck
+	}
+
+	t.ppfmt.Infof(pp.EmojiInternet, "API request: %s %s (%s)", req.Method, req.URL, describeHeaders(req.Header))
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.ppfmt.Infof(pp.EmojiInternet, "API request failed: %s %s: %v", req.Method, req.URL, err)
+		return nil, err //nolint:wrapcheck
+	}
+	t.ppfmt.Infof(pp.EmojiInternet, "API response: %s %s: %s", req.Method, req.URL, resp.Status)
+	return resp, nil
+}
+
+// EnableRequestLogging logs the method, the URL, and the headers of every API request, and the status
+// of its response, with the secrets in the headers redacted. The messages are only printed at the
+// debugging level (see pp.Debug); PP has no separate levels for debugging or tracing, so they are
+// printed as information and the response bodies are never logged. Handles created by CloneForZone
+// share the HTTP client, and thus the logging, with the original handle.
+func (h *CloudflareHandle) EnableRequestLogging(ppfmt pp.PP) {
+	next := h.httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	h.httpClient.Transport = &loggingTransport{next: next, ppfmt: ppfmt}
+}
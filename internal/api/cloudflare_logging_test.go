@@ -0,0 +1,50 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestEnableRequestLogging(t *testing.T) {
+	t.Parallel()
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 1)
+
+	var buf bytes.Buffer
+	ppfmt := pp.New(&buf).SetLevel(pp.Debug)
+	h.(*api.CloudflareHandle).EnableRequestLogging(ppfmt)
+	_, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), ppfmt, "test.org")
+	require.True(t, ok)
+
+	output := buf.String()
+	require.Contains(t, output, "API request: GET ")
+	require.Contains(t, output, "/zones")
+	require.Contains(t, output, "Authorization: Bearer [REDACTED]")
+	require.Contains(t, output, "200 OK")
+	require.NotContains(t, output, mockToken)
+}
+
+func TestEnableRequestLoggingNotDebugging(t *testing.T) {
+	t.Parallel()
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 1)
+
+	var buf bytes.Buffer
+	ppfmt := pp.New(&buf).SetLevel(pp.Info)
+	h.(*api.CloudflareHandle).EnableRequestLogging(ppfmt)
+	_, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), ppfmt, "test.org")
+	require.True(t, ok)
+	require.Empty(t, buf.String())
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// normalizeNameServers turns a list of nameservers into a sorted list of lowercase names
+// without trailing dots, so that two lists can be compared.
+func normalizeNameServers(servers []string) []string {
+	normalized := make([]string, 0, len(servers))
+	for _, s := range servers {
+		normalized = append(normalized, strings.TrimSuffix(strings.ToLower(s), "."))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// sameNameServers checks whether two lists of nameservers are the same, ignoring the order,
+// the cases, and the trailing dots.
+func sameNameServers(servers1, servers2 []string) bool {
+	servers1, servers2 = normalizeNameServers(servers1), normalizeNameServers(servers2)
+	if len(servers1) != len(servers2) {
+		return false
+	}
+	for i := range servers1 {
+		if servers1[i] != servers2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// NameServers returns the nameservers currently assigned to a zone (original_name_servers)
+// and the nameservers Cloudflare expects (name_servers). A warning is printed if they differ,
+// which usually means the setup of the zone is incomplete. Nothing is compared if Cloudflare
+// does not know the assigned nameservers.
+func (h *CloudflareHandle) NameServers(ctx context.Context, ppfmt pp.PP, zoneID string) ([]string, []string, bool) {
+	var zone cloudflare.Zone
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		zone, err = h.cf.ZoneDetails(ctx, zoneID)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve the nameservers of the zone %q: %v", zoneID, err)
+		return nil, nil, false
+	}
+
+	assigned, expected := zone.OriginalNS, zone.NameServers
+	if len(assigned) > 0 && !sameNameServers(assigned, expected) {
+		ppfmt.Warningf(pp.EmojiUserWarning,
+			"The nameservers of the zone %q are %s, but Cloudflare expects %s; the setup of the zone might be incomplete",
+			zoneID, strings.Join(assigned, ", "), strings.Join(expected, ", "))
+	}
+
+	return assigned, expected, true
+}
+
+// CustomNameservers returns the custom (vanity) nameservers of a zone, or an empty list if there are none.
+// cloudflare-go does not support GET /zones/{zoneID}/custom_ns, so the raw API is used.
+func (h *CloudflareHandle) CustomNameservers(ctx context.Context, ppfmt pp.PP, zoneID string) ([]string, bool) {
+	var raw json.RawMessage
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		raw, err = h.cf.Raw(ctx, http.MethodGet, fmt.Sprintf("/zones/%s/custom_ns", zoneID), nil, nil)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve the custom nameservers of the zone %q: %v", zoneID, err)
+		return nil, false
+	}
+
+	var servers []string
+	if err := json.Unmarshal(raw, &servers); err != nil {
+		ppfmt.Warningf(pp.EmojiImpossible, "Failed to parse the custom nameservers of the zone %q: %v", zoneID, err)
+		return nil, false
+	}
+	if servers == nil {
+		servers = []string{}
+	}
+
+	if len(servers) > 0 {
+		ppfmt.Infof(pp.EmojiConfig, "The zone %q uses custom nameservers: %s", zoneID, strings.Join(servers, ", "))
+	}
+
+	return servers, true
+}
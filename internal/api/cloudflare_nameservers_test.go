@@ -0,0 +1,183 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+//nolint:funlen
+func TestNameServers(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		assigned      []string
+		expected      []string
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"matching": {
+			[]string{"B.NS.CLOUDFLARE.COM.", "a.ns.cloudflare.com"},
+			[]string{"a.ns.cloudflare.com", "b.ns.cloudflare.com"},
+			nil,
+		},
+		"unknown": {
+			nil,
+			[]string{"a.ns.cloudflare.com", "b.ns.cloudflare.com"},
+			nil,
+		},
+		"non-matching": {
+			[]string{"ns1.registrar.com", "ns2.registrar.com"},
+			[]string{"a.ns.cloudflare.com", "b.ns.cloudflare.com"},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiUserWarning,
+					"The nameservers of the zone %q are %s, but Cloudflare expects %s; the setup of the zone might be incomplete",
+					mockID("test.org", 0), "ns1.registrar.com, ns2.registrar.com", "a.ns.cloudflare.com, b.ns.cloudflare.com")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			accessCount := 0
+			mux.HandleFunc(fmt.Sprintf("/zones/%s", mockID("test.org", 0)), func(w http.ResponseWriter, r *http.Request) {
+				accessCount++
+				require.Equal(t, http.MethodGet, r.Method)
+
+				zone := mockZone("test.org", 0, "active")
+				zone.OriginalNS = tc.assigned
+				zone.NameServers = tc.expected
+
+				w.Header().Set("content-type", "application/json")
+				err := json.NewEncoder(w).Encode(cloudflare.ZoneResponse{
+					Result: *zone,
+					Response: cloudflare.Response{
+						Success:  true,
+						Errors:   []cloudflare.ResponseInfo{},
+						Messages: []cloudflare.ResponseInfo{},
+					},
+				})
+				require.NoError(t, err)
+			})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			assigned, expected, ok := h.(*api.CloudflareHandle).NameServers(context.Background(), mockPP,
+				mockID("test.org", 0))
+			require.True(t, ok)
+			require.Equal(t, tc.assigned, assigned)
+			require.Equal(t, tc.expected, expected)
+			require.Equal(t, 1, accessCount)
+		})
+	}
+}
+
+func TestNameServersFailed(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s", mockID("test.org", 0)), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve the nameservers of the zone %q: %v",
+		mockID("test.org", 0), gomock.Any())
+	assigned, expected, ok := h.(*api.CloudflareHandle).NameServers(context.Background(), mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.Nil(t, assigned)
+	require.Nil(t, expected)
+}
+
+func TestCustomNameservers(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		result        any
+		expected      []string
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"none": {[]string{}, []string{}, nil},
+		"null": {nil, []string{}, nil},
+		"some": {
+			[]string{"ns1.test.org", "ns2.test.org"},
+			[]string{"ns1.test.org", "ns2.test.org"},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiConfig, "The zone %q uses custom nameservers: %s",
+					mockID("test.org", 0), "ns1.test.org, ns2.test.org")
+			},
+		},
+		"invalid": {
+			map[string]any{"enabled": true},
+			nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiImpossible, "Failed to parse the custom nameservers of the zone %q: %v",
+					mockID("test.org", 0), gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/custom_ns", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(map[string]any{
+						"success":  true,
+						"errors":   []any{},
+						"messages": []any{},
+						"result":   tc.result,
+					})
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			servers, ok := h.(*api.CloudflareHandle).CustomNameservers(context.Background(), mockPP,
+				mockID("test.org", 0))
+			require.Equal(t, tc.expected != nil, ok)
+			require.Equal(t, tc.expected, servers)
+		})
+	}
+}
+
+func TestCustomNameserversFailed(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/custom_ns", mockID("test.org", 0)), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve the custom nameservers of the zone %q: %v",
+		mockID("test.org", 0), gomock.Any())
+	servers, ok := h.(*api.CloudflareHandle).CustomNameservers(context.Background(), mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.Nil(t, servers)
+}
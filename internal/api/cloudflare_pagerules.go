@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A PageRule is a page rule of a zone. Targets are the URL patterns the rule matches,
+// and Actions are the IDs of the actions (such as "forwarding_url" or "cache_level").
+type PageRule struct {
+	ID      string
+	Targets []string
+	Actions []string
+	Status  string
+}
+
+// ListPageRules lists all page rules of a zone in the order of their priorities.
+func (h *CloudflareHandle) ListPageRules(ctx context.Context, ppfmt pp.PP, zoneID string) ([]PageRule, bool) {
+	var rs []cloudflare.PageRule
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		rs, err = h.cf.ListPageRules(ctx, zoneID)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to list the page rules of the zone %q: %v", zoneID, err)
+		return nil, false
+	}
+
+	rules := make([]PageRule, 0, len(rs))
+	for _, r := range rs {
+		targets := make([]string, 0, len(r.Targets))
+		for _, t := range r.Targets {
+			targets = append(targets, t.Constraint.Value)
+		}
+		actions := make([]string, 0, len(r.Actions))
+		for _, a := range r.Actions {
+			actions = append(actions, a.ID)
+		}
+		rules = append(rules, PageRule{ID: r.ID, Targets: targets, Actions: actions, Status: r.Status})
+	}
+	return rules, true
+}
+
+// DeletePageRule deletes a page rule of a zone.
+func (h *CloudflareHandle) DeletePageRule(ctx context.Context, ppfmt pp.PP, zoneID, ruleID string) bool {
+	err := h.withRetries(ctx, ppfmt, operationWrite, func() error {
+		return h.cf.DeletePageRule(ctx, zoneID, ruleID) //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to delete the page rule %q of the zone %q: %v", ruleID, zoneID, err)
+		return false
+	}
+
+	ppfmt.Noticef(pp.EmojiDelRecord, "Deleted the page rule %q of the zone %q", ruleID, zoneID)
+	return true
+}
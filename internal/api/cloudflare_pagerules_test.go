@@ -0,0 +1,150 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func mockPageRule(id, target, status string, actions ...string) map[string]any {
+	as := make([]any, 0, len(actions))
+	for _, a := range actions {
+		as = append(as, map[string]any{"id": a, "value": "on"})
+	}
+	return map[string]any{
+		"id": id,
+		"targets": []any{map[string]any{
+			"target":     "url",
+			"constraint": map[string]any{"operator": "matches", "value": target},
+		}},
+		"actions":  as,
+		"priority": 1,
+		"status":   status,
+	}
+}
+
+func TestListPageRules(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/pagerules", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(map[string]any{
+				"success":  true,
+				"errors":   []any{},
+				"messages": []any{},
+				"result": []any{
+					mockPageRule("rule1", "*test.org/images/*", "active", "cache_level", "browser_cache_ttl"),
+					mockPageRule("rule2", "http://test.org/*", "disabled", "always_use_https"),
+				},
+			})
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	rules, ok := h.(*api.CloudflareHandle).ListPageRules(context.Background(), mockPP, mockID("test.org", 0))
+	require.True(t, ok)
+	require.Equal(t, []api.PageRule{
+		{
+			ID:      "rule1",
+			Targets: []string{"*test.org/images/*"},
+			Actions: []string{"cache_level", "browser_cache_ttl"},
+			Status:  "active",
+		},
+		{
+			ID:      "rule2",
+			Targets: []string{"http://test.org/*"},
+			Actions: []string{"always_use_https"},
+			Status:  "disabled",
+		},
+	}, rules)
+}
+
+func TestListPageRulesFailed(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/pagerules", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to list the page rules of the zone %q: %v",
+		mockID("test.org", 0), gomock.Any())
+	rules, ok := h.(*api.CloudflareHandle).ListPageRules(context.Background(), mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.Nil(t, rules)
+}
+
+func TestDeletePageRule(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		status        int
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"success": {
+			http.StatusOK, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted the page rule %q of the zone %q",
+					"rule1", mockID("test.org", 0))
+			},
+		},
+		"not-found": {
+			http.StatusNotFound, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to delete the page rule %q of the zone %q: %v",
+					"rule1", mockID("test.org", 0), gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/pagerules/rule1", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodDelete, r.Method)
+					require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+					if tc.status != http.StatusOK {
+						w.WriteHeader(tc.status)
+						return
+					}
+
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(map[string]any{
+						"success":  true,
+						"errors":   []any{},
+						"messages": []any{},
+						"result":   map[string]any{"id": "rule1"},
+					})
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+			require.Equal(t, tc.ok,
+				h.(*api.CloudflareHandle).DeletePageRule(context.Background(), mockPP, mockID("test.org", 0), "rule1"))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// maxPageSize is the maximum number of DNS records Cloudflare returns in one page.
+const maxPageSize = 5000
+
+// ErrInvalidPageSize means the page size is not between 1 and maxPageSize.
+var ErrInvalidPageSize = errors.New("invalid page size")
+
+// A pagedRecord is a DNS record in a page of the raw API.
+type pagedRecord struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+// listRecordsPage retrieves one page of DNS records.
+func (h *CloudflareHandle) listRecordsPage(ctx context.Context, ppfmt pp.PP, zoneID string,
+	domain domain.Domain, ipNet ipnet.Type, page, pageSize int,
+) ([]pagedRecord, error) {
+	query := url.Values{
+		"name":     {domain.DNSNameASCII()},
+		"type":     {ipNet.RecordType()},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(pageSize)},
+	}
+
+	var raw json.RawMessage
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		raw, err = h.cf.Raw(ctx, http.MethodGet, fmt.Sprintf("/zones/%s/dns_records?%s", zoneID, query.Encode()), nil, nil)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rs []pagedRecord
+	if err := json.Unmarshal(raw, &rs); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return rs, nil
+}
+
+// ListRecordsPaginated lists the DNS records of a domain page by page, bypassing the cache.
+// Each page is sent to the first channel, and the next page is only requested after the current
+// one is received. The first channel is closed after the last page or the first error; the error,
+// if any, is then sent to the second channel, which is closed afterwards. Canceling the context
+// stops the listing.
+func (h *CloudflareHandle) ListRecordsPaginated(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, pageSize int,
+) (<-chan RecordPage, <-chan error) {
+	pages := make(chan RecordPage)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+
+		err := h.streamRecords(ctx, ppfmt, domain, ipNet, pageSize, pages)
+		close(pages)
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return pages, errs
+}
+
+// streamRecords sends the pages of DNS records of a domain to the channel one by one.
+func (h *CloudflareHandle) streamRecords(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, pageSize int, pages chan<- RecordPage,
+) error {
+	if pageSize <= 0 || pageSize > maxPageSize {
+		ppfmt.Warningf(pp.EmojiImpossible, "The page size %d is not between 1 and %d", pageSize, maxPageSize)
+		return fmt.Errorf("%w: %d", ErrInvalidPageSize, pageSize)
+	}
+
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return ErrRecordListFailed
+	}
+
+	for page := 1; ; page++ {
+		rs, err := h.listRecordsPage(ctx, ppfmt, zone, domain, ipNet, page, pageSize)
+		if err != nil {
+			ppfmt.Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", domain.Describe(), err)
+			return err
+		}
+
+		// The last page was full, and there are no more records.
+		if len(rs) == 0 && page > 1 {
+			return nil
+		}
+
+		rmap := make(map[string]netip.Addr, len(rs))
+		for _, r := range rs {
+			rmap[r.ID], err = netip.ParseAddr(r.Content)
+			if err != nil {
+				ppfmt.Warningf(pp.EmojiImpossible,
+					"Failed to parse the IP address in records of %q: %v", domain.Describe(), err)
+				return err //nolint:wrapcheck
+			}
+		}
+
+		select {
+		case pages <- RecordPage{Page: page, Records: rmap}:
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		}
+
+		if len(rs) < pageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// handlePagedRecords serves the pages of AAAA records of "sub.test.org" and counts the requests.
+func handlePagedRecords(t *testing.T, mux *http.ServeMux, pages []map[string]string) *atomic.Int64 {
+	t.Helper()
+
+	var accessCount atomic.Int64
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			accessCount.Add(1)
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, "sub.test.org", r.URL.Query().Get("name"))
+			require.Equal(t, "AAAA", r.URL.Query().Get("type"))
+			require.Equal(t, "2", r.URL.Query().Get("per_page"))
+
+			page, err := strconv.Atoi(r.URL.Query().Get("page"))
+			require.NoError(t, err)
+			require.True(t, 1 <= page && page <= len(pages))
+
+			w.Header().Set("content-type", "application/json")
+			err = json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org", pages[page-1]))
+			require.NoError(t, err)
+		})
+	return &accessCount
+}
+
+func TestListRecordsPaginated(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	accessCount := handlePagedRecords(t, mux, []map[string]string{
+		{"record1": "::1", "record2": "::2"},
+		{"record3": "::3", "record4": "::4"},
+		{"record5": "::5"},
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	pages, errs := h.ListRecordsPaginated(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, 2)
+
+	// The next page should not be requested before the first page is received.
+	require.Eventually(t, func() bool { return accessCount.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(time.Second)
+	require.EqualValues(t, 1, accessCount.Load())
+
+	expected := []api.RecordPage{
+		{Page: 1, Records: map[string]netip.Addr{"record1": mustIP("::1"), "record2": mustIP("::2")}},
+		{Page: 2, Records: map[string]netip.Addr{"record3": mustIP("::3"), "record4": mustIP("::4")}},
+		{Page: 3, Records: map[string]netip.Addr{"record5": mustIP("::5")}},
+	}
+	received := 0
+	for page := range pages {
+		require.Equal(t, expected[received], page)
+		received++
+		require.LessOrEqual(t, accessCount.Load(), int64(received+1))
+	}
+	require.Equal(t, len(expected), received)
+	require.NoError(t, <-errs)
+	require.EqualValues(t, 3, accessCount.Load())
+	require.True(t, zh.isExhausted())
+}
+
+func TestListRecordsPaginatedFullLastPage(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	accessCount := handlePagedRecords(t, mux, []map[string]string{
+		{"record1": "::1", "record2": "::2"},
+		{},
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	pages, errs := h.ListRecordsPaginated(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, 2)
+
+	var received []api.RecordPage
+	for page := range pages {
+		received = append(received, page)
+	}
+	require.Equal(t, []api.RecordPage{
+		{Page: 1, Records: map[string]netip.Addr{"record1": mustIP("::1"), "record2": mustIP("::2")}},
+	}, received)
+	require.NoError(t, <-errs)
+	require.EqualValues(t, 2, accessCount.Load())
+}
+
+func TestListRecordsPaginatedFailed(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", "sub.test.org", gomock.Any())
+	pages, errs := h.ListRecordsPaginated(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, 2)
+
+	for range pages {
+		require.Fail(t, "unexpected page")
+	}
+	require.Error(t, <-errs)
+}
+
+func TestListRecordsPaginatedInvalidPageSize(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newExtendedHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiImpossible, "The page size %d is not between 1 and %d", 0, 5000)
+	pages, errs := h.ListRecordsPaginated(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, 0)
+
+	for range pages {
+		require.Fail(t, "unexpected page")
+	}
+	require.ErrorIs(t, <-errs, api.ErrInvalidPageSize)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A Resolver looks up the IP addresses of a host. *net.Resolver is a Resolver.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DefaultPropagationInterval is the default interval between DNS lookups in WaitForPropagation.
+const DefaultPropagationInterval = time.Second * 5
+
+// resolvesTo checks whether the IP addresses contain the expected IP address of the IP network.
+func resolvesTo(ipNet ipnet.Type, addrs []net.IPAddr, expectedIP netip.Addr) bool {
+	for _, addr := range addrs {
+		ip, ok := netip.AddrFromSlice(addr.IP)
+		if !ok {
+			continue
+		}
+		if ip, ok = ipNet.NormalizeIP(ip); ok && ip == expectedIP {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitForPropagation polls the resolver until the domain resolves to the expected IP address,
+// for example, before an ACME challenge. It gives up once maxWait has passed.
+func (h *CloudflareHandle) WaitForPropagation(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, expectedIP netip.Addr, maxWait time.Duration,
+) bool {
+	deadline := h.clock.Now().Add(maxWait)
+
+	for attempt := 1; ; attempt++ {
+		addrs, err := h.resolver.LookupIPAddr(ctx, domain.DNSNameASCII())
+		switch {
+		case err != nil:
+			ppfmt.Infof(pp.EmojiInternet, "Attempt %d: failed to look up %q: %v", attempt, domain.Describe(), err)
+		case resolvesTo(ipNet, addrs, expectedIP):
+			ppfmt.Infof(pp.EmojiGood, "The %s records of %q have propagated (%v)",
+				ipNet.RecordType(), domain.Describe(), expectedIP)
+			return true
+		default:
+			ppfmt.Infof(pp.EmojiInternet, "Attempt %d: %q does not resolve to %v yet", attempt, domain.Describe(), expectedIP)
+		}
+
+		if ctx.Err() != nil || !h.clock.Now().Add(h.propagationInterval).Before(deadline) {
+			ppfmt.Infof(pp.EmojiError, "The %s records of %q have not propagated within %v",
+				ipNet.RecordType(), domain.Describe(), maxWait)
+			return false
+		}
+
+		h.clock.Sleep(h.propagationInterval)
+	}
+}
+
+// proxiedStates retrieves the records of the domain, bypassing the cache, and checks whether
+// there are records and whether all of them have the expected proxy status.
+func (h *CloudflareHandle) proxiedStates(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, expectedProxied bool,
+) (bool, bool) {
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return false, false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally unspecified
+	rs, err := h.cf.DNSRecords(ctx, zone, cloudflare.DNSRecord{
+		Name: domain.DNSNameASCII(),
+		Type: ipNet.RecordType(),
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", domain.Describe(), err)
+		return false, false
+	}
+
+	for i := range rs {
+		if (rs[i].Proxied != nil && *rs[i].Proxied) != expectedProxied {
+			return false, true
+		}
+	}
+	return len(rs) > 0, true
+}
+
+// WaitForProxiedChange polls the records of the domain until all of them have the expected proxy status,
+// because Cloudflare might still show the old status for a while after a change. It gives up once maxWait
+// has passed or the records cannot be retrieved.
+func (h *CloudflareHandle) WaitForProxiedChange(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, expectedProxied bool, maxWait time.Duration,
+) bool {
+	deadline := h.clock.Now().Add(maxWait)
+
+	for attempt := 1; ; attempt++ {
+		matched, ok := h.proxiedStates(ctx, ppfmt, domain, ipNet, expectedProxied)
+		switch {
+		case !ok:
+			return false
+		case matched:
+			ppfmt.Infof(pp.EmojiGood, "The %s records of %q are now %s",
+				ipNet.RecordType(), domain.Describe(), describeProxied(expectedProxied))
+			return true
+		default:
+			ppfmt.Infof(pp.EmojiInternet, "Attempt %d: the %s records of %q are not %s yet",
+				attempt, ipNet.RecordType(), domain.Describe(), describeProxied(expectedProxied))
+		}
+
+		if ctx.Err() != nil || !h.clock.Now().Add(h.propagationInterval).Before(deadline) {
+			ppfmt.Infof(pp.EmojiError, "The %s records of %q did not become %s within %v",
+				ipNet.RecordType(), domain.Describe(), describeProxied(expectedProxied), maxWait)
+			return false
+		}
+
+		h.clock.Sleep(h.propagationInterval)
+	}
+}
+
+// describeProxied describes the proxy status.
+func describeProxied(proxied bool) string {
+	if proxied {
+		return "proxied"
+	}
+	return "not proxied"
+}
@@ -0,0 +1,212 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+var errLookup = errors.New("lookup failed")
+
+// fakeResolver answers the lookups one by one, repeating the last answer when it runs out.
+type fakeResolver struct {
+	t       *testing.T
+	host    string
+	answers [][]net.IPAddr // nil means a failed lookup
+	count   int
+}
+
+func (r *fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	require.Equal(r.t, r.host, host)
+
+	answer := r.answers[len(r.answers)-1]
+	if r.count < len(r.answers) {
+		answer = r.answers[r.count]
+	}
+	r.count++
+
+	if answer == nil {
+		return nil, errLookup
+	}
+	return answer, nil
+}
+
+func ipAddrs(ips ...string) []net.IPAddr {
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip), Zone: ""})
+	}
+	return addrs
+}
+
+//nolint:funlen
+func TestWaitForPropagation(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		answers       [][]net.IPAddr
+		ok            bool
+		lookups       int
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"immediate": {
+			[][]net.IPAddr{ipAddrs("1.1.1.1", "::1")}, true, 1,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiGood, "The %s records of %q have propagated (%v)", "A", "sub.test.org", mustIP("1.1.1.1"))
+			},
+		},
+		"eventually": {
+			[][]net.IPAddr{nil, ipAddrs("2.2.2.2"), ipAddrs("::ffff:1.1.1.1")}, true, 3,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: failed to look up %q: %v", 1, "sub.test.org", errLookup),
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: %q does not resolve to %v yet",
+						2, "sub.test.org", mustIP("1.1.1.1")),
+					m.EXPECT().Infof(pp.EmojiGood, "The %s records of %q have propagated (%v)",
+						"A", "sub.test.org", mustIP("1.1.1.1")),
+				)
+			},
+		},
+		"timeout": {
+			[][]net.IPAddr{ipAddrs("2.2.2.2")}, false, 3,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: %q does not resolve to %v yet",
+						1, "sub.test.org", mustIP("1.1.1.1")),
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: %q does not resolve to %v yet",
+						2, "sub.test.org", mustIP("1.1.1.1")),
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: %q does not resolve to %v yet",
+						3, "sub.test.org", mustIP("1.1.1.1")),
+					m.EXPECT().Infof(pp.EmojiError, "The %s records of %q have not propagated within %v",
+						"A", "sub.test.org", time.Second*25),
+				)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, auth := newServerAuth(t)
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				handleTokensVerify(t, w, r)
+			})
+			resolver := &fakeResolver{t: t, host: "sub.test.org", answers: tc.answers, count: 0}
+			auth.Resolver = resolver
+			auth.PropagationInterval = time.Second * 10
+			auth.Clock = clock.NewMock(time.Now())
+
+			h, ok := auth.New(context.Background(), mocks.NewMockPP(mockCtrl), time.Second, time.Second)
+			require.True(t, ok)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+			ok = h.(*api.CloudflareHandle).WaitForPropagation(context.Background(), mockPP,
+				domain.FQDN("sub.test.org"), ipnet.IP4, mustIP("1.1.1.1"), time.Second*25)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.lookups, resolver.count)
+		})
+	}
+}
+
+//nolint:funlen
+func TestWaitForProxiedChange(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		states        []bool // the proxy status in each response, repeating the last one when it runs out
+		ok            bool
+		accesses      int64
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"eventually": {
+			[]bool{true, true, false}, true, 3,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: the %s records of %q are not %s yet",
+						1, "A", "sub.test.org", "not proxied"),
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: the %s records of %q are not %s yet",
+						2, "A", "sub.test.org", "not proxied"),
+					m.EXPECT().Infof(pp.EmojiGood, "The %s records of %q are now %s", "A", "sub.test.org", "not proxied"),
+				)
+			},
+		},
+		"timeout": {
+			[]bool{true}, false, 3,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: the %s records of %q are not %s yet",
+						1, "A", "sub.test.org", "not proxied"),
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: the %s records of %q are not %s yet",
+						2, "A", "sub.test.org", "not proxied"),
+					m.EXPECT().Infof(pp.EmojiInternet, "Attempt %d: the %s records of %q are not %s yet",
+						3, "A", "sub.test.org", "not proxied"),
+					m.EXPECT().Infof(pp.EmojiError, "The %s records of %q did not become %s within %v",
+						"A", "sub.test.org", "not proxied", time.Second*25),
+				)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, auth := newServerAuth(t)
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				handleTokensVerify(t, w, r)
+			})
+			auth.PropagationInterval = time.Second * 10
+			auth.Clock = clock.NewMock(time.Now())
+
+			// The zone stays in the cache while the mock clock advances.
+			h, ok := auth.New(context.Background(), mocks.NewMockPP(mockCtrl), time.Hour, time.Second)
+			require.True(t, ok)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			var accessCount atomic.Int64
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+					count := int(accessCount.Add(1))
+					if count > len(tc.states) {
+						count = len(tc.states)
+					}
+					proxied := tc.states[count-1]
+
+					response := mockDNSListResponse(ipnet.IP4, "sub.test.org", map[string]string{"record1": "1.1.1.1"})
+					response.Result[0].Proxied = &proxied
+
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(response)
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+			ok = h.(*api.CloudflareHandle).WaitForProxiedChange(context.Background(), mockPP,
+				domain.FQDN("sub.test.org"), ipnet.IP4, false, time.Second*25)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.accesses, accessCount.Load())
+		})
+	}
+}
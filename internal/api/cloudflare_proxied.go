@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// GetRecordProxied checks whether one DNS record is proxied by Cloudflare. The cached details
+// of the record are used if available; otherwise, only the record itself is retrieved and cached.
+func (h *CloudflareHandle) GetRecordProxied(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string,
+) (bool, bool) {
+	if detail, ok := h.cache.recordDetail.Get(id); ok {
+		return detail.Proxied, true
+	}
+
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return false, false
+	}
+
+	var r cloudflare.DNSRecord
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		r, err = h.cf.DNSRecord(ctx, zone, id)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve the %s record of %q (ID: %s): %v",
+			ipNet.RecordType(), domain.Describe(), id, err)
+		return false, false
+	}
+
+	detail := RecordDetail{
+		ID:      r.ID,
+		Type:    r.Type,
+		Name:    r.Name,
+		Content: r.Content,
+		TTL:     TTL(r.TTL),
+		Proxied: r.Proxied != nil && *r.Proxied,
+	}
+	h.cache.recordDetail.Set(id, detail)
+
+	return detail.Proxied, true
+}
@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestGetRecordProxied(t *testing.T) {
+	t.Parallel()
+
+	for name, proxied := range map[string]bool{"proxied": true, "unproxied": false} {
+		proxied := proxied
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			accessCount := 0
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					accessCount++
+					require.Equal(t, http.MethodGet, r.Method)
+
+					record := mockDNSRecord("record1", ipnet.IP6, "sub.test.org", "::1")
+					record.Proxied = &proxied
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(record))
+					require.NoError(t, err)
+				})
+
+			ctx := context.Background()
+			d := domain.FQDN("sub.test.org")
+			mockPP := mocks.NewMockPP(mockCtrl)
+
+			// cache miss
+			result, ok := h.GetRecordProxied(ctx, mockPP, d, ipnet.IP6, "record1")
+			require.True(t, ok)
+			require.Equal(t, proxied, result)
+			require.Equal(t, 1, accessCount)
+
+			// cache hit
+			result, ok = h.GetRecordProxied(ctx, mockPP, d, ipnet.IP6, "record1")
+			require.True(t, ok)
+			require.Equal(t, proxied, result)
+			require.Equal(t, 1, accessCount)
+
+			// the cache is flushed
+			require.Positive(t, h.FlushCache())
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+			result, ok = h.GetRecordProxied(ctx, mockPP, d, ipnet.IP6, "record1")
+			require.True(t, ok)
+			require.Equal(t, proxied, result)
+			require.Equal(t, 2, accessCount)
+		})
+	}
+}
+
+func TestGetRecordProxiedFailed(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve the %s record of %q (ID: %s): %v",
+		"AAAA", "sub.test.org", "record1", gomock.Any())
+	proxied, ok := h.GetRecordProxied(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
+	require.False(t, ok)
+	require.False(t, proxied)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// PTRRecordType is the type of PTR records.
+const PTRRecordType = "PTR"
+
+// UpdatePTRRecord makes the PTR record of the IP address point to the hostname. If there are
+// already PTR records, the one with the smallest ID is updated and the others are kept as they are.
+func (h *CloudflareHandle) UpdatePTRRecord(ctx context.Context, ppfmt pp.PP,
+	ip netip.Addr, hostname string, ttl TTL,
+) bool {
+	ptr, err := domain.PTRDomain(ip)
+	if err != nil {
+		ppfmt.Errorf(pp.EmojiImpossible, "Failed to find the PTR domain of %v: %v", ip, err)
+		return false
+	}
+
+	target, err := domain.New(hostname)
+	if fqdn, isFQDN := target.(domain.FQDN); err != nil || !isFQDN || fqdn == "" {
+		ppfmt.Errorf(pp.EmojiUserError, "Invalid hostname %q for the PTR record of %v", hostname, ip)
+		return false
+	}
+	content := target.DNSNameASCII()
+
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, ptr)
+	if !ok {
+		return false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally unspecified
+	rs, err := h.cf.DNSRecords(ctx, zone, cloudflare.DNSRecord{
+		Name: ptr.DNSNameASCII(),
+		Type: PTRRecordType,
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to retrieve PTR records of %q: %v", ptr.Describe(), err)
+		return false
+	}
+
+	ids := make([]string, 0, len(rs))
+	for i := range rs {
+		if rs[i].Content == content {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "The PTR record of %v already points to %q", ip, target.Describe())
+			return true
+		}
+		ids = append(ids, rs[i].ID)
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	payload := cloudflare.DNSRecord{
+		Name:    ptr.DNSNameASCII(),
+		Type:    PTRRecordType,
+		Content: content,
+		TTL:     ttl.Int(),
+	}
+
+	if len(ids) == 0 {
+		if _, err := h.cf.CreateDNSRecord(ctx, zone, payload); err != nil {
+			ppfmt.Warningf(pp.EmojiError, "Failed to add a new PTR record of %q: %v", ptr.Describe(), err)
+			return false
+		}
+		ppfmt.Noticef(pp.EmojiAddRecord, "Added a new PTR record of %v pointing to %q", ip, target.Describe())
+		return true
+	}
+
+	sort.Strings(ids)
+	if err := h.cf.UpdateDNSRecord(ctx, zone, ids[0], payload); err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to update a stale PTR record of %q (ID: %s): %v",
+			ptr.Describe(), ids[0], err)
+		return false
+	}
+	ppfmt.Noticef(pp.EmojiUpdateRecord, "Updated the PTR record of %v to point to %q", ip, target.Describe())
+	return true
+}
@@ -0,0 +1,217 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+const (
+	ptr4Zone   = "3.2.1.in-addr.arpa"
+	ptr4Domain = "4.3.2.1.in-addr.arpa"
+	ptr6Zone   = "8.b.d.0.1.0.0.2.ip6.arpa"
+	ptr6Domain = "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0." + ptr6Zone
+)
+
+// handlePTRRecords serves the PTR records of the domain and checks the record sent to Cloudflare.
+func handlePTRRecords(t *testing.T, mux *http.ServeMux, zoneName, ptrDomain string, records map[string]string,
+	expectedMethod string,
+) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID(zoneName, 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			switch r.Method {
+			case http.MethodGet:
+				require.Equal(t, ptrDomain, r.URL.Query().Get("name"))
+				require.Equal(t, api.PTRRecordType, r.URL.Query().Get("type"))
+
+				response := mockDNSListResponse(ipnet.Any, ptrDomain, records)
+				for i := range response.Result {
+					response.Result[i].Type = api.PTRRecordType
+				}
+				err := json.NewEncoder(w).Encode(response)
+				require.NoError(t, err)
+			case http.MethodPost:
+				require.Equal(t, expectedMethod, r.Method)
+				checkPTRRecord(t, r, ptrDomain)
+				err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(&cloudflare.DNSRecord{ID: "new"})) //nolint:exhaustruct,lll
+				require.NoError(t, err)
+			default:
+				require.Fail(t, "unexpected method", r.Method)
+			}
+		})
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID(zoneName, 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, expectedMethod, r.Method)
+			checkPTRRecord(t, r, ptrDomain)
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(&cloudflare.DNSRecord{ID: "record1"})) //nolint:exhaustruct,lll
+			require.NoError(t, err)
+		})
+}
+
+func checkPTRRecord(t *testing.T, r *http.Request, ptrDomain string) {
+	t.Helper()
+
+	var record cloudflare.DNSRecord
+	err := json.NewDecoder(r.Body).Decode(&record)
+	require.NoError(t, err)
+
+	require.Equal(t, ptrDomain, record.Name)
+	require.Equal(t, api.PTRRecordType, record.Type)
+	require.Equal(t, "host.test.org", record.Content)
+	require.Equal(t, 300, record.TTL)
+}
+
+func TestUpdatePTRRecord(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ip             string
+		zoneName       string
+		ptrDomain      string
+		accessCount    int
+		records        map[string]string
+		expectedMethod string
+		prepareMockPP  func(*mocks.MockPP)
+	}{
+		"ip4/create": {
+			"1.2.3.4", ptr4Zone, ptr4Domain, 2,
+			map[string]string{},
+			http.MethodPost,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiAddRecord, "Added a new PTR record of %v pointing to %q",
+					mustIP("1.2.3.4"), "host.test.org")
+			},
+		},
+		"ip4/update": {
+			"1.2.3.4", ptr4Zone, ptr4Domain, 2,
+			map[string]string{"record1": "old.test.org", "record2": "old.test.org"},
+			http.MethodPatch,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUpdateRecord, "Updated the PTR record of %v to point to %q",
+					mustIP("1.2.3.4"), "host.test.org")
+			},
+		},
+		"ip4/already-done": {
+			"1.2.3.4", ptr4Zone, ptr4Domain, 2,
+			map[string]string{"record1": "host.test.org"},
+			"",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiAlreadyDone, "The PTR record of %v already points to %q",
+					mustIP("1.2.3.4"), "host.test.org")
+			},
+		},
+		"ip6/create": {
+			"2001:db8::1", ptr6Zone, ptr6Domain, 25,
+			map[string]string{},
+			http.MethodPost,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiAddRecord, "Added a new PTR record of %v pointing to %q",
+					mustIP("2001:db8::1"), "host.test.org")
+			},
+		},
+		"ip6/update": {
+			"2001:db8::1", ptr6Zone, ptr6Domain, 25,
+			map[string]string{"record1": "old.test.org"},
+			http.MethodPatch,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUpdateRecord, "Updated the PTR record of %v to point to %q",
+					mustIP("2001:db8::1"), "host.test.org")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{tc.zoneName: {"active"}}, tc.accessCount)
+
+			handlePTRRecords(t, mux, tc.zoneName, tc.ptrDomain, tc.records, tc.expectedMethod)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+			ok := h.UpdatePTRRecord(context.Background(), mockPP, mustIP(tc.ip), "host.test.org.", 300)
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestUpdatePTRRecordNoZone(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 8)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", ptr4Domain),
+		mockPP.EXPECT().Warningf(pp.EmojiUserError,
+			"No zone accessible with the API token contains %q; is the domain on Cloudflare?", ptr4Domain),
+	)
+	ok := h.UpdatePTRRecord(context.Background(), mockPP, mustIP("1.2.3.4"), "host.test.org", api.TTLAuto)
+	require.False(t, ok)
+}
+
+func TestUpdatePTRRecordInvalidHostname(t *testing.T) {
+	t.Parallel()
+
+	for name, hostname := range map[string]string{
+		"empty":    "",
+		"wildcard": "*.test.org",
+		"illegal":  "xn--a.test.org",
+	} {
+		hostname := hostname
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{ptr4Zone: {"active"}}, 0)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError, "Invalid hostname %q for the PTR record of %v",
+				hostname, mustIP("1.2.3.4"))
+			ok := h.UpdatePTRRecord(context.Background(), mockPP, mustIP("1.2.3.4"), hostname, api.TTLAuto)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestUpdatePTRRecordInvalidIP(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{ptr4Zone: {"active"}}, 0)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Errorf(pp.EmojiImpossible, "Failed to find the PTR domain of %v: %v", gomock.Any(), gomock.Any())
+	ok := h.UpdatePTRRecord(context.Background(), mockPP, netip.Addr{}, "host.test.org", api.TTLAuto)
+	require.False(t, ok)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// PurgeCDNCache purges the cached copies of the URLs in the CDN of Cloudflare. It is never called
+// automatically because many DDNS setups do not use the CDN at all.
+func (h *CloudflareHandle) PurgeCDNCache(ctx context.Context, ppfmt pp.PP, zoneID string, files []string) bool {
+	if len(files) == 0 {
+		return true
+	}
+
+	// Purging the same URLs again is harmless, so it is retried like a read.
+	err := h.withRetries(ctx, ppfmt, operationRead, func() error {
+		_, err := h.cf.PurgeCache(ctx, zoneID, cloudflare.PurgeCacheRequest{
+			Everything: false,
+			Files:      files,
+			Tags:       nil,
+			Hosts:      nil,
+			Prefixes:   nil,
+		})
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to purge the cache of the zone %q: %v", zoneID, err)
+		return false
+	}
+
+	ppfmt.Infof(pp.EmojiGood, "Purged the cache of %s", strings.Join(files, ", "))
+	return true
+}
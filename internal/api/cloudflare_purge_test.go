@@ -0,0 +1,67 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestPurgeCDNCache(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	accessCount := 0
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/purge_cache", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			accessCount++
+			require.Equal(t, http.MethodPost, r.Method)
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"files":["https://test.org/","https://sub.test.org/index.html"]}`, string(body))
+
+			w.Header().Set("content-type", "application/json")
+			_, err = fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":{"id":%q}}`, mockID("test.org", 0))
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiGood, "Purged the cache of %s", "https://test.org/, https://sub.test.org/index.html")
+	ok := h.(*api.CloudflareHandle).PurgeCDNCache(context.Background(), mockPP, mockID("test.org", 0),
+		[]string{"https://test.org/", "https://sub.test.org/index.html"})
+	require.True(t, ok)
+	require.Equal(t, 1, accessCount)
+}
+
+func TestPurgeCDNCacheEmpty(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	require.True(t, h.(*api.CloudflareHandle).PurgeCDNCache(context.Background(), mockPP, mockID("test.org", 0), nil))
+}
+
+func TestPurgeCDNCacheInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to purge the cache of the zone %q: %v", "zone", gomock.Any())
+	ok := h.(*api.CloudflareHandle).PurgeCDNCache(context.Background(), mockPP, "zone", []string{"https://test.org/"})
+	require.False(t, ok)
+}
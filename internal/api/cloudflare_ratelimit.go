@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// DefaultRateLimitWarning is the default number of remaining API requests below which
+// RateLimitStatus will emit warnings.
+const DefaultRateLimitWarning = 100
+
+// A rateLimit keeps the rate limit reported by the most recent API response with the headers
+// X-RateLimit-Remaining and X-RateLimit-Reset. It is shared by all requests of a handle.
+type rateLimit struct {
+	mutex     sync.Mutex
+	known     bool
+	remaining int
+	resetAt   time.Time
+}
+
+// record updates the rate limit from the headers of a response. Responses without
+// valid headers are ignored. X-RateLimit-Reset is the Unix time (in seconds) of the reset.
+func (l *rateLimit) record(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.known, l.remaining, l.resetAt = true, remaining, time.Unix(reset, 0).UTC()
+}
+
+// A rateLimitTransport records the rate limit reported by each response.
+type rateLimitTransport struct {
+	next  http.RoundTripper
+	limit *rateLimit
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		t.limit.record(resp.Header)
+	}
+	return resp, err //nolint:wrapcheck
+}
+
+// RateLimitStatus returns the number of remaining API requests and the time of the reset,
+// as reported by the most recent API response. A warning is printed if the remaining
+// requests are fewer than the threshold in CloudflareAuth.RateLimitWarning.
+// No new requests are made; it fails if no responses so far have reported the rate limit.
+func (h *CloudflareHandle) RateLimitStatus(_ context.Context, ppfmt pp.PP) (int, time.Time, bool) {
+	h.rateLimit.mutex.Lock()
+	known, remaining, resetAt := h.rateLimit.known, h.rateLimit.remaining, h.rateLimit.resetAt
+	h.rateLimit.mutex.Unlock()
+
+	if !known {
+		ppfmt.Warningf(pp.EmojiWarning, "No responses from Cloudflare have reported the rate limit of the API")
+		return 0, time.Time{}, false
+	}
+
+	if remaining < h.rateLimitWarning {
+		ppfmt.Warningf(pp.EmojiWarning,
+			"Only %d requests to the Cloudflare API remain before the rate limit resets at %s",
+			remaining, resetAt.Format(time.RFC3339))
+	}
+
+	return remaining, resetAt, true
+}
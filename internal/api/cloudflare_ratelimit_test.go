@@ -0,0 +1,150 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+//nolint:funlen
+func TestRateLimitStatus(t *testing.T) {
+	t.Parallel()
+
+	reset := time.Date(2099, time.January, 31, 15, 56, 36, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		threshold         int
+		headers           map[string]string
+		expectedRemaining int
+		expectedResetAt   time.Time
+		expectedOK        bool
+		prepareMockPP     func(*mocks.MockPP)
+	}{
+		"plenty": {
+			0,
+			map[string]string{"X-RateLimit-Remaining": "1199", "X-RateLimit-Reset": fmt.Sprint(reset.Unix())},
+			1199, reset, true,
+			nil,
+		},
+		"low": {
+			0,
+			map[string]string{"X-RateLimit-Remaining": "99", "X-RateLimit-Reset": fmt.Sprint(reset.Unix())},
+			99, reset, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning,
+					"Only %d requests to the Cloudflare API remain before the rate limit resets at %s",
+					99, "2099-01-31T15:56:36Z")
+			},
+		},
+		"custom-threshold": {
+			1000,
+			map[string]string{"X-RateLimit-Remaining": "999", "X-RateLimit-Reset": fmt.Sprint(reset.Unix())},
+			999, reset, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning,
+					"Only %d requests to the Cloudflare API remain before the rate limit resets at %s",
+					999, "2099-01-31T15:56:36Z")
+			},
+		},
+		"missing": {
+			0,
+			map[string]string{},
+			0, time.Time{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning,
+					"No responses from Cloudflare have reported the rate limit of the API")
+			},
+		},
+		"invalid": {
+			0,
+			map[string]string{"X-RateLimit-Remaining": "many", "X-RateLimit-Reset": fmt.Sprint(reset.Unix())},
+			0, time.Time{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiWarning,
+					"No responses from Cloudflare have reported the rate limit of the API")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, auth := newServerAuth(t)
+			auth.RateLimitWarning = tc.threshold
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				handleTokensVerify(t, w, r)
+			})
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/custom_ns", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					for k, v := range tc.headers {
+						w.Header().Set(k, v)
+					}
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(map[string]any{
+						"success":  true,
+						"errors":   []any{},
+						"messages": []any{},
+						"result":   []any{},
+					})
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.True(t, ok)
+			ch := h.(*api.CloudflareHandle) //nolint:forcetypeassert
+
+			_, ok = ch.CustomNameservers(context.Background(), mockPP, mockID("test.org", 0))
+			require.True(t, ok)
+
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			remaining, resetAt, ok := ch.RateLimitStatus(context.Background(), mockPP)
+			require.Equal(t, tc.expectedOK, ok)
+			require.Equal(t, tc.expectedRemaining, remaining)
+			require.Equal(t, tc.expectedResetAt, resetAt)
+		})
+	}
+}
+
+func TestRateLimitStatusCloneForZone(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/custom_ns", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RateLimit-Remaining", "500")
+			w.Header().Set("X-RateLimit-Reset", "4073730996")
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(map[string]any{
+				"success":  true,
+				"errors":   []any{},
+				"messages": []any{},
+				"result":   []any{},
+			})
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	_, ok := h.(*api.CloudflareHandle).CustomNameservers(context.Background(), mockPP, mockID("test.org", 0))
+	require.True(t, ok)
+
+	clone := h.(*api.CloudflareHandle).CloneForZone(mockID("test.org", 0))
+	remaining, resetAt, ok := clone.(*api.CloudflareHandle).RateLimitStatus(context.Background(), mockPP)
+	require.True(t, ok)
+	require.Equal(t, 500, remaining)
+	require.Equal(t, time.Unix(4073730996, 0).UTC(), resetAt)
+}
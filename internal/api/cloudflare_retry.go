@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/url"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A RetryConfig controls the retries of failed API calls. The zero value keeps the built-in
+// retries of cloudflare-go, which retry all operations (including deletions) on transient errors.
+type RetryConfig struct {
+	MaxRetries int           // the maximum number of retries of one call; zero means the built-in retries
+	MinDelay   time.Duration // the delay before the first retry, doubled for each further retry
+	MaxDelay   time.Duration // the maximum delay between retries (zero means no limit)
+	SafeRetry  bool          // only retry the operations that are safe to repeat
+}
+
+const (
+	// DefaultRetryMinDelay is the default delay before the first retry.
+	DefaultRetryMinDelay = time.Second
+	// DefaultRetryMaxDelay is the default maximum delay between retries.
+	DefaultRetryMaxDelay = time.Second * 30
+)
+
+// An operationKind tells whether an operation is safe to repeat.
+type operationKind int
+
+const (
+	// operationRead does not change anything and is always safe to repeat.
+	operationRead operationKind = iota
+	// operationCreate is only safe to repeat if the request never reached Cloudflare.
+	operationCreate
+	// operationWrite changes or deletes existing records and is unsafe to repeat:
+	// the record might have been deleted and then re-created by another process.
+	operationWrite
+)
+
+// isNetworkError checks whether the request failed before any response was received.
+func isNetworkError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// isTransientError checks whether the error is likely to go away by itself. Without its own retries,
+// cloudflare-go reports 4xx responses with typed errors, but 5xx responses and rate limiting with plain ones.
+func isTransientError(err error) bool {
+	var (
+		requestErr        *cloudflare.RequestError
+		authenticationErr *cloudflare.AuthenticationError
+		authorizationErr  *cloudflare.AuthorizationError
+		notFoundErr       *cloudflare.NotFoundError
+	)
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &requestErr), errors.As(err, &authenticationErr),
+		errors.As(err, &authorizationErr), errors.As(err, &notFoundErr):
+		return false
+	default:
+		return true
+	}
+}
+
+// shouldRetry checks whether an operation of the kind should be retried after the error.
+func (r RetryConfig) shouldRetry(kind operationKind, err error) bool {
+	if !r.SafeRetry {
+		return isTransientError(err)
+	}
+
+	switch kind {
+	case operationRead:
+		return isTransientError(err)
+	case operationCreate:
+		return isNetworkError(err)
+	default:
+		return false
+	}
+}
+
+// delay computes the delay before the retry (counting from 1), with a random jitter of up to a half.
+func (r RetryConfig) delay(retry int) time.Duration {
+	d := r.MinDelay << (retry - 1)
+	if d <= 0 || (r.MaxDelay > 0 && d > r.MaxDelay) {
+		d = r.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) //nolint:gosec // No need for cryptographic randomness
+}
+
+// withRetries calls f until it succeeds, its error should not be retried, or the retries are used up.
+// Waiting for the next retry stops as soon as ctx is done, in which case ctx.Err() is returned.
+func (h *CloudflareHandle) withRetries(ctx context.Context, ppfmt pp.PP, kind operationKind, f func() error) error {
+	err := f()
+	for retry := 1; err != nil && retry <= h.retry.MaxRetries && h.retry.shouldRetry(kind, err); retry++ {
+		if ctx.Err() != nil {
+			return err
+		}
+
+		delay := h.retry.delay(retry)
+		ppfmt.Infof(pp.EmojiRepeatOnce, "Retrying in %v after a transient error: %v", delay.Round(time.Millisecond), err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-h.clock.After(delay):
+		}
+
+		err = f()
+	}
+
+	return err
+}
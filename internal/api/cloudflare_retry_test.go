@@ -0,0 +1,225 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func newRetryHandle(t *testing.T, safeRetry bool) (*http.ServeMux, api.Handle) {
+	t.Helper()
+
+	mux, auth := newServerAuth(t)
+	auth.Retry = api.RetryConfig{MaxRetries: 1, MinDelay: time.Second, MaxDelay: 0, SafeRetry: safeRetry}
+	return mux, newRetryHandleWithAuth(t, mux, auth)
+}
+
+func newRetryHandleWithAuth(t *testing.T, mux *http.ServeMux, auth *api.CloudflareAuth) api.Handle {
+	t.Helper()
+	mockCtrl := gomock.NewController(t)
+
+	// Idle connections are not kept. Otherwise, the HTTP client itself could silently retry
+	// idempotent requests that failed on reused connections.
+	auth.TransportConfig.MaxIdleConnsPerHost = -1
+
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+
+	return h
+}
+
+// dropConnection simulates a network failure by closing the connection without any response.
+func dropConnection(t *testing.T, w http.ResponseWriter) {
+	t.Helper()
+
+	hijacker, ok := w.(http.Hijacker)
+	require.True(t, ok)
+	conn, _, err := hijacker.Hijack()
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+}
+
+func TestRetryListRecords(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newRetryHandle(t, true)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	var accessCount atomic.Int64
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+			if accessCount.Add(1) == 1 {
+				dropConnection(t, w)
+				return
+			}
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org",
+				map[string]string{"record1": "::1"}))
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiRepeatOnce, "Retrying in %v after a transient error: %v", gomock.Any(), gomock.Any())
+	rmap, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, map[string]netip.Addr{"record1": mustIP("::1")}, rmap)
+	require.EqualValues(t, 2, accessCount.Load())
+}
+
+func TestRetryListAllZoneRecords(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newRetryHandle(t, true)
+
+	var accessCount atomic.Int64
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+			if accessCount.Add(1) == 1 {
+				dropConnection(t, w)
+				return
+			}
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org",
+				map[string]string{"record1": "::1"}))
+			require.NoError(t, err)
+		})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiRepeatOnce, "Retrying in %v after a transient error: %v", gomock.Any(), gomock.Any())
+	rs, ok := h.(*api.CloudflareHandle).ListAllZoneRecords(context.Background(), mockPP, mockID("test.org", 0))
+	require.True(t, ok)
+	require.Len(t, rs, 1)
+	require.EqualValues(t, 2, accessCount.Load())
+}
+
+func TestRetryCanceled(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+	// The real clock would wait for an hour before the retry if the cancellation were ignored.
+	auth.Clock = clock.Real{}
+	auth.Retry = api.RetryConfig{MaxRetries: 1, MinDelay: time.Hour, MaxDelay: 0, SafeRetry: true}
+	h := newRetryHandleWithAuth(t, mux, auth)
+
+	var accessCount atomic.Int64
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			accessCount.Add(1)
+			dropConnection(t, w)
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Infof(pp.EmojiRepeatOnce, "Retrying in %v after a transient error: %v", gomock.Any(), gomock.Any()).
+			Do(func(pp.Emoji, string, ...any) { cancel() }),
+		mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records in the zone %q: %v",
+			mockID("test.org", 0), context.Canceled),
+	)
+	start := time.Now()
+	rs, ok := h.(*api.CloudflareHandle).ListAllZoneRecords(ctx, mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.Nil(t, rs)
+	require.EqualValues(t, 1, accessCount.Load())
+	require.Less(t, time.Since(start), time.Minute)
+}
+
+//nolint:funlen
+func TestRetryWrites(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		safeRetry   bool
+		method      string
+		networkErr  bool
+		accessCount int64
+	}{
+		"delete/safe":          {true, http.MethodDelete, true, 1},
+		"delete/unsafe":        {false, http.MethodDelete, true, 2},
+		"update/safe":          {true, http.MethodPatch, true, 1},
+		"update/unsafe":        {false, http.MethodPatch, true, 2},
+		"create/safe/network":  {true, http.MethodPost, true, 2},
+		"create/safe/service":  {true, http.MethodPost, false, 1},
+		"create/unsafe/server": {false, http.MethodPost, false, 2},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newRetryHandle(t, tc.safeRetry)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			var accessCount atomic.Int64
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, tc.method, r.Method)
+				accessCount.Add(1)
+				if tc.networkErr {
+					dropConnection(t, w)
+				} else {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)), handler)
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)), handler)
+
+			ctx := context.Background()
+			d := domain.FQDN("sub.test.org")
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.accessCount > 1 {
+				mockPP.EXPECT().Infof(pp.EmojiRepeatOnce, "Retrying in %v after a transient error: %v",
+					gomock.Any(), gomock.Any())
+			}
+
+			switch tc.method {
+			case http.MethodDelete:
+				mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to delete a stale %s record of %q (ID: %s): %v",
+					"AAAA", "sub.test.org", "record1", gomock.Any())
+				require.False(t, h.DeleteRecord(ctx, mockPP, d, ipnet.IP6, "record1"))
+			case http.MethodPatch:
+				mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
+					"AAAA", "sub.test.org", "record1", gomock.Any())
+				require.False(t, h.UpdateRecord(ctx, mockPP, d, ipnet.IP6, "record1", mustIP("::1")))
+			case http.MethodPost:
+				mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to add a new %s record of %q: %v",
+					"AAAA", "sub.test.org", gomock.Any())
+				_, ok := h.CreateRecord(ctx, mockPP, d, ipnet.IP6, mustIP("::1"), api.TTLAuto, false, "")
+				require.False(t, ok)
+			}
+			require.Equal(t, tc.accessCount, accessCount.Load())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// SetZoneSSLMode sets the SSL/TLS encryption mode of a zone, which is one of "off", "flexible",
+// "full", and "strict" (shown as "Full (strict)" on the dashboard).
+func (h *CloudflareHandle) SetZoneSSLMode(ctx context.Context, ppfmt pp.PP, zoneID, mode string) bool {
+	switch mode {
+	case "off", "flexible", "full", "strict":
+	default:
+		ppfmt.Errorf(pp.EmojiUserError,
+			`Invalid SSL/TLS mode %q; it should be "off", "flexible", "full", or "strict"`, mode)
+		return false
+	}
+
+	// Setting the same mode again is harmless, so it is retried like a read.
+	err := h.withRetries(ctx, ppfmt, operationRead, func() error {
+		_, err := h.cf.UpdateZoneSSLSettings(ctx, zoneID, mode)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to set the SSL/TLS mode of the zone %q: %v", zoneID, err)
+		return false
+	}
+
+	ppfmt.Noticef(pp.EmojiConfig, "Set the SSL/TLS mode of the zone %q to %q", zoneID, mode)
+	return true
+}
+
+// onOff converts a boolean to the value of an on/off setting of a zone.
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// SetZoneAlwaysUseHTTPS turns "Always Use HTTPS" (redirecting all HTTP requests to HTTPS) on or off for a zone.
+func (h *CloudflareHandle) SetZoneAlwaysUseHTTPS(ctx context.Context, ppfmt pp.PP, zoneID string, enabled bool) bool {
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	setting := cloudflare.ZoneSetting{Value: onOff(enabled)}
+
+	// Setting the same value again is harmless, so it is retried like a read.
+	err := h.withRetries(ctx, ppfmt, operationRead, func() error {
+		_, err := h.cf.UpdateZoneSingleSetting(ctx, zoneID, "always_use_https", setting)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to set Always Use HTTPS of the zone %q: %v", zoneID, err)
+		return false
+	}
+
+	ppfmt.Infof(pp.EmojiConfig, "Turned %s Always Use HTTPS of the zone %q", onOff(enabled), zoneID)
+	return true
+}
+
+// GetZoneAlwaysUseHTTPS checks whether "Always Use HTTPS" is on for a zone.
+func (h *CloudflareHandle) GetZoneAlwaysUseHTTPS(ctx context.Context, ppfmt pp.PP, zoneID string) (bool, bool) {
+	var res cloudflare.ZoneSetting
+	err := h.withRetries(ctx, ppfmt, operationRead, func() (err error) {
+		res, err = h.cf.ZoneSingleSetting(ctx, zoneID, "always_use_https")
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to check Always Use HTTPS of the zone %q: %v", zoneID, err)
+		return false, false
+	}
+
+	switch res.Value {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	default:
+		ppfmt.Warningf(pp.EmojiImpossible, "Unexpected value of Always Use HTTPS of the zone %q: %v", zoneID, res.Value)
+		return false, false
+	}
+}
@@ -0,0 +1,190 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestSetZoneSSLMode(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range [...]string{"off", "flexible", "full", "strict"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			accessCount := 0
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/settings/ssl", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					accessCount++
+					require.Equal(t, http.MethodPatch, r.Method)
+
+					var body map[string]any
+					err := json.NewDecoder(r.Body).Decode(&body)
+					require.NoError(t, err)
+					require.Equal(t, mode, body["value"])
+
+					w.Header().Set("content-type", "application/json")
+					err = json.NewEncoder(w).Encode(map[string]any{
+						"success":  true,
+						"errors":   []any{},
+						"messages": []any{},
+						"result":   map[string]any{"id": "ssl", "value": mode, "editable": true},
+					})
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Noticef(pp.EmojiConfig, "Set the SSL/TLS mode of the zone %q to %q", mockID("test.org", 0), mode)
+			require.True(t, h.(*api.CloudflareHandle).SetZoneSSLMode(context.Background(), mockPP, mockID("test.org", 0), mode))
+			require.Equal(t, 1, accessCount)
+		})
+	}
+}
+
+func TestSetZoneSSLModeInvalidMode(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range [...]string{"", "Full (strict)", "STRICT", "on"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/settings/ssl", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Fail(t, "unexpected API call")
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError,
+				`Invalid SSL/TLS mode %q; it should be "off", "flexible", "full", or "strict"`, mode)
+			require.False(t, h.(*api.CloudflareHandle).SetZoneSSLMode(context.Background(), mockPP, mockID("test.org", 0), mode))
+		})
+	}
+}
+
+func TestSetZoneSSLModeInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to set the SSL/TLS mode of the zone %q: %v", "zone", gomock.Any())
+	require.False(t, h.(*api.CloudflareHandle).SetZoneSSLMode(context.Background(), mockPP, "zone", "strict"))
+}
+
+// handleZoneSetting serves a zone setting and records the values it was set to.
+func handleZoneSetting(t *testing.T, mux *http.ServeMux, setting string, value string, updates *[]string) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/settings/%s", mockID("test.org", 0), setting),
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+			case http.MethodPatch:
+				var body map[string]any
+				err := json.NewDecoder(r.Body).Decode(&body)
+				require.NoError(t, err)
+				newValue, ok := body["value"].(string)
+				require.True(t, ok)
+				*updates = append(*updates, newValue)
+				value = newValue
+			default:
+				require.Fail(t, "unexpected method", r.Method)
+			}
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(map[string]any{
+				"success":  true,
+				"errors":   []any{},
+				"messages": []any{},
+				"result":   map[string]any{"id": setting, "value": value, "editable": true},
+			})
+			require.NoError(t, err)
+		})
+}
+
+func TestSetZoneAlwaysUseHTTPS(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		enabled bool
+		value   string
+	}{
+		"enable":  {true, "on"},
+		"disable": {false, "off"},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			var updates []string
+			handleZoneSetting(t, mux, "always_use_https", "unknown", &updates)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Infof(pp.EmojiConfig, "Turned %s Always Use HTTPS of the zone %q", tc.value, mockID("test.org", 0))
+			ok := h.(*api.CloudflareHandle).SetZoneAlwaysUseHTTPS(context.Background(), mockPP,
+				mockID("test.org", 0), tc.enabled)
+			require.True(t, ok)
+			require.Equal(t, []string{tc.value}, updates)
+
+			enabled, ok := h.(*api.CloudflareHandle).GetZoneAlwaysUseHTTPS(context.Background(), mockPP,
+				mockID("test.org", 0))
+			require.True(t, ok)
+			require.Equal(t, tc.enabled, enabled)
+		})
+	}
+}
+
+func TestGetZoneAlwaysUseHTTPSUnexpected(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	var updates []string
+	handleZoneSetting(t, mux, "always_use_https", "maybe", &updates)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiImpossible, "Unexpected value of Always Use HTTPS of the zone %q: %v",
+		mockID("test.org", 0), "maybe")
+	enabled, ok := h.(*api.CloudflareHandle).GetZoneAlwaysUseHTTPS(context.Background(), mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.False(t, enabled)
+}
+
+func TestZoneAlwaysUseHTTPSInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to set Always Use HTTPS of the zone %q: %v", "zone", gomock.Any()),
+		mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check Always Use HTTPS of the zone %q: %v", "zone", gomock.Any()),
+	)
+	require.False(t, h.(*api.CloudflareHandle).SetZoneAlwaysUseHTTPS(context.Background(), mockPP, "zone", true))
+	enabled, ok := h.(*api.CloudflareHandle).GetZoneAlwaysUseHTTPS(context.Background(), mockPP, "zone")
+	require.False(t, ok)
+	require.False(t, enabled)
+}
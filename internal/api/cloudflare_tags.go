@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// maxTagLength is the maximum length of a tag of a DNS record.
+const maxTagLength = 50
+
+// isValidTag checks whether a tag is non-empty and consists of at most maxTagLength
+// ASCII letters, digits, and hyphens.
+func isValidTag(tag string) bool {
+	if len(tag) == 0 || len(tag) > maxTagLength {
+		return false
+	}
+
+	for _, c := range tag {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// A tagsPayload is the payload to set the tags of a DNS record. The DNSRecord type of
+// cloudflare-go does not have the tags field, so the raw API is used.
+type tagsPayload struct {
+	Tags []string `json:"tags"`
+}
+
+// SetRecordTags replaces the tags of a DNS record. All tags are checked before the API is called.
+func (h *CloudflareHandle) SetRecordTags(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string, tags []string,
+) bool {
+	for _, tag := range tags {
+		if !isValidTag(tag) {
+			ppfmt.Errorf(pp.EmojiUserError,
+				"Invalid tag %q; a tag should consist of at most %d letters, digits, and hyphens", tag, maxTagLength)
+			return false
+		}
+	}
+
+	zone, ok := h.ZoneOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return false
+	}
+
+	// The tags must be sent as [] instead of null to remove all of them.
+	payload := tagsPayload{Tags: append([]string{}, tags...)}
+
+	err := h.withRetries(ctx, ppfmt, operationWrite, func() error {
+		_, err := h.cf.Raw(ctx, http.MethodPatch, fmt.Sprintf("/zones/%s/dns_records/%s", zone, id), payload, nil)
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		ppfmt.Warningf(pp.EmojiError, "Failed to set the tags of the %s record of %q (ID: %s): %v",
+			ipNet.RecordType(), domain.Describe(), id, err)
+		return false
+	}
+
+	return true
+}
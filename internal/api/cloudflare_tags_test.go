@@ -0,0 +1,108 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestSetRecordTags(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		tags     []string
+		expected string
+	}{
+		"tags":  {[]string{"team-a", "Prod2"}, `{"tags":["team-a","Prod2"]}`},
+		"max":   {[]string{strings.Repeat("a", 50)}, `{"tags":["` + strings.Repeat("a", 50) + `"]}`},
+		"empty": {[]string{}, `{"tags":[]}`},
+		"nil":   {nil, `{"tags":[]}`},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodPatch, r.Method)
+
+					var body json.RawMessage
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+					require.JSONEq(t, tc.expected, string(body))
+
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP4, "sub.test.org", "1.1.1.1"))
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			ok := h.SetRecordTags(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4, "record1", tc.tags)
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestSetRecordTagsInvalidTag(t *testing.T) {
+	t.Parallel()
+
+	for name, tag := range map[string]string{
+		"empty":      "",
+		"too-long":   strings.Repeat("a", 51),
+		"underscore": "team_a",
+		"colon":      "team:a",
+		"space":      "team a",
+		"non-ascii":  "équipe",
+	} {
+		tag := tag
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newExtendedHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 0)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError,
+				"Invalid tag %q; a tag should consist of at most %d letters, digits, and hyphens", tag, 50)
+			ok := h.SetRecordTags(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4, "record1",
+				[]string{"valid", tag})
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestSetRecordTagsInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newExtendedHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to set the tags of the %s record of %q (ID: %s): %v",
+		"A", "sub.test.org", "record1", gomock.Any())
+	ok := h.SetRecordTags(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4, "record1",
+		[]string{"team-a"})
+	require.False(t, ok)
+}
@@ -2,14 +2,26 @@ package api_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/netip"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +30,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/clock"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/mocks"
@@ -57,9 +70,26 @@ func newServerAuth(t *testing.T) (*http.ServeMux, *api.CloudflareAuth) {
 	t.Cleanup(ts.Close)
 
 	auth := api.CloudflareAuth{
-		Token:     mockToken,
-		AccountID: mockAccount,
-		BaseURL:   ts.URL,
+		Token:                mockToken,
+		AccountID:            mockAccount,
+		BaseURL:              ts.URL,
+		TokenExpiryWarning:   api.DefaultTokenExpiryWarning,
+		RejectCloudflareIPs:  false,
+		ClientCert:           tls.Certificate{},
+		ClientCA:             nil,
+		ProxyURL:             "",
+		Clock:                clock.NewMock(time.Now()),
+		TransportConfig:      api.TransportConfig{},
+		UseBatchAPI:          false,
+		Retry:                api.RetryConfig{MaxRetries: 0, MinDelay: 0, MaxDelay: 0, SafeRetry: false},
+		TokenVerifyPath:      "",
+		StartupTimeout:       0,
+		StartupRetryInterval: 0,
+		Resolver:             nil,
+		PropagationInterval:  0,
+		AccessClientID:       "",
+		AccessClientSecret:   "",
+		RateLimitWarning:     api.DefaultRateLimitWarning,
 	}
 
 	return mux, &auth
@@ -100,13 +130,23 @@ func newHandle(t *testing.T) (*http.ServeMux, api.Handle) {
 	})
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	h, ok := auth.New(context.Background(), mockPP, time.Second)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
 	require.True(t, ok)
 	require.NotNil(t, h)
 
 	return mux, h
 }
 
+func newExtendedHandle(t *testing.T) (*http.ServeMux, api.ExtendedHandle) {
+	t.Helper()
+
+	mux, h := newHandle(t)
+	extendedHandle, ok := h.(api.ExtendedHandle)
+	require.True(t, ok)
+
+	return mux, extendedHandle
+}
+
 func TestNewValid(t *testing.T) {
 	t.Parallel()
 
@@ -122,11 +162,79 @@ func TestNewEmpty(t *testing.T) {
 	auth.Token = ""
 	mockPP := mocks.NewMockPP(mockCtrl)
 	mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to prepare the Cloudflare authentication: %v", gomock.Any())
-	h, ok := auth.New(context.Background(), mockPP, time.Second)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.False(t, ok)
+	require.Nil(t, h)
+}
+
+func TestNewInvalidBaseURL(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		baseURL string
+		format  string
+	}{
+		"no-scheme":   {"api.cloudflare.com/client/v4", "Invalid CF_BASE_URL: %q should use http:// or https://"},
+		"ftp":         {"ftp://api.cloudflare.com/client/v4", "Invalid CF_BASE_URL: %q should use http:// or https://"},
+		"empty-host":  {"https:///client/v4", "Invalid CF_BASE_URL: %q does not specify a host"},
+		"only-scheme": {"http://", "Invalid CF_BASE_URL: %q does not specify a host"},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			_, auth := newServerAuth(t)
+			auth.BaseURL = tc.baseURL
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Errorf(pp.EmojiUserError, tc.format, tc.baseURL)
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.False(t, ok)
+			require.Nil(t, h)
+		})
+	}
+}
+
+func TestNewUnparsableBaseURL(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, auth := newServerAuth(t)
+	auth.BaseURL = "http://[::1/client/v4"
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Errorf(pp.EmojiUserError, "Invalid CF_BASE_URL: %v", gomock.Any())
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
 	require.False(t, ok)
 	require.Nil(t, h)
 }
 
+func TestNewHTTPSBaseURL(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
+	ts := httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+	require.True(t, strings.HasPrefix(ts.URL, "https://"))
+
+	serverPool := x509.NewCertPool()
+	serverPool.AddCert(ts.Certificate())
+
+	_, auth := newServerAuth(t)
+	auth.BaseURL = ts.URL
+	auth.ClientCA = serverPool
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
+}
+
 func TestNewInvalid(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
@@ -154,289 +262,281 @@ func TestNewInvalid(t *testing.T) {
 		mockPP.EXPECT().Errorf(pp.EmojiUserError, "The Cloudflare API token could not be verified: %v", gomock.Any()),
 		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Please double-check CF_API_TOKEN or CF_API_TOKEN_FILE"),
 	)
-	h, ok := auth.New(context.Background(), mockPP, time.Second)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
 	require.False(t, ok)
 	require.Nil(t, h)
 }
 
-func mockZone(name string, i int, status string) *cloudflare.Zone {
-	return &cloudflare.Zone{ //nolint:exhaustruct
-		ID:     mockID(name, i),
-		Name:   name,
-		Status: status,
-	}
-}
-
-func mockZonesResponse(zoneName string, zoneStatuses []string) *cloudflare.ZonesResponse {
-	numZones := len(zoneStatuses)
+func TestNewTokenVerifyPath(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
 
-	if numZones > 50 {
-		panic("mockZonesResponse got too many zone names")
-	}
+	mux, auth := newServerAuth(t)
+	auth.BaseURL += "/platform/v4"
+	auth.TokenVerifyPath = "/tokens/check"
 
-	zones := make([]cloudflare.Zone, len(zoneStatuses))
-	for i, status := range zoneStatuses {
-		zones[i] = *mockZone(zoneName, i, status)
-	}
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		require.Fail(t, "the default token verification endpoint should not be called")
+	})
+	var accessCount int
+	mux.HandleFunc("/platform/v4/tokens/check", func(w http.ResponseWriter, r *http.Request) {
+		accessCount++
+		handleTokensVerify(t, w, r)
+	})
 
-	return &cloudflare.ZonesResponse{
-		Result: zones,
-		ResultInfo: cloudflare.ResultInfo{
-			Page:       1,
-			PerPage:    50,
-			TotalPages: (numZones + 49) / 50,
-			Count:      numZones,
-			Total:      numZones,
-			Cursor:     "",
-			Cursors:    cloudflare.ResultInfoCursors{}, //nolint:exhaustruct
-		},
-		Response: cloudflare.Response{
-			Success:  true,
-			Errors:   []cloudflare.ResponseInfo{},
-			Messages: []cloudflare.ResponseInfo{},
-		},
-	}
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
+	require.Equal(t, 1, accessCount)
 }
 
-func handleZones(t *testing.T, zoneName string, zoneStatuses []string, w http.ResponseWriter, r *http.Request) {
-	t.Helper()
-
-	require.Equal(t, http.MethodGet, r.Method)
-	require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
-	require.Equal(t, url.Values{
-		"account.id": {mockAccount},
-		"name":       {zoneName},
-		"per_page":   {"50"},
-	}, r.URL.Query())
+func TestNewMockAuthFailure(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
 
-	w.Header().Set("content-type", "application/json")
-	err := json.NewEncoder(w).Encode(mockZonesResponse(zoneName, zoneStatuses))
-	require.NoError(t, err)
-}
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockAuth := mocks.NewMockAuth(mockCtrl)
+	mockAuth.EXPECT().New(context.Background(), mockPP, time.Second, time.Second).Return(nil, false)
 
-type zonesHandler struct {
-	mux          *http.ServeMux
-	zoneStatuses *map[string][]string
-	accessCount  *int
+	var auth api.Auth = mockAuth
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.False(t, ok)
+	require.Nil(t, h)
 }
 
-func newZonesHandler(t *testing.T, mux *http.ServeMux) *zonesHandler {
+func newClientCert(t *testing.T) (tls.Certificate, *x509.Certificate) {
 	t.Helper()
 
-	var (
-		zoneStatuses map[string][]string
-		accessCount  int
-	)
-
-	mux.HandleFunc("/zones", func(w http.ResponseWriter, r *http.Request) {
-		if accessCount <= 0 {
-			return
-		}
-		accessCount--
-
-		zoneName := r.URL.Query().Get("name")
-		handleZones(t, zoneName, zoneStatuses[zoneName], w, r)
-	})
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
 
-	return &zonesHandler{
-		mux:          mux,
-		zoneStatuses: &zoneStatuses,
-		accessCount:  &accessCount,
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cloudflare-ddns test client"}, //nolint:exhaustruct
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 	}
-}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
 
-func (h *zonesHandler) set(zoneStatuses map[string][]string, accessCount int) {
-	*(h.zoneStatuses), *(h.accessCount) = zoneStatuses, accessCount
-}
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
 
-func (h *zonesHandler) isExhausted() bool {
-	return *h.accessCount == 0
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, cert //nolint:exhaustruct
 }
 
-func TestActiveZonesRoot(t *testing.T) {
+func TestNewMutualTLS(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
-	_, h := newHandle(t)
+	clientCert, clientX509 := newClientCert(t)
+	clientPool := x509.NewCertPool()
+	clientPool.AddCert(clientX509)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		require.Len(t, r.TLS.PeerCertificates, 1)
+		require.Equal(t, clientX509.Raw, r.TLS.PeerCertificates[0].Raw)
+		handleTokensVerify(t, w, r)
+	})
+
+	ts := httptest.NewUnstartedServer(mux)
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	ts.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientPool,
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	serverPool := x509.NewCertPool()
+	serverPool.AddCert(ts.Certificate())
+
+	auth := api.CloudflareAuth{
+		Token:                mockToken,
+		AccountID:            mockAccount,
+		BaseURL:              ts.URL,
+		TokenExpiryWarning:   api.DefaultTokenExpiryWarning,
+		RejectCloudflareIPs:  false,
+		ClientCert:           clientCert,
+		ClientCA:             serverPool,
+		ProxyURL:             "",
+		Clock:                clock.NewMock(time.Now()),
+		TransportConfig:      api.TransportConfig{},
+		UseBatchAPI:          false,
+		Retry:                api.RetryConfig{MaxRetries: 0, MinDelay: 0, MaxDelay: 0, SafeRetry: false},
+		TokenVerifyPath:      "",
+		StartupTimeout:       0,
+		StartupRetryInterval: 0,
+		Resolver:             nil,
+		PropagationInterval:  0,
+		AccessClientID:       "",
+		AccessClientSecret:   "",
+		RateLimitWarning:     api.DefaultRateLimitWarning,
+	}
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "")
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
 	require.True(t, ok)
-	require.Empty(t, zones)
+	require.NotNil(t, h)
 }
 
-func TestActiveZonesTwo(t *testing.T) {
+func TestNewMutualTLSNoPrivateKey(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
-	mux, h := newHandle(t)
+	_, auth := newServerAuth(t)
 
-	zh := newZonesHandler(t, mux)
+	clientCert, _ := newClientCert(t)
+	clientCert.PrivateKey = nil
+	auth.ClientCert = clientCert
 
-	zh.set(map[string][]string{"test.org": {"active", "active"}}, 1)
 	mockPP := mocks.NewMockPP(mockCtrl)
-	zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
-	require.True(t, ok)
-	require.Equal(t, mockIDs("test.org", 0, 1), zones)
-	require.True(t, zh.isExhausted())
+	mockPP.EXPECT().Errorf(pp.EmojiUserError, "The client certificate for mutual TLS has no private key")
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.False(t, ok)
+	require.Nil(t, h)
+}
 
-	zh.set(nil, 0)
-	mockPP = mocks.NewMockPP(mockCtrl)
-	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
-	require.True(t, ok)
-	require.Equal(t, mockIDs("test.org", 0, 1), zones)
-	require.True(t, zh.isExhausted())
+func handleTokensVerifyExpiring(t *testing.T, w http.ResponseWriter, r *http.Request, expiresOn time.Time) {
+	t.Helper()
 
-	h.FlushCache()
+	require.Equal(t, http.MethodGet, r.Method)
+	require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+	require.Empty(t, r.URL.Query())
 
-	mockPP = mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(
-		pp.EmojiError,
-		"Failed to check the existence of a zone named %q: %v",
-		"test.org",
-		gomock.Any(),
-	)
-	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
-	require.False(t, ok)
-	require.Nil(t, zones)
-	require.True(t, zh.isExhausted())
+	w.Header().Set("content-type", "application/json")
+	fmt.Fprintf(w,
+		`{
+				"result": { "id": "%s", "status": "active", "expires_on": "%s" },
+				"success": true,
+				"errors": [],
+				"messages": [
+					{
+						"code": 10000,
+						"message": "This API Token is valid and active",
+						"type": null
+					}
+				]
+			}`,
+		mockID("result", 0), expiresOn.Format(time.RFC3339))
 }
 
-func TestActiveZonesEmpty(t *testing.T) {
+func TestNewExpiring(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
-	mux, h := newHandle(t)
+	for name, tc := range map[string]struct {
+		expiresIn     time.Duration
+		prepareMockPP func(*mocks.MockPP, string)
+	}{
+		"soon": {
+			time.Hour * 24,
+			func(m *mocks.MockPP, expiresOn string) {
+				m.EXPECT().Warningf(pp.EmojiUserWarning,
+					"The Cloudflare API token will expire at %s; please renew it soon", expiresOn)
+			},
+		},
+		"later": {time.Hour * 24 * 30, nil},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
 
-	zh := newZonesHandler(t, mux)
+			mux, auth := newServerAuth(t)
 
-	zh.set(map[string][]string{}, 1)
-	mockPP := mocks.NewMockPP(mockCtrl)
-	zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
-	require.True(t, ok)
-	require.Empty(t, zones)
-	require.True(t, zh.isExhausted())
+			// The expiry is judged by the clock of the handle, not the real time.
+			now := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+			auth.Clock = clock.NewMock(now)
+			expiresOn := now.Add(tc.expiresIn)
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				handleTokensVerifyExpiring(t, w, r, expiresOn)
+			})
 
-	zh.set(nil, 0) // this should not affect the result due to the caching
-	mockPP = mocks.NewMockPP(mockCtrl)
-	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
-	require.True(t, ok)
-	require.Empty(t, zones)
-	require.True(t, zh.isExhausted())
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP, expiresOn.Format(time.RFC3339))
+			}
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.True(t, ok)
+			require.NotNil(t, h)
+		})
+	}
+}
 
-	h.FlushCache()
+func handleAccount(t *testing.T, mux *http.ServeMux, accountID string, name string) {
+	t.Helper()
 
-	mockPP = mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(
-		pp.EmojiError,
-		"Failed to check the existence of a zone named %q: %v",
-		"test.org",
-		gomock.Any(),
-	)
-	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
-	require.False(t, ok)
-	require.Nil(t, zones)
-	require.True(t, zh.isExhausted())
+	mux.HandleFunc(fmt.Sprintf("/accounts/%s", accountID), func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"success":  true,
+			"errors":   []any{},
+			"messages": []any{},
+			"result":   map[string]any{"id": accountID, "name": name},
+		})
+		require.NoError(t, err)
+	})
 }
 
-//nolint:funlen
-func TestZoneOfDomain(t *testing.T) {
+func handleTokenDetails(t *testing.T, mux *http.ServeMux, tokenID string, name string) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/user/tokens/%s", tokenID), func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"success":  true,
+			"errors":   []any{},
+			"messages": []any{},
+			"result":   map[string]any{"id": tokenID, "name": name, "status": "active"},
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestDescribe(t *testing.T) {
 	t.Parallel()
 
 	for name, tc := range map[string]struct {
-		zone          string
-		domain        domain.Domain
-		zoneStatuses  map[string][]string
-		accessCount   int
-		expected      string
-		ok            bool
-		prepareMockPP func(*mocks.MockPP)
+		accountID       string
+		hasTokenDetails bool
+		expected        api.AccountInfo
 	}{
-		"root":     {"test.org", domain.FQDN("test.org"), map[string][]string{"test.org": {"active"}}, 1, mockID("test.org", 0), true, nil},     //nolint:lll
-		"wildcard": {"test.org", domain.Wildcard("test.org"), map[string][]string{"test.org": {"active"}}, 1, mockID("test.org", 0), true, nil}, //nolint:lll
-		"one":      {"test.org", domain.FQDN("sub.test.org"), map[string][]string{"test.org": {"active"}}, 2, mockID("test.org", 0), true, nil}, //nolint:lll
-		"none": {
-			"test.org", domain.FQDN("sub.test.org"),
-			map[string][]string{},
-			3, "", false,
-			func(m *mocks.MockPP) {
-				m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "sub.test.org")
-			},
-		},
-		"none/wildcard": {
-			"test.org", domain.Wildcard("test.org"),
-			map[string][]string{},
-			2, "", false,
-			func(m *mocks.MockPP) {
-				m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "*.test.org")
-			},
-		},
-		"multiple": {
-			"test.org", domain.FQDN("sub.test.org"),
-			map[string][]string{"test.org": {"active", "active"}},
-			2, "", false,
-			func(m *mocks.MockPP) {
-				m.EXPECT().Warningf(
-					pp.EmojiImpossible,
-					"Found multiple active zones named %q. Specifying CF_ACCOUNT_ID might help",
-					"test.org",
-				)
-			},
-		},
-		"multiple/wildcard": {
-			"test.org", domain.Wildcard("test.org"),
-			map[string][]string{"test.org": {"active", "active"}},
-			1, "", false,
-			func(m *mocks.MockPP) {
-				m.EXPECT().Warningf(
-					pp.EmojiImpossible,
-					"Found multiple active zones named %q. Specifying CF_ACCOUNT_ID might help",
-					"test.org",
-				)
-			},
-		},
-		"deleted": {
-			"test.org", domain.FQDN("test.org"),
-			map[string][]string{"test.org": {"deleted"}},
-			2, "", false,
-			func(m *mocks.MockPP) {
-				gomock.InOrder(
-					m.EXPECT().Infof(pp.EmojiWarning, "Zone %q is %q and thus skipped", "test.org", "deleted"),
-					m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "test.org"),
-				)
-			},
-		},
-		"pending": {
-			"test.org", domain.FQDN("test.org"),
-			map[string][]string{"test.org": {"pending"}},
-			1, mockID("test.org", 0), true,
-			func(m *mocks.MockPP) {
-				gomock.InOrder(
-					m.EXPECT().Warningf(pp.EmojiWarning, "Zone %q is %q; your Cloudflare setup is incomplete", "test.org", "pending"), //nolint:lll
-					m.EXPECT().Warningf(pp.EmojiWarning, "Some features might stop working", "test.org", "pending"),
-				)
+		"account": {
+			mockAccount, true,
+			api.AccountInfo{
+				AccountID:   mockAccount,
+				AccountName: "My Account",
+				TokenID:     mockID("result", 0),
+				TokenName:   "DDNS token",
 			},
 		},
-		"initializing": {
-			"test.org", domain.FQDN("test.org"),
-			map[string][]string{"test.org": {"initializing"}},
-			1, mockID("test.org", 0), true,
-			func(m *mocks.MockPP) {
-				gomock.InOrder(
-					m.EXPECT().Warningf(pp.EmojiWarning, "Zone %q is %q; your Cloudflare setup is incomplete", "test.org", "initializing"), //nolint:lll
-					m.EXPECT().Warningf(pp.EmojiWarning, "Some features might stop working", "test.org", "initializing"),
-				)
+		"no-account-id": {
+			"", true,
+			api.AccountInfo{
+				AccountID:   "",
+				AccountName: "",
+				TokenID:     mockID("result", 0),
+				TokenName:   "DDNS token",
 			},
 		},
-		"undocumented": {
-			"test.org", domain.FQDN("test.org"),
-			map[string][]string{"test.org": {"some-undocumented-status"}},
-			1, mockID("test.org", 0), true,
-			func(m *mocks.MockPP) {
-				gomock.InOrder(
-					m.EXPECT().Warningf(pp.EmojiImpossible, "Zone %q is in an undocumented status %q", "test.org", "some-undocumented-status"), //nolint:lll
-					m.EXPECT().Warningf(pp.EmojiImpossible, "Please report the bug at https://github.com/favonia/cloudflare-ddns/issues/new"),  //nolint:lll
-				)
+		"no-token-details": {
+			mockAccount, false,
+			api.AccountInfo{
+				AccountID:   mockAccount,
+				AccountName: "My Account",
+				TokenID:     mockID("result", 0),
+				TokenName:   "",
 			},
 		},
 	} {
@@ -444,104 +544,1657 @@ func TestZoneOfDomain(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 			mockCtrl := gomock.NewController(t)
-			mux, h := newHandle(t)
 
-			zh := newZonesHandler(t, mux)
+			mux, auth := newServerAuth(t)
+			auth.AccountID = tc.accountID
+
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				handleTokensVerify(t, w, r)
+			})
+			if tc.hasTokenDetails {
+				handleTokenDetails(t, mux, mockID("result", 0), "DDNS token")
+			}
+			// The account should not be retrieved when the account ID is empty.
+			handleAccount(t, mux, mockAccount, "My Account")
 
-			zh.set(tc.zoneStatuses, tc.accessCount)
 			mockPP := mocks.NewMockPP(mockCtrl)
-			if tc.prepareMockPP != nil {
-				tc.prepareMockPP(mockPP)
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.True(t, ok)
+
+			info, ok := h.Describe(context.Background(), mockPP)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestDescribeInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+
+	valid := true
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handleTokensVerify(t, w, r)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+
+	// The account does not exist.
+	mockPP = mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve the account %q: %v", mockAccount, gomock.Any())
+	info, ok := h.Describe(context.Background(), mockPP)
+	require.False(t, ok)
+	require.Equal(t, api.AccountInfo{}, info) //nolint:exhaustruct
+
+	// The token is no longer valid.
+	valid = false
+	mockPP = mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "The Cloudflare API token could not be verified: %v", gomock.Any())
+	info, ok = h.Describe(context.Background(), mockPP)
+	require.False(t, ok)
+	require.Equal(t, api.AccountInfo{}, info) //nolint:exhaustruct
+}
+
+func handleMemberships(t *testing.T, mux *http.ServeMux, accountIDs []string) {
+	t.Helper()
+
+	mux.HandleFunc("/memberships", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+		require.Equal(t, "accepted", r.URL.Query().Get("status"))
+
+		memberships := make([]any, 0, len(accountIDs))
+		for i, accountID := range accountIDs {
+			memberships = append(memberships, map[string]any{
+				"id":      mockID("membership", i),
+				"status":  "accepted",
+				"account": map[string]any{"id": accountID, "name": "My Account"},
+			})
+		}
+
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"success":  true,
+			"errors":   []any{},
+			"messages": []any{},
+			"result":   memberships,
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestNewDiscoverAccountID(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		hasMemberships bool
+		accountIDs     []string
+		expected       string
+	}{
+		"one":       {true, []string{mockAccount}, mockAccount},
+		"zero":      {true, []string{}, ""},
+		"two":       {true, []string{mockAccount, "account789"}, ""},
+		"forbidden": {false, nil, ""},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, auth := newServerAuth(t)
+			auth.AccountID = ""
+
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				handleTokensVerify(t, w, r)
+			})
+			if tc.hasMemberships {
+				handleMemberships(t, mux, tc.accountIDs)
+			} else {
+				mux.HandleFunc("/memberships", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusForbidden)
+				})
 			}
-			zoneID, ok := h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, tc.domain)
-			require.Equal(t, tc.ok, ok)
-			require.Equal(t, tc.expected, zoneID)
-			require.True(t, zh.isExhausted())
+			handleTokenDetails(t, mux, mockID("result", 0), "DDNS token")
+			handleAccount(t, mux, mockAccount, "My Account")
 
-			if tc.ok {
-				zh.set(nil, 0)
-				mockPP = mocks.NewMockPP(mockCtrl) // there shouldn't be any messages
-				zoneID, ok = h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, tc.domain)
-				require.Equal(t, tc.ok, ok)
-				require.Equal(t, tc.expected, zoneID)
-				require.True(t, zh.isExhausted())
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.expected != "" {
+				mockPP.EXPECT().Infof(pp.EmojiConfig,
+					"Using the account %q, the only account accessible with the API token", tc.expected)
+			}
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.True(t, ok)
+
+			info, ok := h.Describe(context.Background(), mockPP)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, info.AccountID)
+		})
+	}
+}
+
+func TestNewTimeout(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second * 5):
+		}
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Errorf(pp.EmojiError, "Failed to verify the Cloudflare API token within %v", time.Millisecond*100)
+	start := time.Now()
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Millisecond*100)
+	require.False(t, ok)
+	require.Nil(t, h)
+	require.Less(t, time.Since(start), time.Second*5)
+}
+
+func TestNewSharedTimeout(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+	auth.AccountID = ""
+
+	// Each call alone fits in the timeout, but both together do not.
+	delay := func(r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Millisecond * 600):
+		}
+	}
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		delay(r)
+		handleTokensVerify(t, w, r)
+	})
+	mux.HandleFunc("/memberships", func(w http.ResponseWriter, r *http.Request) {
+		delay(r)
+		if r.Context().Err() != nil {
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"success":  true,
+			"errors":   []any{},
+			"messages": []any{},
+			"result":   []any{map[string]any{"account": map[string]any{"id": mockAccount}}},
+		})
+		require.NoError(t, err)
+	})
+
+	// The account would be discovered if the discovery had its own timeout.
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
+}
+
+func TestNewStartupRetry(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		startupTimeout time.Duration
+		failures       int64
+		ok             bool
+		accessCount    int64
+	}{
+		"disabled":  {0, 1, false, 1},
+		"recovered": {time.Minute, 2, true, 3},
+		"timeout":   {time.Second * 25, 5, false, 3},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, auth := newServerAuth(t)
+			auth.StartupTimeout = tc.startupTimeout
+			auth.StartupRetryInterval = time.Second * 10
+			// The built-in retries of cloudflare-go and the HTTP client are disabled
+			// so that only the startup attempts are counted.
+			auth.Retry.MaxRetries = 1
+			auth.TransportConfig.MaxIdleConnsPerHost = -1
+
+			// The server is not ready for the first few attempts.
+			var accessCount atomic.Int64
+			mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+				if accessCount.Add(1) <= tc.failures {
+					dropConnection(t, w)
+					return
+				}
+				handleTokensVerify(t, w, r)
+			})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Infof(pp.EmojiRepeatOnce, "The Cloudflare API is not reachable yet; retrying in %v . . .",
+				time.Second*10).Times(int(tc.accessCount - 1))
+			if !tc.ok {
+				gomock.InOrder(
+					mockPP.EXPECT().Errorf(pp.EmojiUserError, "The Cloudflare API token could not be verified: %v", gomock.Any()),
+					mockPP.EXPECT().Errorf(pp.EmojiUserError, "Please double-check CF_API_TOKEN or CF_API_TOKEN_FILE"),
+				)
 			}
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.ok, h != nil)
+			require.Equal(t, tc.accessCount, accessCount.Load())
 		})
 	}
 }
 
-func TestZoneOfDomainInvalid(t *testing.T) {
+func mockZone(name string, i int, status string) *cloudflare.Zone {
+	return &cloudflare.Zone{ //nolint:exhaustruct
+		ID:     mockID(name, i),
+		Name:   name,
+		Status: status,
+	}
+}
+
+func mockZonesResponse(zoneName string, zoneStatuses []string) *cloudflare.ZonesResponse {
+	numZones := len(zoneStatuses)
+
+	if numZones > 50 {
+		panic("mockZonesResponse got too many zone names")
+	}
+
+	zones := make([]cloudflare.Zone, len(zoneStatuses))
+	for i, status := range zoneStatuses {
+		zones[i] = *mockZone(zoneName, i, status)
+	}
+
+	return &cloudflare.ZonesResponse{
+		Result: zones,
+		ResultInfo: cloudflare.ResultInfo{
+			Page:       1,
+			PerPage:    50,
+			TotalPages: (numZones + 49) / 50,
+			Count:      numZones,
+			Total:      numZones,
+			Cursor:     "",
+			Cursors:    cloudflare.ResultInfoCursors{}, //nolint:exhaustruct
+		},
+		Response: cloudflare.Response{
+			Success:  true,
+			Errors:   []cloudflare.ResponseInfo{},
+			Messages: []cloudflare.ResponseInfo{},
+		},
+	}
+}
+
+// handleZones checks the query and responds with the zones. An empty accountID means
+// the query should not filter zones by accounts.
+func handleZones(t *testing.T, accountID string, zoneName string, zoneStatuses []string,
+	w http.ResponseWriter, r *http.Request,
+) {
+	t.Helper()
+
+	require.Equal(t, http.MethodGet, r.Method)
+	require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+	expectedQuery := url.Values{
+		"name":     {zoneName},
+		"per_page": {"50"},
+	}
+	if accountID != "" {
+		expectedQuery.Set("account.id", accountID)
+	}
+	require.Equal(t, expectedQuery, r.URL.Query())
+
+	w.Header().Set("content-type", "application/json")
+	err := json.NewEncoder(w).Encode(mockZonesResponse(zoneName, zoneStatuses))
+	require.NoError(t, err)
+}
+
+// zonesHandler serves the zones. When the account ID is not empty, the queries without the account ID
+// are served with the zones outside the account, which are empty unless setOutside is called.
+type zonesHandler struct {
+	mux             *http.ServeMux
+	mutex           *sync.Mutex
+	zoneStatuses    *map[string][]string
+	outsideStatuses *map[string][]string
+	accessCount     *int
+}
+
+func newZonesHandler(t *testing.T, mux *http.ServeMux) *zonesHandler {
+	t.Helper()
+
+	return newZonesHandlerWithAccount(t, mux, mockAccount)
+}
+
+func newZonesHandlerWithAccount(t *testing.T, mux *http.ServeMux, accountID string) *zonesHandler {
+	t.Helper()
+
+	var (
+		mutex           sync.Mutex
+		zoneStatuses    map[string][]string
+		outsideStatuses map[string][]string
+		accessCount     int
+	)
+
+	mux.HandleFunc("/zones", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if accessCount <= 0 {
+			return
+		}
+		accessCount--
+
+		zoneName := r.URL.Query().Get("name")
+		if accountID != "" && !r.URL.Query().Has("account.id") {
+			handleZones(t, "", zoneName, outsideStatuses[zoneName], w, r)
+			return
+		}
+		handleZones(t, accountID, zoneName, zoneStatuses[zoneName], w, r)
+	})
+
+	return &zonesHandler{
+		mux:             mux,
+		mutex:           &mutex,
+		zoneStatuses:    &zoneStatuses,
+		outsideStatuses: &outsideStatuses,
+		accessCount:     &accessCount,
+	}
+}
+
+func (h *zonesHandler) set(zoneStatuses map[string][]string, accessCount int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	*(h.zoneStatuses), *(h.accessCount) = zoneStatuses, accessCount
+}
+
+func (h *zonesHandler) setOutside(outsideStatuses map[string][]string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	*(h.outsideStatuses) = outsideStatuses
+}
+
+func (h *zonesHandler) isExhausted() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return *h.accessCount == 0
+}
+
+func TestActiveZonesRoot(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "")
+	require.True(t, ok)
+	require.Empty(t, zones)
+}
+
+func TestActiveZonesTwo(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+
+	zh.set(map[string][]string{"test.org": {"active", "active"}}, 1)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+	require.True(t, ok)
+	require.Equal(t, mockIDs("test.org", 0, 1), zones)
+	require.True(t, zh.isExhausted())
+
+	zh.set(nil, 0)
+	mockPP = mocks.NewMockPP(mockCtrl)
+	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+	require.True(t, ok)
+	require.Equal(t, mockIDs("test.org", 0, 1), zones)
+	require.True(t, zh.isExhausted())
+
+	require.Positive(t, h.FlushCache())
+
+	mockPP = mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiError,
+		"Failed to check the existence of a zone named %q: %v",
+		"test.org",
+		gomock.Any(),
+	)
+	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+	require.False(t, ok)
+	require.Nil(t, zones)
+	require.True(t, zh.isExhausted())
+}
+
+func TestActiveZonesEmpty(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+
+	zh.set(map[string][]string{}, 1)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+	require.True(t, ok)
+	require.Empty(t, zones)
+	require.True(t, zh.isExhausted())
+
+	zh.set(nil, 0) // this should not affect the result due to the caching
+	mockPP = mocks.NewMockPP(mockCtrl)
+	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+	require.True(t, ok)
+	require.Empty(t, zones)
+	require.True(t, zh.isExhausted())
+
+	require.Positive(t, h.FlushCache())
+
+	mockPP = mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiError,
+		"Failed to check the existence of a zone named %q: %v",
+		"test.org",
+		gomock.Any(),
+	)
+	zones, ok = h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+	require.False(t, ok)
+	require.Nil(t, zones)
+	require.True(t, zh.isExhausted())
+}
+
+//nolint:funlen
+func TestActiveZonesZoneScopedToken(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		forbidden     bool
+		expected      []string
+		accessCount   int
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"account": {false, mockIDs("test.org", 0), 1, nil},
+		"fallback": {
+			true, mockIDs("test.org", 0), 2,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiWarning,
+						"Failed to look up zones named %q within the account specified by CF_ACCOUNT_ID; the API token might be zone-scoped", //nolint:lll
+						"test.org"),
+					m.EXPECT().Warningf(pp.EmojiWarning, "Retrying without CF_ACCOUNT_ID . . ."),
+				)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			accessCount := tc.accessCount
+			mux.HandleFunc("/zones", func(w http.ResponseWriter, r *http.Request) {
+				if accessCount <= 0 {
+					return
+				}
+				accessCount--
+
+				require.Equal(t, http.MethodGet, r.Method)
+				require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+				if r.URL.Query().Has("account.id") {
+					if tc.forbidden {
+						w.Header().Set("content-type", "application/json")
+						w.WriteHeader(http.StatusForbidden)
+						fmt.Fprintf(w,
+							`{
+								"success": false,
+								"errors": [{ "code": 9109, "message": "Unauthorized to access requested resource" }],
+								"messages": [],
+								"result": null
+							}`)
+						return
+					}
+					handleZones(t, mockAccount, "test.org", []string{"active"}, w, r)
+					return
+				}
+
+				require.Equal(t, url.Values{
+					"name":     {"test.org"},
+					"per_page": {"50"},
+				}, r.URL.Query())
+
+				w.Header().Set("content-type", "application/json")
+				err := json.NewEncoder(w).Encode(mockZonesResponse("test.org", []string{"active"}))
+				require.NoError(t, err)
+			})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+			require.True(t, ok)
+			require.Equal(t, tc.expected, zones)
+			require.Equal(t, 0, accessCount)
+		})
+	}
+}
+
+func TestActiveZonesNoAccountID(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+	auth.AccountID = ""
+
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+
+	zh := newZonesHandlerWithAccount(t, mux, "")
+	zh.set(map[string][]string{"test.org": {"active"}}, 1)
+
+	zones, ok := h.(*api.CloudflareHandle).ActiveZones(context.Background(), mockPP, "test.org")
+	require.True(t, ok)
+	require.Equal(t, mockIDs("test.org", 0), zones)
+	require.True(t, zh.isExhausted())
+}
+
+//nolint:funlen
+func TestZoneOfDomain(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		zone          string
+		domain        domain.Domain
+		zoneStatuses  map[string][]string
+		accessCount   int
+		expected      string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"root":     {"test.org", domain.FQDN("test.org"), map[string][]string{"test.org": {"active"}}, 1, mockID("test.org", 0), true, nil},     //nolint:lll
+		"wildcard": {"test.org", domain.Wildcard("test.org"), map[string][]string{"test.org": {"active"}}, 1, mockID("test.org", 0), true, nil}, //nolint:lll
+		"one":      {"test.org", domain.FQDN("sub.test.org"), map[string][]string{"test.org": {"active"}}, 2, mockID("test.org", 0), true, nil}, //nolint:lll
+		"ccsld": {
+			"example.co.uk", domain.FQDN("sub.example.co.uk"),
+			map[string][]string{"example.co.uk": {"active"}},
+			2, mockID("example.co.uk", 0), true, nil,
+		},
+		"private-suffix": {
+			"github.io", domain.FQDN("user.github.io"),
+			map[string][]string{"github.io": {"active"}},
+			2, mockID("github.io", 0), true, nil,
+		},
+		"public-suffix": {
+			"co.uk", domain.FQDN("example.co.uk"),
+			map[string][]string{"co.uk": {"active"}},
+			2, "", false,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "example.co.uk"),
+					m.EXPECT().Warningf(pp.EmojiUserError,
+						"No zone accessible with the API token contains %q; is the domain on Cloudflare?", "example.co.uk"),
+				)
+			},
+		},
+		"none": {
+			"test.org", domain.FQDN("sub.test.org"),
+			map[string][]string{},
+			4, "", false,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "sub.test.org"),
+					m.EXPECT().Warningf(pp.EmojiUserError,
+						"No zone accessible with the API token contains %q; is the domain on Cloudflare?", "sub.test.org"),
+				)
+			},
+		},
+		"none/wildcard": {
+			"test.org", domain.Wildcard("test.org"),
+			map[string][]string{},
+			2, "", false,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "*.test.org"),
+					m.EXPECT().Warningf(pp.EmojiUserError,
+						"No zone accessible with the API token contains %q; is the domain on Cloudflare?", "*.test.org"),
+				)
+			},
+		},
+		"multiple": {
+			"test.org", domain.FQDN("sub.test.org"),
+			map[string][]string{"test.org": {"active", "active"}},
+			2, "", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(
+					pp.EmojiImpossible,
+					"Found multiple active zones named %q. Specifying CF_ACCOUNT_ID might help",
+					"test.org",
+				)
+			},
+		},
+		"multiple/wildcard": {
+			"test.org", domain.Wildcard("test.org"),
+			map[string][]string{"test.org": {"active", "active"}},
+			1, "", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(
+					pp.EmojiImpossible,
+					"Found multiple active zones named %q. Specifying CF_ACCOUNT_ID might help",
+					"test.org",
+				)
+			},
+		},
+		"deleted": {
+			"test.org", domain.FQDN("test.org"),
+			map[string][]string{"test.org": {"deleted"}},
+			2, "", false,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiWarning, "Zone %q is %q and thus skipped", "test.org", "deleted"),
+					m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "test.org"),
+					m.EXPECT().Warningf(pp.EmojiUserError,
+						"No zone accessible with the API token contains %q; is the domain on Cloudflare?", "test.org"),
+				)
+			},
+		},
+		"pending": {
+			"test.org", domain.FQDN("test.org"),
+			map[string][]string{"test.org": {"pending"}},
+			1, mockID("test.org", 0), true,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiWarning, "Zone %q is %q; your Cloudflare setup is incomplete", "test.org", "pending"), //nolint:lll
+					m.EXPECT().Warningf(pp.EmojiWarning, "Some features might stop working", "test.org", "pending"),
+				)
+			},
+		},
+		"pending/malformed": {
+			"xn--zzzzzzzzzzz.org", domain.FQDN("xn--zzzzzzzzzzz.org"),
+			map[string][]string{"xn--zzzzzzzzzzz.org": {"pending"}},
+			1, mockID("xn--zzzzzzzzzzz.org", 0), true,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiWarning, "Failed to decode the zone name %q into Unicode: %v",
+						"xn--zzzzzzzzzzz.org", gomock.Any()),
+					m.EXPECT().Warningf(pp.EmojiWarning, "Zone %q is %q; your Cloudflare setup is incomplete", "xn--zzzzzzzzzzz.org", "pending"), //nolint:lll
+					m.EXPECT().Warningf(pp.EmojiWarning, "Some features might stop working", "xn--zzzzzzzzzzz.org", "pending"),
+				)
+			},
+		},
+		"initializing": {
+			"test.org", domain.FQDN("test.org"),
+			map[string][]string{"test.org": {"initializing"}},
+			1, mockID("test.org", 0), true,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiWarning, "Zone %q is %q; your Cloudflare setup is incomplete", "test.org", "initializing"), //nolint:lll
+					m.EXPECT().Warningf(pp.EmojiWarning, "Some features might stop working", "test.org", "initializing"),
+				)
+			},
+		},
+		"undocumented": {
+			"test.org", domain.FQDN("test.org"),
+			map[string][]string{"test.org": {"some-undocumented-status"}},
+			1, mockID("test.org", 0), true,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiImpossible, "Zone %q is in an undocumented status %q", "test.org", "some-undocumented-status"), //nolint:lll
+					m.EXPECT().Warningf(pp.EmojiImpossible, "Please report the bug at https://github.com/favonia/cloudflare-ddns/issues/new"),  //nolint:lll
+				)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			mux, h := newHandle(t)
+
+			zh := newZonesHandler(t, mux)
+
+			zh.set(tc.zoneStatuses, tc.accessCount)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			zoneID, ok := h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, tc.domain)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, zoneID)
+			require.True(t, zh.isExhausted())
+
+			if tc.ok {
+				zh.set(nil, 0)
+				mockPP = mocks.NewMockPP(mockCtrl) // there shouldn't be any messages
+				zoneID, ok = h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, tc.domain)
+				require.Equal(t, tc.ok, ok)
+				require.Equal(t, tc.expected, zoneID)
+				require.True(t, zh.isExhausted())
+			}
+		})
+	}
+}
+
+func TestZoneOfDomainOutsideAccount(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		domain          domain.Domain
+		zoneStatuses    map[string][]string
+		outsideStatuses map[string][]string
+		accessCount     int
+		expected        string
+		ok              bool
+		prepareMockPP   func(*mocks.MockPP)
+	}{
+		"wildcard/delegated": {
+			domain.Wildcard("sub.test.org"),
+			map[string][]string{"sub.test.org": {"active"}, "test.org": {"active"}}, nil,
+			1, mockID("sub.test.org", 0), true, nil,
+		},
+		"wildcard/parent": {
+			domain.Wildcard("sub.test.org"),
+			map[string][]string{"test.org": {"active"}}, nil,
+			2, mockID("test.org", 0), true, nil,
+		},
+		"wildcard/outside": {
+			domain.Wildcard("sub.test.org"),
+			map[string][]string{}, map[string][]string{"test.org": {"active"}},
+			4, "", false,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "*.sub.test.org"),
+					m.EXPECT().Warningf(pp.EmojiUserError,
+						"Zone %q exists but is not in the account specified by CF_ACCOUNT_ID", "test.org"),
+				)
+			},
+		},
+		"outside/deleted": {
+			domain.FQDN("test.org"),
+			map[string][]string{}, map[string][]string{"test.org": {"deleted"}},
+			2, "", false,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "test.org"),
+					m.EXPECT().Warningf(pp.EmojiUserError,
+						"No zone accessible with the API token contains %q; is the domain on Cloudflare?", "test.org"),
+				)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			mux, h := newHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(tc.zoneStatuses, tc.accessCount)
+			zh.setOutside(tc.outsideStatuses)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			zoneID, ok := h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, tc.domain)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, zoneID)
+			require.True(t, zh.isExhausted())
+		})
+	}
+}
+
+func TestZoneOfDomainInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiError,
+		"Failed to check the existence of a zone named %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	zoneID, ok := h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	require.False(t, ok)
+	require.Equal(t, "", zoneID)
+}
+
+func TestZoneID(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	// uncached: the zone is looked up via the API
+	mockPP := mocks.NewMockPP(mockCtrl)
+	zoneID, ok := h.(*api.CloudflareHandle).ZoneID(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	require.True(t, ok)
+	require.Equal(t, mockID("test.org", 0), zoneID)
+	require.True(t, zh.isExhausted())
+
+	// cached: no more API calls
+	zoneID, ok = h.(*api.CloudflareHandle).ZoneID(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	require.True(t, ok)
+	require.Equal(t, mockID("test.org", 0), zoneID)
+
+	// the cache is shared with ZoneOfDomain
+	zoneID, ok = h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	require.True(t, ok)
+	require.Equal(t, mockID("test.org", 0), zoneID)
+}
+
+func TestCloneForZone(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	mux.HandleFunc("/zones", func(w http.ResponseWriter, r *http.Request) {
+		require.Fail(t, "unexpected zone lookup", r.URL.String())
+	})
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			switch r.Method {
+			case http.MethodGet:
+				err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP4, "sub.test.org",
+					map[string]string{"record1": "1.1.1.1"}))
+				require.NoError(t, err)
+			case http.MethodPost:
+				err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record2", ipnet.IP4, "sub.test.org", "2.2.2.2"))
+				require.NoError(t, err)
+			default:
+				require.Fail(t, "unexpected method", r.Method)
+			}
+		})
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodDelete, r.Method)
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP4, "sub.test.org", "1.1.1.1"))
+			require.NoError(t, err)
+		})
+
+	ctx := context.Background()
+	d := domain.FQDN("sub.test.org")
+	mockPP := mocks.NewMockPP(mockCtrl)
+	clone := h.(*api.CloudflareHandle).CloneForZone(mockID("test.org", 0))
+
+	rmap, ok := clone.ListRecords(ctx, mockPP, d, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, map[string]netip.Addr{"record1": mustIP("1.1.1.1")}, rmap)
+	id, ok := clone.CreateRecord(ctx, mockPP, d, ipnet.IP4, mustIP("2.2.2.2"), api.TTLAuto, false, "")
+	require.True(t, ok)
+	require.Equal(t, "record2", id)
+	require.True(t, clone.DeleteRecord(ctx, mockPP, d, ipnet.IP4, "record1"))
+}
+
+func TestCloneForZoneSeparateCache(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("other.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP4, "sub.test.org", map[string]string{}))
+			require.NoError(t, err)
+		})
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP4, "sub.test.org",
+				map[string]string{"record1": "1.1.1.1"}))
+			require.NoError(t, err)
+		})
+
+	ctx := context.Background()
+	d := domain.FQDN("sub.test.org")
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	// A clone pointed at the wrong zone must not poison the cache of the original handle.
+	clone := h.(*api.CloudflareHandle).CloneForZone(mockID("other.org", 0))
+	rmap, ok := clone.ListRecords(ctx, mockPP, d, ipnet.IP4)
+	require.True(t, ok)
+	require.Empty(t, rmap)
+
+	rmap, ok = h.ListRecords(ctx, mockPP, d, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, map[string]netip.Addr{"record1": mustIP("1.1.1.1")}, rmap)
+	require.True(t, zh.isExhausted())
+}
+
+func TestWarmZoneCache(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	// The zone test.org is looked up only once, and the zone of sub.test.net needs two rounds.
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}, "test.net": {"active"}}, 5)
+
+	domains := []domain.Domain{
+		domain.FQDN("a.test.org"),
+		domain.FQDN("b.test.org"),
+		domain.FQDN("test.org"),
+		domain.FQDN("sub.test.net"),
+	}
+	zoneIDs := []string{
+		mockID("test.org", 0),
+		mockID("test.org", 0),
+		mockID("test.org", 0),
+		mockID("test.net", 0),
+	}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiInternet, "Pre-fetched the zones: %s", "test.net, test.org")
+	ok := h.(*api.CloudflareHandle).WarmZoneCache(context.Background(), mockPP, domains)
+	require.True(t, ok)
+	require.True(t, zh.isExhausted())
+
+	// The cache is warm: no more API calls
+	for i, d := range domains {
+		zoneID, ok := h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, d)
+		require.True(t, ok)
+		require.Equal(t, zoneIDs[i], zoneID)
+	}
+}
+
+func TestWarmZoneCacheInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 3)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to find the zone of %q", "test.com"),
+		mockPP.EXPECT().Warningf(pp.EmojiUserError,
+			"No zone accessible with the API token contains %q; is the domain on Cloudflare?", "test.com"),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Pre-fetched the zones: %s", "test.org"),
+	)
+	ok := h.(*api.CloudflareHandle).WarmZoneCache(context.Background(), mockPP,
+		[]domain.Domain{domain.FQDN("test.com"), domain.FQDN("test.org")})
+	require.False(t, ok)
+	require.True(t, zh.isExhausted())
+}
+
+func TestWarmZoneCacheBounded(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	// The lookups are held until released, so that the ones in flight pile up.
+	var (
+		inFlight, accessCount atomic.Int64
+		release               = make(chan struct{})
+		releaseOnce           sync.Once
+	)
+	releaseAll := func() { releaseOnce.Do(func() { close(release) }) }
+	t.Cleanup(releaseAll)
+	mux.HandleFunc("/zones", func(w http.ResponseWriter, r *http.Request) {
+		accessCount.Add(1)
+		inFlight.Add(1)
+		<-release
+		inFlight.Add(-1)
+
+		handleZones(t, mockAccount, r.URL.Query().Get("name"), []string{"active"}, w, r)
+	})
+
+	const numDomains = 10
+	domains := make([]domain.Domain, 0, numDomains)
+	for i := 0; i < numDomains; i++ {
+		domains = append(domains, domain.FQDN(fmt.Sprintf("test%d.org", i)))
+	}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiInternet, "Pre-fetched the zones: %s", gomock.Any())
+	done := make(chan bool)
+	go func() { done <- h.(*api.CloudflareHandle).WarmZoneCache(context.Background(), mockPP, domains) }()
+
+	require.Eventually(t, func() bool { return inFlight.Load() == api.MaxZoneLookups }, 10*time.Second, time.Millisecond)
+	// More lookups would have been started by now if they were not bounded.
+	time.Sleep(time.Second)
+	require.EqualValues(t, api.MaxZoneLookups, inFlight.Load())
+
+	releaseAll()
+	require.True(t, <-done)
+	require.EqualValues(t, numDomains, accessCount.Load())
+}
+
+func TestZoneIDInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiError,
+		"Failed to check the existence of a zone named %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	zoneID, ok := h.(*api.CloudflareHandle).ZoneID(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	require.False(t, ok)
+	require.Equal(t, "", zoneID)
+}
+
+func TestZoneName(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	accessCount := 1
+	mux.HandleFunc(fmt.Sprintf("/zones/%s", mockID("test.org", 0)), func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+		require.Positive(t, accessCount)
+		accessCount--
+
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(cloudflare.ZoneResponse{
+			Result: *mockZone("test.org", 0, "active"),
+			Response: cloudflare.Response{
+				Success:  true,
+				Errors:   []cloudflare.ResponseInfo{},
+				Messages: []cloudflare.ResponseInfo{},
+			},
+		})
+		require.NoError(t, err)
+	})
+
+	// uncached: the zone is looked up via the API
+	mockPP := mocks.NewMockPP(mockCtrl)
+	name, ok := h.(*api.CloudflareHandle).ZoneName(context.Background(), mockPP, mockID("test.org", 0))
+	require.True(t, ok)
+	require.Equal(t, "test.org", name)
+	require.Zero(t, accessCount)
+
+	// cached: no more API calls
+	name, ok = h.(*api.CloudflareHandle).ZoneName(context.Background(), mockPP, mockID("test.org", 0))
+	require.True(t, ok)
+	require.Equal(t, "test.org", name)
+}
+
+func TestZoneNameInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve the name of the zone %q: %v",
+		mockID("test.org", 0), gomock.Any())
+	name, ok := h.(*api.CloudflareHandle).ZoneName(context.Background(), mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.Equal(t, "", name)
+}
+
+// mockDNSRecord creates a DNS record. The type is left empty for ipnet.Any so that it can be set later.
+func mockDNSRecord(id string, ipNet ipnet.Type, name string, ip string) *cloudflare.DNSRecord {
+	record := &cloudflare.DNSRecord{ //nolint:exhaustruct
+		ID:      id,
+		Name:    name,
+		Content: ip,
+	}
+	if ipNet.IsSpecific() {
+		record.Type = ipNet.RecordType()
+	}
+	return record
+}
+
+func mockDNSListResponse(ipNet ipnet.Type, name string, ips map[string]string) *cloudflare.DNSListResponse {
+	if len(ips) > 100 {
+		panic("mockDNSResponse got too many IPs")
+	}
+
+	rs := make([]cloudflare.DNSRecord, 0, len(ips))
+	for id, ip := range ips {
+		rs = append(rs, *mockDNSRecord(id, ipNet, name, ip))
+	}
+
+	return &cloudflare.DNSListResponse{
+		Result: rs,
+		ResultInfo: cloudflare.ResultInfo{
+			Page:       1,
+			PerPage:    100,
+			TotalPages: (len(ips) + 99) / 100,
+			Count:      len(ips),
+			Total:      len(ips),
+			Cursor:     "",
+			Cursors:    cloudflare.ResultInfoCursors{}, //nolint:exhaustruct
+		},
+		Response: cloudflare.Response{
+			Success:  true,
+			Errors:   []cloudflare.ResponseInfo{},
+			Messages: []cloudflare.ResponseInfo{},
+		},
+	}
+}
+
+func mockDNSListResponseFromAddr(ipNet ipnet.Type, name string, ips map[string]netip.Addr) *cloudflare.DNSListResponse {
+	if len(ips) > 100 {
+		panic("mockDNSResponse got too many IPs")
+	}
+
+	strings := make(map[string]string)
+
+	for id, ip := range ips {
+		strings[id] = ip.String()
+	}
+
+	return mockDNSListResponse(ipNet, name, strings)
+}
+
+//nolint:dupl
+func TestFlushCacheCount(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodGet, r.Method)
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org",
+				map[string]string{"record1": "::1"}))
+			require.NoError(t, err)
+		})
+
+	require.Zero(t, h.FlushCache())
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	_, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.True(t, zh.isExhausted())
+
+	// the zones of "sub.test.org" and "test.org", the zone of the domain, the name of the zone,
+	// and the records of the domain
+	require.Equal(t, 5, h.FlushCache())
+	require.Zero(t, h.FlushCache())
+}
+
+func TestListRecords(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	var (
+		ipNet       ipnet.Type
+		ips         map[string]netip.Addr
+		accessCount int
+	)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if accessCount <= 0 {
+				return
+			}
+			accessCount--
+
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+			require.Equal(t, url.Values{
+				"name": {"sub.test.org"},
+				"page": {"1"},
+				"type": {ipNet.RecordType()},
+			}, r.URL.Query())
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponseFromAddr(ipNet, "test.org", ips))
+			require.NoError(t, err)
+		})
+
+	expected := map[string]netip.Addr{"record1": mustIP("::1"), "record2": mustIP("::2")}
+	ipNet, ips, accessCount = ipnet.IP6, expected, 1
+	mockPP := mocks.NewMockPP(mockCtrl)
+	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, expected, ips)
+	require.Equal(t, 0, accessCount)
+
+	// testing the caching
+	mockPP = mocks.NewMockPP(mockCtrl)
+	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, expected, ips)
+}
+
+//nolint:funlen
+func TestListRecordsInvalidIPAddress(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	var (
+		ipNet       ipnet.Type
+		ips         map[string]netip.Addr
+		accessCount int
+	)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if accessCount <= 0 {
+				return
+			}
+			accessCount--
+
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+			require.Equal(t, url.Values{
+				"name": {"sub.test.org"},
+				"page": {"1"},
+				"type": {ipNet.RecordType()},
+			}, r.URL.Query())
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipNet, "test.org",
+				map[string]string{"record1": "::1", "record2": "NOT AN IP"},
+			))
+			require.NoError(t, err)
+		})
+
+	ipNet, accessCount = ipnet.IP6, 1
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiImpossible,
+		"Failed to parse the IP address in records of %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.False(t, ok)
+	require.Nil(t, ips)
+	require.Equal(t, 0, accessCount)
+
+	// testing the (no) caching
+	mockPP = mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiError,
+		"Failed to retrieve records of %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.False(t, ok)
+	require.Nil(t, ips)
+	require.Equal(t, 0, accessCount)
+}
+
+//nolint:dupl
+func TestListRecordsWildcard(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 1)
+
+	var (
+		ipNet       ipnet.Type
+		ips         map[string]netip.Addr
+		accessCount int
+	)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if accessCount <= 0 {
+				return
+			}
+			accessCount--
+
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+			require.Equal(t, url.Values{
+				"name": {"*.test.org"},
+				"page": {"1"},
+				"type": {ipNet.RecordType()},
+			}, r.URL.Query())
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponseFromAddr(ipNet, "*.test.org", ips))
+			require.NoError(t, err)
+		})
+
+	expected := map[string]netip.Addr{"record1": mustIP("::1"), "record2": mustIP("::2")}
+	ipNet, ips, accessCount = ipnet.IP6, expected, 1
+	mockPP := mocks.NewMockPP(mockCtrl)
+	ips, ok := h.ListRecords(context.Background(), mockPP, domain.Wildcard("test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, expected, ips)
+	require.Equal(t, 0, accessCount)
+
+	// testing the caching
+	mockPP = mocks.NewMockPP(mockCtrl)
+	ips, ok = h.ListRecords(context.Background(), mockPP, domain.Wildcard("test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, expected, ips)
+}
+
+func TestListRecordsInvalidDomain(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", "sub.test.org", gomock.Any())
+	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4)
+	require.False(t, ok)
+	require.Nil(t, ips)
+
+	mockPP = mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", "sub.test.org", gomock.Any())
+	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.False(t, ok)
+	require.Nil(t, ips)
+}
+
+func TestListRecordsInvalidZone(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiError,
+		"Failed to check the existence of a zone named %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4)
+	require.False(t, ok)
+	require.Nil(t, ips)
+
+	mockPP = mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(
+		pp.EmojiError,
+		"Failed to check the existence of a zone named %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.False(t, ok)
+	require.Nil(t, ips)
+}
+
+//nolint:funlen
+func TestListAllZoneRecords(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	const totalPages = 3
+	var accessCount int
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if accessCount <= 0 {
+				return
+			}
+			accessCount--
+
+			require.Equal(t, http.MethodGet, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+			page, err := strconv.Atoi(r.URL.Query().Get("page"))
+			require.NoError(t, err)
+			require.Equal(t, url.Values{"page": {strconv.Itoa(page)}}, r.URL.Query())
+
+			res := mockDNSListResponse(ipnet.IP4, fmt.Sprintf("page%d.test.org", page),
+				map[string]string{fmt.Sprintf("record%d", page): fmt.Sprintf("10.0.0.%d", page)})
+			res.ResultInfo.Page = page
+			res.ResultInfo.TotalPages = totalPages
+
+			w.Header().Set("content-type", "application/json")
+			err = json.NewEncoder(w).Encode(res)
+			require.NoError(t, err)
+		})
+
+	accessCount = totalPages
+	mockPP := mocks.NewMockPP(mockCtrl)
+	rs, ok := h.(*api.CloudflareHandle).ListAllZoneRecords(context.Background(), mockPP, mockID("test.org", 0))
+	require.True(t, ok)
+	require.Equal(t, []api.RecordDetail{
+		{ID: "record1", Type: "A", Name: "page1.test.org", Content: "10.0.0.1", TTL: 0, Proxied: false},
+		{ID: "record2", Type: "A", Name: "page2.test.org", Content: "10.0.0.2", TTL: 0, Proxied: false},
+		{ID: "record3", Type: "A", Name: "page3.test.org", Content: "10.0.0.3", TTL: 0, Proxied: false},
+	}, rs)
+	require.Equal(t, 0, accessCount)
+}
+
+func TestListAllZoneRecordsInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records in the zone %q: %v",
+		mockID("test.org", 0),
+		gomock.Any(),
+	)
+	rs, ok := h.(*api.CloudflareHandle).ListAllZoneRecords(context.Background(), mockPP, mockID("test.org", 0))
+	require.False(t, ok)
+	require.Nil(t, rs)
+}
+
+func envelopDNSRecordResponse(record *cloudflare.DNSRecord) *cloudflare.DNSRecordResponse {
+	return &cloudflare.DNSRecordResponse{
+		Result: *record,
+		ResultInfo: cloudflare.ResultInfo{
+			Page:       1,
+			PerPage:    100,
+			TotalPages: 1,
+			Count:      1,
+			Total:      1,
+			Cursor:     "",
+			Cursors:    cloudflare.ResultInfoCursors{}, //nolint:exhaustruct
+		},
+		Response: cloudflare.Response{
+			Success:  true,
+			Errors:   []cloudflare.ResponseInfo{},
+			Messages: []cloudflare.ResponseInfo{},
+		},
+	}
+}
+
+func mockDNSRecordResponse(id string, ipNet ipnet.Type, name string, ip string) *cloudflare.DNSRecordResponse {
+	return envelopDNSRecordResponse(mockDNSRecord(id, ipNet, name, ip))
+}
+
+func TestDeleteRecordValid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	var (
+		listAccessCount   int
+		deleteAccessCount int
+	)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if listAccessCount <= 0 {
+				return
+			}
+			listAccessCount--
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponseFromAddr(ipnet.IP6, "test.org",
+				map[string]netip.Addr{"record1": mustIP("::1")}))
+			require.NoError(t, err)
+		})
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if deleteAccessCount <= 0 {
+				return
+			}
+			deleteAccessCount--
+
+			require.Equal(t, http.MethodDelete, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+			require.Empty(t, r.URL.Query())
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "test.org", "::1"))
+			require.NoError(t, err)
+		})
+
+	deleteAccessCount = 1
+	mockPP := mocks.NewMockPP(mockCtrl)
+	ok := h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
+	require.True(t, ok)
+
+	listAccessCount, deleteAccessCount = 1, 1
+	mockPP = mocks.NewMockPP(mockCtrl)
+	_, _ = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	_ = h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
+	rs, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Empty(t, rs)
+}
+
+func TestDeleteRecordInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to delete a stale %s record of %q (ID: %s): %v",
+		"AAAA",
+		"sub.test.org",
+		"record1",
+		gomock.Any(),
+	)
+	ok := h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
+	require.False(t, ok)
+}
+
+func handleLockedRecord(t *testing.T, mux *http.ServeMux, zoneID string, recordID string, method string) {
+	t.Helper()
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, recordID),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, method, r.Method)
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+
+			w.Header().Set("content-type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w,
+				`{
+					"success": false,
+					"errors": [{ "code": 81062, "message": "This record is managed by Workers and cannot be modified." }],
+					"messages": [],
+					"result": null
+				}`)
+		})
+}
+
+func TestDeleteRecordLocked(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	handleLockedRecord(t, mux, mockID("test.org", 0), "record1", http.MethodDelete)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiUserWarning,
+		"Could not delete the %s record of %q (ID: %s) because it is managed by Cloudflare Workers",
+		"AAAA",
+		"sub.test.org",
+		"record1",
+	)
+	ok := h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
+	require.False(t, ok)
+}
+
+func TestDeleteRecordZoneInvalid(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
 	_, h := newHandle(t)
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(
-		pp.EmojiError,
-		"Failed to check the existence of a zone named %q: %v",
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v",
 		"sub.test.org",
 		gomock.Any(),
 	)
-	zoneID, ok := h.(*api.CloudflareHandle).ZoneOfDomain(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+	ok := h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
 	require.False(t, ok)
-	require.Equal(t, "", zoneID)
-}
-
-func mockDNSRecord(id string, ipNet ipnet.Type, name string, ip string) *cloudflare.DNSRecord {
-	return &cloudflare.DNSRecord{ //nolint:exhaustruct
-		ID:      id,
-		Type:    ipNet.RecordType(),
-		Name:    name,
-		Content: ip,
-	}
-}
-
-func mockDNSListResponse(ipNet ipnet.Type, name string, ips map[string]string) *cloudflare.DNSListResponse {
-	if len(ips) > 100 {
-		panic("mockDNSResponse got too many IPs")
-	}
-
-	rs := make([]cloudflare.DNSRecord, 0, len(ips))
-	for id, ip := range ips {
-		rs = append(rs, *mockDNSRecord(id, ipNet, name, ip))
-	}
-
-	return &cloudflare.DNSListResponse{
-		Result: rs,
-		ResultInfo: cloudflare.ResultInfo{
-			Page:       1,
-			PerPage:    100,
-			TotalPages: (len(ips) + 99) / 100,
-			Count:      len(ips),
-			Total:      len(ips),
-			Cursor:     "",
-			Cursors:    cloudflare.ResultInfoCursors{}, //nolint:exhaustruct
-		},
-		Response: cloudflare.Response{
-			Success:  true,
-			Errors:   []cloudflare.ResponseInfo{},
-			Messages: []cloudflare.ResponseInfo{},
-		},
-	}
-}
-
-func mockDNSListResponseFromAddr(ipNet ipnet.Type, name string, ips map[string]netip.Addr) *cloudflare.DNSListResponse {
-	if len(ips) > 100 {
-		panic("mockDNSResponse got too many IPs")
-	}
-
-	strings := make(map[string]string)
-
-	for id, ip := range ips {
-		strings[id] = ip.String()
-	}
-
-	return mockDNSListResponse(ipNet, name, strings)
 }
 
-//nolint:dupl
-func TestListRecords(t *testing.T) {
+//nolint:funlen
+func TestUpdateRecordValid(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
@@ -551,48 +2204,122 @@ func TestListRecords(t *testing.T) {
 	zh.set(map[string][]string{"test.org": {"active"}}, 2)
 
 	var (
-		ipNet       ipnet.Type
-		ips         map[string]netip.Addr
-		accessCount int
+		listAccessCount   int
+		updateAccessCount int
 	)
 
 	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
 		func(w http.ResponseWriter, r *http.Request) {
-			if accessCount <= 0 {
+			require.Equal(t, http.MethodGet, r.Method)
+			if listAccessCount <= 0 {
 				return
 			}
-			accessCount--
+			listAccessCount--
+
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "test.org",
+				map[string]string{"record1": "::1"}))
+			require.NoError(t, err)
+		})
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPatch, r.Method)
+			if updateAccessCount <= 0 {
+				return
+			}
+			updateAccessCount--
 
-			require.Equal(t, http.MethodGet, r.Method)
 			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
-			require.Equal(t, url.Values{
-				"name": {"sub.test.org"},
-				"page": {"1"},
-				"type": {ipNet.RecordType()},
-			}, r.URL.Query())
+			require.Empty(t, r.URL.Query())
+
+			var record cloudflare.DNSRecord
+			err := json.NewDecoder(r.Body).Decode(&record)
+			require.NoError(t, err)
+
+			require.Equal(t, "sub.test.org", record.Name)
+			require.Equal(t, ipnet.IP6.RecordType(), record.Type)
+			require.Equal(t, "::2", record.Content)
 
 			w.Header().Set("content-type", "application/json")
-			err := json.NewEncoder(w).Encode(mockDNSListResponseFromAddr(ipNet, "test.org", ips))
+			err = json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "sub.test.org", "::2"))
 			require.NoError(t, err)
 		})
 
-	expected := map[string]netip.Addr{"record1": mustIP("::1"), "record2": mustIP("::2")}
-	ipNet, ips, accessCount = ipnet.IP6, expected, 1
+	updateAccessCount = 1
 	mockPP := mocks.NewMockPP(mockCtrl)
-	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::2"))
 	require.True(t, ok)
-	require.Equal(t, expected, ips)
-	require.Equal(t, 0, accessCount)
 
-	// testing the caching
+	listAccessCount, updateAccessCount = 1, 1
 	mockPP = mocks.NewMockPP(mockCtrl)
-	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	_, _ = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	_ = h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::2"))
+	rs, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
 	require.True(t, ok)
-	require.Equal(t, expected, ips)
+	require.Equal(t, map[string]netip.Addr{"record1": mustIP("::2")}, rs)
+}
+
+func TestUpdateRecordInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
+		"AAAA",
+		"sub.test.org",
+		"record1",
+		gomock.Any(),
+	)
+	ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::1"))
+	require.False(t, ok)
+}
+
+func TestUpdateRecordLocked(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	handleLockedRecord(t, mux, mockID("test.org", 0), "record1", http.MethodPatch)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiUserWarning,
+		"Could not update the %s record of %q (ID: %s) because it is managed by Cloudflare Workers",
+		"AAAA",
+		"sub.test.org",
+		"record1",
+	)
+	ok := h.UpdateRecord(context.Background(), mockPP,
+		domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::1"))
+	require.False(t, ok)
+}
+
+func TestUpdateRecordInvalidZone(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::1"))
+	require.False(t, ok)
 }
 
 //nolint:funlen
-func TestListRecordsInvalidIPAddress(t *testing.T) {
+func TestCreateRecordValid(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
@@ -602,112 +2329,200 @@ func TestListRecordsInvalidIPAddress(t *testing.T) {
 	zh.set(map[string][]string{"test.org": {"active"}}, 2)
 
 	var (
-		ipNet       ipnet.Type
-		ips         map[string]netip.Addr
-		accessCount int
+		listAccessCount   int
+		createAccessCount int
 	)
 
 	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
 		func(w http.ResponseWriter, r *http.Request) {
-			if accessCount <= 0 {
-				return
+			switch r.Method {
+			case http.MethodGet:
+				if listAccessCount <= 0 {
+					return
+				}
+				listAccessCount--
+
+				w.Header().Set("content-type", "application/json")
+				err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "test.org",
+					map[string]string{"record1": "::1"}))
+				require.NoError(t, err)
+			case http.MethodPost:
+				if createAccessCount <= 0 {
+					return
+				}
+				createAccessCount--
+
+				require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+				require.Empty(t, r.URL.Query())
+
+				var record cloudflare.DNSRecord
+				err := json.NewDecoder(r.Body).Decode(&record)
+				require.NoError(t, err)
+
+				require.Equal(t, "sub.test.org", record.Name)
+				require.Equal(t, ipnet.IP6.RecordType(), record.Type)
+				require.Equal(t, "::1", record.Content)
+				require.Equal(t, 100, record.TTL)
+				require.Equal(t, false, *record.Proxied)
+				record.ID = "record1"
+
+				w.Header().Set("content-type", "application/json")
+				err = json.NewEncoder(w).Encode(envelopDNSRecordResponse(&record))
+				require.NoError(t, err)
 			}
-			accessCount--
+		})
 
-			require.Equal(t, http.MethodGet, r.Method)
-			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
-			require.Equal(t, url.Values{
-				"name": {"sub.test.org"},
-				"page": {"1"},
-				"type": {ipNet.RecordType()},
-			}, r.URL.Query())
+	createAccessCount = 1
+	mockPP := mocks.NewMockPP(mockCtrl)
+	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false, "") //nolint:lll
+	require.True(t, ok)
+	require.Equal(t, "record1", actualID)
+
+	listAccessCount, createAccessCount = 1, 1
+	mockPP = mocks.NewMockPP(mockCtrl)
+	_, _ = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	_, _ = h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false, "") //nolint:lll
+	rs, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, map[string]netip.Addr{"record1": mustIP("::1")}, rs)
+}
+
+func TestCreateRecordComment(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		comment  string
+		expected any
+	}{
+		"none":    {"", nil},
+		"comment": {"managed by cloudflare-ddns", "managed by cloudflare-ddns"},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mux, h := newHandle(t)
+
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodPost, r.Method)
+
+					var body map[string]any
+					err := json.NewDecoder(r.Body).Decode(&body)
+					require.NoError(t, err)
+					require.Equal(t, tc.expected, body["comment"])
 
-			w.Header().Set("content-type", "application/json")
-			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipNet, "test.org",
-				map[string]string{"record1": "::1", "record2": "NOT AN IP"},
-			))
-			require.NoError(t, err)
+					w.Header().Set("content-type", "application/json")
+					err = json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "sub.test.org", "::1"))
+					require.NoError(t, err)
+				})
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			id, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6,
+				mustIP("::1"), api.TTLAuto, false, tc.comment)
+			require.True(t, ok)
+			require.Equal(t, "record1", id)
 		})
+	}
+}
+
+func TestCreateRecordInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
 
-	ipNet, accessCount = ipnet.IP6, 1
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(
-		pp.EmojiImpossible,
-		"Failed to parse the IP address in records of %q: %v",
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to add a new %s record of %q: %v",
+		"AAAA",
 		"sub.test.org",
 		gomock.Any(),
 	)
-	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false, "") //nolint:lll
 	require.False(t, ok)
-	require.Nil(t, ips)
-	require.Equal(t, 0, accessCount)
+	require.Equal(t, "", actualID)
+}
 
-	// testing the (no) caching
-	mockPP = mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(
-		pp.EmojiError,
-		"Failed to retrieve records of %q: %v",
+func TestCreateRecordInvalidZone(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v",
 		"sub.test.org",
 		gomock.Any(),
 	)
-	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false, "") //nolint:lll
 	require.False(t, ok)
-	require.Nil(t, ips)
-	require.Equal(t, 0, accessCount)
+	require.Equal(t, "", actualID)
 }
 
-//nolint:dupl
-func TestListRecordsWildcard(t *testing.T) {
+func TestEnsureRecordExists(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
-	mux, h := newHandle(t)
+	for name, tc := range map[string]struct {
+		records         map[string]string
+		expectedID      string
+		expectedCreated bool
+	}{
+		"exists":        {map[string]string{"record1": "::2", "record2": "::1"}, "record2", false},
+		"exists/many":   {map[string]string{"record2": "::1", "record1": "::1"}, "record1", false},
+		"absent":        {map[string]string{}, "record3", true},
+		"partial-match": {map[string]string{"record1": "::2"}, "record3", true},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
 
-	zh := newZonesHandler(t, mux)
-	zh.set(map[string][]string{"test.org": {"active"}}, 1)
+			mux, h := newHandle(t)
 
-	var (
-		ipNet       ipnet.Type
-		ips         map[string]netip.Addr
-		accessCount int
-	)
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+			var createCount atomic.Int64
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("content-type", "application/json")
+					switch r.Method {
+					case http.MethodGet:
+						err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "sub.test.org", tc.records))
+						require.NoError(t, err)
+					case http.MethodPost:
+						createCount.Add(1)
+						err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record3", ipnet.IP6, "sub.test.org", "::1"))
+						require.NoError(t, err)
+					default:
+						require.Fail(t, "unexpected method", r.Method)
+					}
+				})
 
-	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
-		func(w http.ResponseWriter, r *http.Request) {
-			if accessCount <= 0 {
-				return
+			mockPP := mocks.NewMockPP(mockCtrl)
+			id, created, ok := h.EnsureRecordExists(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6,
+				mustIP("::1"), api.TTLAuto, false)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedID, id)
+			require.Equal(t, tc.expectedCreated, created)
+			if tc.expectedCreated {
+				require.EqualValues(t, 1, createCount.Load())
+			} else {
+				require.Zero(t, createCount.Load())
 			}
-			accessCount--
-
-			require.Equal(t, http.MethodGet, r.Method)
-			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
-			require.Equal(t, url.Values{
-				"name": {"*.test.org"},
-				"page": {"1"},
-				"type": {ipNet.RecordType()},
-			}, r.URL.Query())
-
-			w.Header().Set("content-type", "application/json")
-			err := json.NewEncoder(w).Encode(mockDNSListResponseFromAddr(ipNet, "*.test.org", ips))
-			require.NoError(t, err)
+			require.True(t, zh.isExhausted())
 		})
-
-	expected := map[string]netip.Addr{"record1": mustIP("::1"), "record2": mustIP("::2")}
-	ipNet, ips, accessCount = ipnet.IP6, expected, 1
-	mockPP := mocks.NewMockPP(mockCtrl)
-	ips, ok := h.ListRecords(context.Background(), mockPP, domain.Wildcard("test.org"), ipnet.IP6)
-	require.True(t, ok)
-	require.Equal(t, expected, ips)
-	require.Equal(t, 0, accessCount)
-
-	// testing the caching
-	mockPP = mocks.NewMockPP(mockCtrl)
-	ips, ok = h.ListRecords(context.Background(), mockPP, domain.Wildcard("test.org"), ipnet.IP6)
-	require.True(t, ok)
-	require.Equal(t, expected, ips)
+	}
 }
 
-func TestListRecordsInvalidDomain(t *testing.T) {
+func TestEnsureRecordExistsInvalid(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
@@ -718,365 +2533,511 @@ func TestListRecordsInvalidDomain(t *testing.T) {
 
 	mockPP := mocks.NewMockPP(mockCtrl)
 	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", "sub.test.org", gomock.Any())
-	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4)
+	id, created, ok := h.EnsureRecordExists(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6,
+		mustIP("::1"), api.TTLAuto, false)
 	require.False(t, ok)
-	require.Nil(t, ips)
+	require.False(t, created)
+	require.Empty(t, id)
+}
 
-	mockPP = mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to retrieve records of %q: %v", "sub.test.org", gomock.Any())
-	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
-	require.False(t, ok)
-	require.Nil(t, ips)
+func newHandleRejectingCloudflareIPs(t *testing.T) (*http.ServeMux, api.Handle) {
+	t.Helper()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+	auth.RejectCloudflareIPs = true
+
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
+
+	return mux, h
 }
 
-func TestListRecordsInvalidZone(t *testing.T) {
+func TestUpdateRecordCloudflareIP(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
-	_, h := newHandle(t)
+	_, h := newHandleRejectingCloudflareIPs(t)
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(
-		pp.EmojiError,
-		"Failed to check the existence of a zone named %q: %v",
+	mockPP.EXPECT().Errorf(pp.EmojiError, "Refused to use %v for %q because it belongs to Cloudflare",
+		mustIP("2606:4700::1111"),
 		"sub.test.org",
-		gomock.Any(),
 	)
-	ips, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4)
+	ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("2606:4700::1111")) //nolint:lll
 	require.False(t, ok)
-	require.Nil(t, ips)
+}
 
-	mockPP = mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(
-		pp.EmojiError,
-		"Failed to check the existence of a zone named %q: %v",
+func TestCreateRecordCloudflareIP(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandleRejectingCloudflareIPs(t)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Errorf(pp.EmojiError, "Refused to use %v for %q because it belongs to Cloudflare",
+		mustIP("104.16.0.1"),
 		"sub.test.org",
-		gomock.Any(),
 	)
-	ips, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4, mustIP("104.16.0.1"), 100, false, "") //nolint:lll
 	require.False(t, ok)
-	require.Nil(t, ips)
+	require.Equal(t, "", actualID)
 }
 
-func envelopDNSRecordResponse(record *cloudflare.DNSRecord) *cloudflare.DNSRecordResponse {
-	return &cloudflare.DNSRecordResponse{
-		Result: *record,
-		ResultInfo: cloudflare.ResultInfo{
-			Page:       1,
-			PerPage:    100,
-			TotalPages: 1,
-			Count:      1,
-			Total:      1,
-			Cursor:     "",
-			Cursors:    cloudflare.ResultInfoCursors{}, //nolint:exhaustruct
-		},
-		Response: cloudflare.Response{
-			Success:  true,
-			Errors:   []cloudflare.ResponseInfo{},
-			Messages: []cloudflare.ResponseInfo{},
-		},
-	}
-}
+func TestCreateRecordNonCloudflareIP(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
 
-func mockDNSRecordResponse(id string, ipNet ipnet.Type, name string, ip string) *cloudflare.DNSRecordResponse {
-	return envelopDNSRecordResponse(mockDNSRecord(id, ipNet, name, ip))
+	_, h := newHandleRejectingCloudflareIPs(t)
+
+	// The IP is not rejected, so the updater proceeds to look up the zone.
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v",
+		"sub.test.org",
+		gomock.Any(),
+	)
+	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP4, mustIP("1.1.1.1"), 100, false, "") //nolint:lll
+	require.False(t, ok)
+	require.Equal(t, "", actualID)
 }
 
-func TestDeleteRecordValid(t *testing.T) {
+//nolint:funlen
+func TestBatchUpdate(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
-	mux, h := newHandle(t)
+	updates := []api.RecordUpdate{
+		{Domain: domain.FQDN("a.test.org"), IPNet: ipnet.IP6, ID: "record1", IP: mustIP("::2")},
+		{Domain: domain.FQDN("b.test.org"), IPNet: ipnet.IP6, ID: "record2", IP: mustIP("::2")},
+		{Domain: domain.FQDN("c.test.org"), IPNet: ipnet.IP6, ID: "record3", IP: mustIP("::2")},
+	}
 
-	zh := newZonesHandler(t, mux)
-	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+	for name, tc := range map[string]struct {
+		records       map[string]map[string]string
+		updatable     map[string]bool
+		ok            bool
+		errs          []error
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"all-success": {
+			map[string]map[string]string{
+				"a.test.org": {"record1": "::1"},
+				"b.test.org": {"record2": "::1"},
+				"c.test.org": {"record3": "::2"},
+			},
+			map[string]bool{"record1": true, "record2": true},
+			true,
+			[]error{nil, nil, nil},
+			nil,
+		},
+		"partial-failure": {
+			map[string]map[string]string{
+				"a.test.org": {"record1": "::1"},
+				"b.test.org": {"record2": "::1"},
+				"c.test.org": {},
+			},
+			map[string]bool{"record1": true},
+			false,
+			[]error{nil, api.ErrRecordUpdateFailed, api.ErrRecordNotFound},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update the %s record of %q (ID: %s): %v",
+					"AAAA", "c.test.org", "record3", api.ErrRecordNotFound)
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
+					"AAAA", "b.test.org", "record2", gomock.Any())
+			},
+		},
+		"all-failure": {
+			map[string]map[string]string{
+				"a.test.org": {"record1": "::1"},
+				"b.test.org": {"record2": "::1"},
+				"c.test.org": {},
+			},
+			map[string]bool{},
+			false,
+			[]error{api.ErrRecordUpdateFailed, api.ErrRecordUpdateFailed, api.ErrRecordNotFound},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update the %s record of %q (ID: %s): %v",
+					"AAAA", "c.test.org", "record3", api.ErrRecordNotFound)
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
+					"AAAA", "a.test.org", "record1", gomock.Any())
+				m.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
+					"AAAA", "b.test.org", "record2", gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
 
-	var (
-		listAccessCount   int
-		deleteAccessCount int
-	)
+			mux, h := newHandle(t)
 
-	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
-		func(w http.ResponseWriter, r *http.Request) {
-			if listAccessCount <= 0 {
-				return
-			}
-			listAccessCount--
+			zh := newZonesHandler(t, mux)
+			zh.set(map[string][]string{"test.org": {"active"}}, 4)
+
+			mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+				func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+
+					name := r.URL.Query().Get("name")
+					w.Header().Set("content-type", "application/json")
+					err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, name, tc.records[name]))
+					require.NoError(t, err)
+				})
+
+			for _, u := range updates {
+				u := u
+				if !tc.updatable[u.ID] {
+					continue
+				}
 
-			w.Header().Set("content-type", "application/json")
-			err := json.NewEncoder(w).Encode(mockDNSListResponseFromAddr(ipnet.IP6, "test.org",
-				map[string]netip.Addr{"record1": mustIP("::1")}))
-			require.NoError(t, err)
-		})
+				mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/%s", mockID("test.org", 0), u.ID),
+					func(w http.ResponseWriter, r *http.Request) {
+						require.Equal(t, http.MethodPatch, r.Method)
 
-	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
-		func(w http.ResponseWriter, r *http.Request) {
-			if deleteAccessCount <= 0 {
-				return
+						w.Header().Set("content-type", "application/json")
+						err := json.NewEncoder(w).Encode(
+							mockDNSRecordResponse(u.ID, ipnet.IP6, u.Domain.DNSNameASCII(), u.IP.String()))
+						require.NoError(t, err)
+					})
 			}
-			deleteAccessCount--
-
-			require.Equal(t, http.MethodDelete, r.Method)
-			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
-			require.Empty(t, r.URL.Query())
 
-			w.Header().Set("content-type", "application/json")
-			err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "test.org", "::1"))
-			require.NoError(t, err)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			results, ok := h.BatchUpdate(context.Background(), mockPP, updates)
+			require.Equal(t, tc.ok, ok)
+			require.Len(t, results, len(updates))
+			for i, result := range results {
+				require.Equal(t, updates[i].Domain, result.Domain)
+				require.Equal(t, tc.errs[i] == nil, result.Success)
+				require.Equal(t, tc.errs[i], result.Err)
+			}
+			require.True(t, zh.isExhausted())
 		})
-
-	deleteAccessCount = 1
-	mockPP := mocks.NewMockPP(mockCtrl)
-	ok := h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
-	require.True(t, ok)
-
-	listAccessCount, deleteAccessCount = 1, 1
-	mockPP = mocks.NewMockPP(mockCtrl)
-	_, _ = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
-	_ = h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
-	rs, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
-	require.True(t, ok)
-	require.Empty(t, rs)
+	}
 }
 
-func TestDeleteRecordInvalid(t *testing.T) {
+//nolint:funlen
+func TestImportRecords(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
 	mux, h := newHandle(t)
 
 	zh := newZonesHandler(t, mux)
-	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+	zh.set(map[string][]string{"test.org": {"active"}}, 3)
+
+	existing := map[string]map[string]string{
+		"A test.org": {"record0": "1.2.3.4"},
+	}
+	type created struct {
+		name, recordType, content string
+		ttl                       int
+	}
+	var createdRecords []created
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
+			w.Header().Set("content-type", "application/json")
+
+			switch r.Method {
+			case http.MethodGet:
+				name, recordType := r.URL.Query().Get("name"), r.URL.Query().Get("type")
+				ipNet := map[string]ipnet.Type{"A": ipnet.IP4, "AAAA": ipnet.IP6}[recordType]
+				err := json.NewEncoder(w).Encode(mockDNSListResponse(ipNet, name, existing[recordType+" "+name]))
+				require.NoError(t, err)
+			case http.MethodPost:
+				var record cloudflare.DNSRecord
+				err := json.NewDecoder(r.Body).Decode(&record)
+				require.NoError(t, err)
+				require.Equal(t, false, *record.Proxied)
+
+				createdRecords = append(createdRecords, created{record.Name, record.Type, record.Content, record.TTL})
+				record.ID = fmt.Sprintf("record%d", len(createdRecords))
+
+				err = json.NewEncoder(w).Encode(envelopDNSRecordResponse(&record))
+				require.NoError(t, err)
+			}
+		})
+
+	zoneFile := `$ORIGIN test.org.
+$TTL 300
+@       IN  A     1.2.3.4 ; already in Cloudflare
+www     600 IN AAAA 2001:db8::1
+        IN  A     5.6.7.8
+mail    IN  MX    10 mail.test.org.
+sub.test.org. IN A ( 9.9.9.9 )
+www     IN  A     5.6.7.8
+`
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to delete a stale %s record of %q (ID: %s): %v",
-		"AAAA",
-		"sub.test.org",
-		"record1",
-		gomock.Any(),
+	gomock.InOrder(
+		mockPP.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s record of %q with %v already exists", "A", "test.org", mustIP("1.2.3.4")),     //nolint:lll
+		mockPP.EXPECT().Noticef(pp.EmojiAddRecord, "Imported a %s record of %q (ID: %s)", "AAAA", "www.test.org", "record1"),             //nolint:lll
+		mockPP.EXPECT().Noticef(pp.EmojiAddRecord, "Imported a %s record of %q (ID: %s)", "A", "www.test.org", "record2"),                //nolint:lll
+		mockPP.EXPECT().Noticef(pp.EmojiAddRecord, "Imported a %s record of %q (ID: %s)", "A", "sub.test.org", "record3"),                //nolint:lll
+		mockPP.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s record of %q with %v already exists", "A", "www.test.org", mustIP("5.6.7.8")), //nolint:lll
 	)
-	ok := h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
-	require.False(t, ok)
+	ok := h.(*api.CloudflareHandle).ImportRecords(context.Background(), mockPP, "test.org", zoneFile)
+	require.True(t, ok)
+	require.Equal(t, []created{
+		{"www.test.org", "AAAA", "2001:db8::1", 600},
+		{"www.test.org", "A", "5.6.7.8", 300},
+		{"sub.test.org", "A", "9.9.9.9", 300},
+	}, createdRecords)
 }
 
-func TestDeleteRecordZoneInvalid(t *testing.T) {
+func TestImportRecordsInvalid(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
 	_, h := newHandle(t)
 
+	zoneFile := `$INCLUDE other.zone
+$TTL forever
+@     IN  A     1.2.3
+www   IN  AAAA  1.2.3.4
+ftp   IN  A     1.2.3.4 5.6.7.8
+mail  IN
+(
+`
+
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v",
-		"sub.test.org",
-		gomock.Any(),
+	gomock.InOrder(
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", 1, gomock.Any()),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", 2, gomock.Any()),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", 3, gomock.Any()),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", 4, gomock.Any()),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", 5, gomock.Any()),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", 6, gomock.Any()),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", 7, gomock.Any()),
 	)
-	ok := h.DeleteRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1")
+	ok := h.(*api.CloudflareHandle).ImportRecords(context.Background(), mockPP, "test.org", zoneFile)
 	require.False(t, ok)
 }
 
-//nolint:funlen
-func TestUpdateRecordValid(t *testing.T) {
+func TestExportZoneFile(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
 	mux, h := newHandle(t)
 
-	zh := newZonesHandler(t, mux)
-	zh.set(map[string][]string{"test.org": {"active"}}, 2)
-
-	var (
-		listAccessCount   int
-		updateAccessCount int
-	)
+	zoneFile := `;;
+;; Domain:     test.org.
+;; Exported:   2022-11-01 00:00:00
+;;
+$ORIGIN test.org.
+@	3600	IN	SOA	test.org. root.test.org. 2042000000 10000 2400 604800 3600
 
-	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
-		func(w http.ResponseWriter, r *http.Request) {
-			require.Equal(t, http.MethodGet, r.Method)
-			if listAccessCount <= 0 {
-				return
-			}
-			listAccessCount--
+;; A Records
+sub.test.org.	1	IN	A	1.1.1.1
 
-			w.Header().Set("content-type", "application/json")
-			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "test.org",
-				map[string]string{"record1": "::1"}))
-			require.NoError(t, err)
-		})
+;; AAAA Records
+sub.test.org.	1	IN	AAAA	::1
+`
 
-	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/record1", mockID("test.org", 0)),
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/export", mockID("test.org", 0)),
 		func(w http.ResponseWriter, r *http.Request) {
-			require.Equal(t, http.MethodPatch, r.Method)
-			if updateAccessCount <= 0 {
-				return
-			}
-			updateAccessCount--
-
+			require.Equal(t, http.MethodGet, r.Method)
 			require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
-			require.Empty(t, r.URL.Query())
-
-			var record cloudflare.DNSRecord
-			err := json.NewDecoder(r.Body).Decode(&record)
-			require.NoError(t, err)
-
-			require.Equal(t, "sub.test.org", record.Name)
-			require.Equal(t, ipnet.IP6.RecordType(), record.Type)
-			require.Equal(t, "::2", record.Content)
 
-			w.Header().Set("content-type", "application/json")
-			err = json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "sub.test.org", "::2"))
-			require.NoError(t, err)
+			w.Header().Set("content-type", "text/plain")
+			fmt.Fprint(w, zoneFile)
 		})
 
-	updateAccessCount = 1
 	mockPP := mocks.NewMockPP(mockCtrl)
-	ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::2"))
-	require.True(t, ok)
-
-	listAccessCount, updateAccessCount = 1, 1
-	mockPP = mocks.NewMockPP(mockCtrl)
-	_, _ = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
-	_ = h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::2"))
-	rs, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
+	content, ok := h.(*api.CloudflareHandle).ExportZoneFile(context.Background(), mockPP, mockID("test.org", 0))
 	require.True(t, ok)
-	require.Equal(t, map[string]netip.Addr{"record1": mustIP("::2")}, rs)
+	require.Equal(t, []byte(zoneFile), content)
 }
 
-func TestUpdateRecordInvalid(t *testing.T) {
+func TestExportZoneFileInvalid(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
-	mux, h := newHandle(t)
-
-	zh := newZonesHandler(t, mux)
-	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+	_, h := newHandle(t)
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to update a stale %s record of %q (ID: %s): %v",
-		"AAAA",
-		"sub.test.org",
-		"record1",
-		gomock.Any(),
-	)
-	ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::1"))
+	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to export the zone %q: %v", mockID("test.org", 0), gomock.Any())
+	content, ok := h.(*api.CloudflareHandle).ExportZoneFile(context.Background(), mockPP, mockID("test.org", 0))
 	require.False(t, ok)
+	require.Nil(t, content)
 }
 
-func TestUpdateRecordInvalidZone(t *testing.T) {
+func TestNewProxy(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
-	_, h := newHandle(t)
+	// The test proxy answers the requests itself instead of forwarding them.
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "api.cloudflare.invalid", r.URL.Host)
+		require.Equal(t, "/client/v4/user/tokens/verify", r.URL.Path)
+		proxied = true
+		handleTokensVerify(t, w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	_, auth := newServerAuth(t)
+	auth.BaseURL = "http://api.cloudflare.invalid/client/v4"
+	auth.ProxyURL = proxy.URL
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v",
-		"sub.test.org",
-		gomock.Any(),
-	)
-	ok := h.UpdateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, "record1", mustIP("::1"))
-	require.False(t, ok)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
+	require.True(t, proxied)
 }
 
-//nolint:funlen
-func TestCreateRecordValid(t *testing.T) {
+func TestNewTransportConfig(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
-	mux, h := newHandle(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
 
-	zh := newZonesHandler(t, mux)
-	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+	// The server never closes idle connections, so any closing must come from the client.
+	closed := make(chan struct{}, 1)
+	ts := httptest.NewUnstartedServer(mux)
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	ts.Start()
+	t.Cleanup(ts.Close)
 
-	var (
-		listAccessCount   int
-		createAccessCount int
-	)
+	_, auth := newServerAuth(t)
+	auth.BaseURL = ts.URL
+	auth.TransportConfig = api.TransportConfig{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     time.Millisecond * 10,
+	}
 
-	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
-		func(w http.ResponseWriter, r *http.Request) {
-			switch r.Method {
-			case http.MethodGet:
-				if listAccessCount <= 0 {
-					return
-				}
-				listAccessCount--
+	mockPP := mocks.NewMockPP(mockCtrl)
+	h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+	require.True(t, ok)
+	require.NotNil(t, h)
 
-				w.Header().Set("content-type", "application/json")
-				err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "test.org",
-					map[string]string{"record1": "::1"}))
-				require.NoError(t, err)
-			case http.MethodPost:
-				if createAccessCount <= 0 {
-					return
-				}
-				createAccessCount--
+	select {
+	case <-closed:
+	case <-time.After(time.Second * 5):
+		require.Fail(t, "The idle connection was not closed")
+	}
+}
 
-				require.Equal(t, []string{fmt.Sprintf("Bearer %s", mockToken)}, r.Header["Authorization"])
-				require.Empty(t, r.URL.Query())
+func TestNewProxyInvalid(t *testing.T) {
+	t.Parallel()
 
-				var record cloudflare.DNSRecord
-				err := json.NewDecoder(r.Body).Decode(&record)
-				require.NoError(t, err)
+	for name, tc := range map[string]struct {
+		proxyURL      string
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"malformed": {
+			"http://[::1",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse the proxy URL %q: %v", "http://[::1", gomock.Any())
+			},
+		},
+		"scheme": {
+			"ftp://proxy.test",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "The proxy URL %q should use http://, https://, or socks5://", "ftp://proxy.test") //nolint:lll
+			},
+		},
+		"nohost": {
+			"socks5://",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "The proxy URL %q does not specify a host", "socks5://")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
 
-				require.Equal(t, "sub.test.org", record.Name)
-				require.Equal(t, ipnet.IP6.RecordType(), record.Type)
-				require.Equal(t, "::1", record.Content)
-				require.Equal(t, 100, record.TTL)
-				require.Equal(t, false, *record.Proxied)
-				record.ID = "record1"
+			_, auth := newServerAuth(t)
+			auth.ProxyURL = tc.proxyURL
 
-				w.Header().Set("content-type", "application/json")
-				err = json.NewEncoder(w).Encode(envelopDNSRecordResponse(&record))
-				require.NoError(t, err)
-			}
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+			h, ok := auth.New(context.Background(), mockPP, time.Second, time.Second)
+			require.False(t, ok)
+			require.Nil(t, h)
 		})
-
-	createAccessCount = 1
-	mockPP := mocks.NewMockPP(mockCtrl)
-	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false) //nolint:lll
-	require.True(t, ok)
-	require.Equal(t, "record1", actualID)
-
-	listAccessCount, createAccessCount = 1, 1
-	mockPP = mocks.NewMockPP(mockCtrl)
-	_, _ = h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
-	_, _ = h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false) //nolint:lll
-	rs, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6)
-	require.True(t, ok)
-	require.Equal(t, map[string]netip.Addr{"record1": mustIP("::1")}, rs)
+	}
 }
 
-func TestCreateRecordInvalid(t *testing.T) {
+//nolint:funlen
+func TestCacheExpiration(t *testing.T) {
 	t.Parallel()
 	mockCtrl := gomock.NewController(t)
 
-	mux, h := newHandle(t)
+	mux, auth := newServerAuth(t)
+	mockClock := clock.NewMock(time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC))
+	auth.Clock = mockClock
 
-	zh := newZonesHandler(t, mux)
-	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		handleTokensVerify(t, w, r)
+	})
 
 	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to add a new %s record of %q: %v",
-		"AAAA",
-		"sub.test.org",
-		gomock.Any(),
-	)
-	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false) //nolint:lll
-	require.False(t, ok)
-	require.Equal(t, "", actualID)
-}
+	h, ok := auth.New(context.Background(), mockPP, time.Minute, time.Second)
+	require.True(t, ok)
 
-func TestCreateRecordInvalidZone(t *testing.T) {
-	t.Parallel()
-	mockCtrl := gomock.NewController(t)
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 1)
 
-	_, h := newHandle(t)
+	var listAccessCount int
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if listAccessCount <= 0 {
+				return
+			}
+			listAccessCount--
 
-	mockPP := mocks.NewMockPP(mockCtrl)
-	mockPP.EXPECT().Warningf(pp.EmojiError, "Failed to check the existence of a zone named %q: %v",
-		"sub.test.org",
-		gomock.Any(),
-	)
-	actualID, ok := h.CreateRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), ipnet.IP6, mustIP("::1"), 100, false) //nolint:lll
-	require.False(t, ok)
-	require.Equal(t, "", actualID)
+			w.Header().Set("content-type", "application/json")
+			err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP6, "test.org", map[string]string{"record1": "::1"}))
+			require.NoError(t, err)
+		})
+
+	expected := map[string]netip.Addr{"record1": mustIP("::1")}
+
+	listAccessCount = 1
+	rs, ok := h.ListRecords(context.Background(), mockPP, domain.FQDN("test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, expected, rs)
+	require.Equal(t, 0, listAccessCount)
+	require.True(t, zh.isExhausted())
+
+	// Just before the expiration, the cached results are used.
+	mockClock.Advance(time.Minute - time.Nanosecond)
+	rs, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, expected, rs)
+
+	// Exactly at the expiration, the records (and the zone) are retrieved again.
+	mockClock.Advance(time.Nanosecond)
+	listAccessCount = 1
+	zh.set(map[string][]string{"test.org": {"active"}}, 1)
+	rs, ok = h.ListRecords(context.Background(), mockPP, domain.FQDN("test.org"), ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, expected, rs)
+	require.Equal(t, 0, listAccessCount)
+	require.True(t, zh.isExhausted())
 }
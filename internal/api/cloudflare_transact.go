@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A transaction wraps a CloudflareHandle and remembers how to revert every successful change.
+// The handle is not embedded so that only the methods of Handle are exposed; other mutating methods
+// of CloudflareHandle would bypass the recording of changes.
+type transaction struct {
+	handle *CloudflareHandle
+	mutex  sync.Mutex
+	undos  []func(ctx context.Context, ppfmt pp.PP) bool
+}
+
+func (t *transaction) addUndo(undo func(ctx context.Context, ppfmt pp.PP) bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.undos = append(t.undos, undo)
+}
+
+// previousIP returns the current IP address of a record before it is changed.
+func (t *transaction) previousIP(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string,
+) netip.Addr {
+	rmap, ok := t.handle.ListRecords(ctx, ppfmt, domain, ipNet)
+	if !ok {
+		return netip.Addr{}
+	}
+	return rmap[id]
+}
+
+func (t *transaction) undoCreate(domain domain.Domain, ipNet ipnet.Type, id string) {
+	t.addUndo(func(ctx context.Context, ppfmt pp.PP) bool {
+		return t.handle.DeleteRecord(ctx, ppfmt, domain, ipNet, id)
+	})
+}
+
+func (t *transaction) undoUpdate(domain domain.Domain, ipNet ipnet.Type, id string, oldIP netip.Addr) {
+	t.addUndo(func(ctx context.Context, ppfmt pp.PP) bool {
+		if !oldIP.IsValid() {
+			ppfmt.Warningf(pp.EmojiError, "Failed to restore the %s record of %q (ID: %s): the previous IP address is unknown",
+				ipNet.RecordType(), domain.Describe(), id)
+			return false
+		}
+		return t.handle.UpdateRecord(ctx, ppfmt, domain, ipNet, id, oldIP)
+	})
+}
+
+func (t *transaction) ListRecords(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type,
+) (map[string]netip.Addr, bool) {
+	return t.handle.ListRecords(ctx, ppfmt, domain, ipNet)
+}
+
+func (t *transaction) DeleteRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string,
+) bool {
+	if !t.handle.DeleteRecord(ctx, ppfmt, domain, ipNet, id) {
+		return false
+	}
+
+	// The TTL, the proxy status, and the comment of a deleted record are lost.
+	t.addUndo(func(_ context.Context, ppfmt pp.PP) bool {
+		ppfmt.Warningf(pp.EmojiError, "Failed to restore the %s record of %q (ID: %s): deleted records cannot be restored",
+			ipNet.RecordType(), domain.Describe(), id)
+		return false
+	})
+	return true
+}
+
+func (t *transaction) UpdateRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string, ip netip.Addr,
+) bool {
+	oldIP := t.previousIP(ctx, ppfmt, domain, ipNet, id)
+	if !t.handle.UpdateRecord(ctx, ppfmt, domain, ipNet, id, ip) {
+		return false
+	}
+
+	t.undoUpdate(domain, ipNet, id, oldIP)
+	return true
+}
+
+func (t *transaction) CreateRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl TTL, proxied bool, comment string,
+) (string, bool) {
+	id, ok := t.handle.CreateRecord(ctx, ppfmt, domain, ipNet, ip, ttl, proxied, comment)
+	if !ok {
+		return "", false
+	}
+
+	t.undoCreate(domain, ipNet, id)
+	return id, true
+}
+
+func (t *transaction) EnsureRecordExists(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl TTL, proxied bool,
+) (string, bool, bool) {
+	id, created, ok := t.handle.EnsureRecordExists(ctx, ppfmt, domain, ipNet, ip, ttl, proxied)
+	if created {
+		t.undoCreate(domain, ipNet, id)
+	}
+	return id, created, ok
+}
+
+func (t *transaction) BatchUpdate(ctx context.Context, ppfmt pp.PP,
+	updates []RecordUpdate,
+) ([]RecordUpdateResult, bool) {
+	oldIPs := make([]netip.Addr, len(updates))
+	for i, u := range updates {
+		oldIPs[i] = t.previousIP(ctx, ppfmt, u.Domain, u.IPNet, u.ID)
+	}
+
+	results, ok := t.handle.BatchUpdate(ctx, ppfmt, updates)
+	for i, u := range updates {
+		if results[i].Success && oldIPs[i] != u.IP {
+			t.undoUpdate(u.Domain, u.IPNet, u.ID, oldIPs[i])
+		}
+	}
+	return results, ok
+}
+
+func (t *transaction) Describe(ctx context.Context, ppfmt pp.PP) (AccountInfo, bool) {
+	return t.handle.Describe(ctx, ppfmt)
+}
+
+func (t *transaction) FlushCache() int {
+	return t.handle.FlushCache()
+}
+
+// rollback reverts the changes in the reverse order. It keeps going after a failure.
+func (t *transaction) rollback(ctx context.Context, ppfmt pp.PP) bool {
+	t.mutex.Lock()
+	undos := t.undos
+	t.undos = nil
+	t.mutex.Unlock()
+
+	if len(undos) == 0 {
+		return true
+	}
+
+	ppfmt.Noticef(pp.EmojiNow, "Reverting %d change(s) made in the failed transaction", len(undos))
+	allOk := true
+	for i := len(undos) - 1; i >= 0; i-- {
+		if !undos[i](ctx, ppfmt) {
+			allOk = false
+		}
+	}
+	return allOk
+}
+
+// Transact calls fn with a Handle that remembers all the changes made through it. If fn returns false,
+// the changes are reverted in the reverse order: created records are deleted and updated records are
+// restored to their previous IP addresses. Deleted records cannot be restored. The result is always
+// that of fn; a failed rollback is only logged. Note that the rollback is not atomic either.
+func (h *CloudflareHandle) Transact(ctx context.Context, ppfmt pp.PP, fn func(Handle) bool) bool {
+	t := &transaction{handle: h, mutex: sync.Mutex{}, undos: nil}
+	if fn(t) {
+		return true
+	}
+
+	if !t.rollback(ctx, ppfmt) {
+		ppfmt.Warningf(pp.EmojiError, "Failed to revert all the changes; the DNS records might be inconsistent")
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// transactServer serves the A records of sub.test.org and remembers all the requests that change them.
+type transactServer struct {
+	mutex     sync.Mutex
+	requests  []string
+	deleteErr bool
+}
+
+func (s *transactServer) add(request string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, request)
+}
+
+func newTransactHandle(t *testing.T, s *transactServer) *api.CloudflareHandle {
+	t.Helper()
+
+	mux, h := newHandle(t)
+
+	zh := newZonesHandler(t, mux)
+	zh.set(map[string][]string{"test.org": {"active"}}, 2)
+
+	mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			switch r.Method {
+			case http.MethodGet:
+				err := json.NewEncoder(w).Encode(mockDNSListResponse(ipnet.IP4, "sub.test.org",
+					map[string]string{"record1": "1.1.1.1"}))
+				require.NoError(t, err)
+			case http.MethodPost:
+				var record cloudflare.DNSRecord
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+				s.add("create " + record.Content)
+				err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record2", ipnet.IP4, "sub.test.org", record.Content))
+				require.NoError(t, err)
+			default:
+				require.Fail(t, "unexpected method", r.Method)
+			}
+		})
+
+	for _, id := range []string{"record1", "record2"} {
+		id := id
+		mux.HandleFunc(fmt.Sprintf("/zones/%s/dns_records/%s", mockID("test.org", 0), id),
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPatch:
+					var record cloudflare.DNSRecord
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+					s.add("update " + id + " " + record.Content)
+				case http.MethodDelete:
+					s.add("delete " + id)
+					if s.deleteErr {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+				default:
+					require.Fail(t, "unexpected method", r.Method)
+				}
+				w.Header().Set("content-type", "application/json")
+				err := json.NewEncoder(w).Encode(mockDNSRecordResponse(id, ipnet.IP4, "sub.test.org", "1.1.1.1"))
+				require.NoError(t, err)
+			})
+	}
+
+	return h.(*api.CloudflareHandle) //nolint:forcetypeassert
+}
+
+func TestTransact(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		succeed          bool
+		deleteErr        bool
+		expectedRequests []string
+		prepareMockPP    func(*mocks.MockPP)
+	}{
+		"success": {
+			true, false,
+			[]string{"update record1 2.2.2.2", "create 3.3.3.3"},
+			nil,
+		},
+		"rollback": {
+			false, false,
+			[]string{"update record1 2.2.2.2", "create 3.3.3.3", "delete record2", "update record1 1.1.1.1"},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiNow, "Reverting %d change(s) made in the failed transaction", 2)
+			},
+		},
+		"rollback-failed": {
+			false, true,
+			[]string{"update record1 2.2.2.2", "create 3.3.3.3", "delete record2", "update record1 1.1.1.1"},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiNow, "Reverting %d change(s) made in the failed transaction", 2),
+					m.EXPECT().Warningf(pp.EmojiError, "Failed to delete a stale %s record of %q (ID: %s): %v",
+						"A", "sub.test.org", "record2", gomock.Any()),
+					m.EXPECT().Warningf(pp.EmojiError,
+						"Failed to revert all the changes; the DNS records might be inconsistent"),
+				)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			s := &transactServer{mutex: sync.Mutex{}, requests: nil, deleteErr: tc.deleteErr}
+			h := newTransactHandle(t, s)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ctx := context.Background()
+			d := domain.FQDN("sub.test.org")
+			ok := h.Transact(ctx, mockPP, func(tx api.Handle) bool {
+				require.True(t, tx.UpdateRecord(ctx, mockPP, d, ipnet.IP4, "record1", mustIP("2.2.2.2")))
+				id, ok := tx.CreateRecord(ctx, mockPP, d, ipnet.IP4, mustIP("3.3.3.3"), api.TTLAuto, false, "")
+				require.True(t, ok)
+				require.Equal(t, "record2", id)
+				return tc.succeed
+			})
+			require.Equal(t, tc.succeed, ok)
+			require.Equal(t, tc.expectedRequests, s.requests)
+		})
+	}
+}
+
+func TestTransactDeleteRecord(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	s := &transactServer{mutex: sync.Mutex{}, requests: nil, deleteErr: false}
+	h := newTransactHandle(t, s)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Noticef(pp.EmojiNow, "Reverting %d change(s) made in the failed transaction", 1),
+		mockPP.EXPECT().Warningf(pp.EmojiError,
+			"Failed to restore the %s record of %q (ID: %s): deleted records cannot be restored",
+			"A", "sub.test.org", "record1"),
+		mockPP.EXPECT().Warningf(pp.EmojiError,
+			"Failed to revert all the changes; the DNS records might be inconsistent"),
+	)
+
+	ctx := context.Background()
+	ok := h.Transact(ctx, mockPP, func(tx api.Handle) bool {
+		require.True(t, tx.DeleteRecord(ctx, mockPP, domain.FQDN("sub.test.org"), ipnet.IP4, "record1"))
+		return false
+	})
+	require.False(t, ok)
+	require.Equal(t, []string{"delete record1"}, s.requests)
+}
+
+func TestTransactHidesUnrecordedMethods(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	s := &transactServer{mutex: sync.Mutex{}, requests: nil, deleteErr: false}
+	h := newTransactHandle(t, s)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	ok := h.Transact(context.Background(), mockPP, func(tx api.Handle) bool {
+		_, isExtendedHandle := tx.(api.ExtendedHandle)
+		require.False(t, isExtendedHandle)
+		return true
+	})
+	require.True(t, ok)
+	require.Empty(t, s.requests)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// isTokenRejected checks whether Cloudflare refused the API token itself, which will not go away by itself.
+func isTokenRejected(err error) bool {
+	var (
+		authenticationErr *cloudflare.AuthenticationError
+		authorizationErr  *cloudflare.AuthorizationError
+	)
+	return errors.As(err, &authenticationErr) || errors.As(err, &authorizationErr)
+}
+
+// watchToken verifies the API token once. It returns false if the token is permanently invalid.
+func (h *CloudflareHandle) watchToken(ctx context.Context, ppfmt pp.PP) bool {
+	res, err := verifyAPIToken(ctx, h.cf, h.tokenVerifyPath)
+	switch {
+	case isTokenRejected(err):
+		ppfmt.Errorf(pp.EmojiUserError, "The Cloudflare API token is no longer valid: %v", err)
+		return false
+	case err != nil:
+		ppfmt.Warningf(pp.EmojiError, "The Cloudflare API token could not be verified: %v", err)
+	case res.Status != "active":
+		ppfmt.Warningf(pp.EmojiUserWarning, "The Cloudflare API token is %s; please check its status", res.Status)
+	default:
+		warnTokenExpiry(ppfmt, h.clock, res.ExpiresOn, h.tokenExpiryWarning)
+	}
+	return true
+}
+
+// StartTokenWatcher verifies the API token again every interval until ctx is done, so that a long-running
+// daemon notices when the token is disabled or revoked. Temporary problems and inactive tokens are reported
+// as warnings. If Cloudflare rejects the token outright, an error is reported and the returned channel
+// is closed, so that the caller can shut down cleanly. Nothing is watched for the legacy API key.
+func (h *CloudflareHandle) StartTokenWatcher(ctx context.Context, ppfmt pp.PP, interval time.Duration) <-chan struct{} {
+	invalid := make(chan struct{})
+	if h.usesAPIKey {
+		return invalid
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !h.watchToken(ctx, ppfmt) {
+					close(invalid)
+					return
+				}
+			}
+		}
+	}()
+
+	return invalid
+}
@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// handleTokensVerifyStatus responds to token verification with the status.
+func handleTokensVerifyStatus(t *testing.T, w http.ResponseWriter, status string) {
+	t.Helper()
+
+	w.Header().Set("content-type", "application/json")
+	fmt.Fprintf(w, `{"result":{"id":"%s","status":"%s"},"success":true,"errors":[],"messages":[]}`,
+		mockID("result", 0), status)
+}
+
+func TestStartTokenWatcher(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	mux, auth := newServerAuth(t)
+
+	// The token is revoked after it has been disabled for a while.
+	var accessCount atomic.Int64
+	mux.HandleFunc("/user/tokens/verify", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		switch accessCount.Add(1) {
+		case 1, 2:
+			handleTokensVerifyStatus(t, w, "active")
+		case 3:
+			handleTokensVerifyStatus(t, w, "disabled")
+		default:
+			w.Header().Set("content-type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"result":null,"success":false,"errors":[{"code":1000,"message":"Invalid API Token"}],"messages":[]}`)
+		}
+	})
+
+	h, ok := auth.New(context.Background(), mocks.NewMockPP(mockCtrl), time.Second, time.Second)
+	require.True(t, ok)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Warningf(pp.EmojiUserWarning, "The Cloudflare API token is %s; please check its status", "disabled"),
+		mockPP.EXPECT().Errorf(pp.EmojiUserError, "The Cloudflare API token is no longer valid: %v", gomock.Any()),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	invalid := h.(*api.CloudflareHandle).StartTokenWatcher(ctx, mockPP, time.Millisecond)
+
+	select {
+	case <-invalid:
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the revoked token was not noticed")
+	}
+	require.EqualValues(t, 4, accessCount.Load())
+}
+
+func TestStartTokenWatcherStop(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	_, h := newHandle(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	invalid := h.(*api.CloudflareHandle).StartTokenWatcher(ctx, mocks.NewMockPP(mockCtrl), time.Hour)
+
+	select {
+	case <-invalid:
+		require.Fail(t, "the token was considered invalid")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
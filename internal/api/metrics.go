@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"net/netip"
+	"sync/atomic"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// Metrics are the numbers of calls of each operation.
+type Metrics struct {
+	List               int64
+	Delete             int64
+	Update             int64
+	Create             int64
+	EnsureRecordExists int64
+	BatchUpdate        int64
+	Describe           int64
+	FlushCache         int64
+}
+
+// A MetricsHandle wraps another Handle and counts the calls of each operation.
+// Calls are counted whether they succeed or not. The wrapped Handle is not embedded
+// so that a new method of Handle cannot be left uncounted by accident.
+type MetricsHandle struct {
+	handle             Handle
+	list               atomic.Int64
+	delete             atomic.Int64
+	update             atomic.Int64
+	create             atomic.Int64
+	ensureRecordExists atomic.Int64
+	batchUpdate        atomic.Int64
+	describe           atomic.Int64
+	flushCache         atomic.Int64
+}
+
+var _ Handle = (*MetricsHandle)(nil)
+
+// NewMetricsHandle wraps a Handle to count the calls of its operations.
+func NewMetricsHandle(h Handle) *MetricsHandle {
+	return &MetricsHandle{ //nolint:exhaustruct // The zero counters are ready to use
+		handle: h,
+	}
+}
+
+func (m *MetricsHandle) ListRecords(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type,
+) (map[string]netip.Addr, bool) {
+	m.list.Add(1)
+	return m.handle.ListRecords(ctx, ppfmt, domain, ipNet)
+}
+
+func (m *MetricsHandle) DeleteRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string,
+) bool {
+	m.delete.Add(1)
+	return m.handle.DeleteRecord(ctx, ppfmt, domain, ipNet, id)
+}
+
+func (m *MetricsHandle) UpdateRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string, ip netip.Addr,
+) bool {
+	m.update.Add(1)
+	return m.handle.UpdateRecord(ctx, ppfmt, domain, ipNet, id, ip)
+}
+
+func (m *MetricsHandle) CreateRecord(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl TTL, proxied bool, comment string,
+) (string, bool) {
+	m.create.Add(1)
+	return m.handle.CreateRecord(ctx, ppfmt, domain, ipNet, ip, ttl, proxied, comment)
+}
+
+func (m *MetricsHandle) EnsureRecordExists(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl TTL, proxied bool,
+) (string, bool, bool) {
+	m.ensureRecordExists.Add(1)
+	return m.handle.EnsureRecordExists(ctx, ppfmt, domain, ipNet, ip, ttl, proxied)
+}
+
+func (m *MetricsHandle) BatchUpdate(ctx context.Context, ppfmt pp.PP,
+	updates []RecordUpdate,
+) ([]RecordUpdateResult, bool) {
+	m.batchUpdate.Add(1)
+	return m.handle.BatchUpdate(ctx, ppfmt, updates)
+}
+
+func (m *MetricsHandle) Describe(ctx context.Context, ppfmt pp.PP) (AccountInfo, bool) {
+	m.describe.Add(1)
+	return m.handle.Describe(ctx, ppfmt)
+}
+
+func (m *MetricsHandle) FlushCache() int {
+	m.flushCache.Add(1)
+	return m.handle.FlushCache()
+}
+
+// Snapshot returns the current numbers of calls.
+func (m *MetricsHandle) Snapshot() Metrics {
+	return Metrics{
+		List:               m.list.Load(),
+		Delete:             m.delete.Load(),
+		Update:             m.update.Load(),
+		Create:             m.create.Load(),
+		EnsureRecordExists: m.ensureRecordExists.Load(),
+		BatchUpdate:        m.batchUpdate.Load(),
+		Describe:           m.describe.Load(),
+		FlushCache:         m.flushCache.Load(),
+	}
+}
+
+// Reset sets all the numbers of calls to zero.
+func (m *MetricsHandle) Reset() {
+	m.list.Store(0)
+	m.delete.Store(0)
+	m.update.Store(0)
+	m.create.Store(0)
+	m.ensureRecordExists.Store(0)
+	m.batchUpdate.Store(0)
+	m.describe.Store(0)
+	m.flushCache.Store(0)
+}
@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+)
+
+//nolint:funlen
+func TestMetricsHandle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	d := domain.FQDN("sub.test.org")
+	ip := netip.MustParseAddr("::1")
+
+	for name, tc := range map[string]struct {
+		call     func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle)
+		expected api.Metrics
+	}{
+		"list": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().ListRecords(ctx, ppfmt, d, ipnet.IP6).Return(nil, false)
+				_, _ = h.ListRecords(ctx, ppfmt, d, ipnet.IP6)
+			},
+			api.Metrics{
+				List: 1, Delete: 0, Update: 0, Create: 0, EnsureRecordExists: 0,
+				BatchUpdate: 0, Describe: 0, FlushCache: 0,
+			},
+		},
+		"delete": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().DeleteRecord(ctx, ppfmt, d, ipnet.IP6, "record1").Return(true)
+				_ = h.DeleteRecord(ctx, ppfmt, d, ipnet.IP6, "record1")
+			},
+			api.Metrics{
+				List: 0, Delete: 1, Update: 0, Create: 0, EnsureRecordExists: 0,
+				BatchUpdate: 0, Describe: 0, FlushCache: 0,
+			},
+		},
+		"update": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().UpdateRecord(ctx, ppfmt, d, ipnet.IP6, "record1", ip).Return(true).Times(2)
+				_ = h.UpdateRecord(ctx, ppfmt, d, ipnet.IP6, "record1", ip)
+				_ = h.UpdateRecord(ctx, ppfmt, d, ipnet.IP6, "record1", ip)
+			},
+			api.Metrics{
+				List: 0, Delete: 0, Update: 2, Create: 0, EnsureRecordExists: 0,
+				BatchUpdate: 0, Describe: 0, FlushCache: 0,
+			},
+		},
+		"create": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().CreateRecord(ctx, ppfmt, d, ipnet.IP6, ip, api.TTLAuto, false, "").Return("record1", true)
+				_, _ = h.CreateRecord(ctx, ppfmt, d, ipnet.IP6, ip, api.TTLAuto, false, "")
+			},
+			api.Metrics{
+				List: 0, Delete: 0, Update: 0, Create: 1, EnsureRecordExists: 0,
+				BatchUpdate: 0, Describe: 0, FlushCache: 0,
+			},
+		},
+		"ensure": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().EnsureRecordExists(ctx, ppfmt, d, ipnet.IP6, ip, api.TTLAuto, false).Return("record1", true, true)
+				_, _, _ = h.EnsureRecordExists(ctx, ppfmt, d, ipnet.IP6, ip, api.TTLAuto, false)
+			},
+			api.Metrics{
+				List: 0, Delete: 0, Update: 0, Create: 0, EnsureRecordExists: 1,
+				BatchUpdate: 0, Describe: 0, FlushCache: 0,
+			},
+		},
+		"batch-update": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().BatchUpdate(ctx, ppfmt, nil).Return(nil, true)
+				_, _ = h.BatchUpdate(ctx, ppfmt, nil)
+			},
+			api.Metrics{
+				List: 0, Delete: 0, Update: 0, Create: 0, EnsureRecordExists: 0,
+				BatchUpdate: 1, Describe: 0, FlushCache: 0,
+			},
+		},
+		"describe": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().Describe(ctx, ppfmt).Return(api.AccountInfo{}, false) //nolint:exhaustruct
+				_, _ = h.Describe(ctx, ppfmt)
+			},
+			api.Metrics{
+				List: 0, Delete: 0, Update: 0, Create: 0, EnsureRecordExists: 0,
+				BatchUpdate: 0, Describe: 1, FlushCache: 0,
+			},
+		},
+		"flush-cache": {
+			func(ppfmt *mocks.MockPP, m *mocks.MockHandle, h api.Handle) {
+				m.EXPECT().FlushCache().Return(3)
+				_ = h.FlushCache()
+			},
+			api.Metrics{
+				List: 0, Delete: 0, Update: 0, Create: 0, EnsureRecordExists: 0,
+				BatchUpdate: 0, Describe: 0, FlushCache: 1,
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			h := api.NewMetricsHandle(mockHandle)
+
+			tc.call(mockPP, mockHandle, h)
+			require.Equal(t, tc.expected, h.Snapshot())
+
+			h.Reset()
+			require.Equal(t, api.Metrics{}, h.Snapshot()) //nolint:exhaustruct
+		})
+	}
+}
+
+// TestMetricsHandleCounters makes sure that each method of Handle has its own counter.
+// It fails when a new method is added to Handle without updating Metrics.
+func TestMetricsHandleCounters(t *testing.T) {
+	t.Parallel()
+
+	methods := reflect.TypeOf((*api.Handle)(nil)).Elem()
+	metrics := reflect.TypeOf(api.Metrics{}) //nolint:exhaustruct
+	require.Equal(t, methods.NumMethod(), metrics.NumField())
+}
@@ -4,12 +4,37 @@ import "strconv"
 
 type TTL int
 
-const TTLAuto TTL = 1
+// TTLAuto means the automatic TTL chosen by Cloudflare. Proxied records always use the automatic TTL,
+// no matter what TTL is set. Other records accept any TTL from 60 (30 for Enterprise zones) to 86400
+// seconds, and the following presets are the values offered by the Cloudflare dashboard.
+const (
+	TTLAuto   TTL = 1
+	TTL2Min   TTL = 120
+	TTL5Min   TTL = 300
+	TTL10Min  TTL = 600
+	TTL15Min  TTL = 900
+	TTL30Min  TTL = 1800
+	TTL1Hour  TTL = 3600
+	TTL2Hour  TTL = 7200
+	TTL5Hour  TTL = 18000
+	TTL12Hour TTL = 43200
+	TTL1Day   TTL = 86400
+)
 
 func (t TTL) Int() int {
 	return int(t)
 }
 
+// IsPreset checks whether the TTL is one of the preset values (including TTLAuto).
+func (t TTL) IsPreset() bool {
+	switch t {
+	case TTLAuto, TTL2Min, TTL5Min, TTL10Min, TTL15Min, TTL30Min, TTL1Hour, TTL2Hour, TTL5Hour, TTL12Hour, TTL1Day:
+		return true
+	default:
+		return false
+	}
+}
+
 func (t TTL) String() string {
 	return strconv.Itoa(t.Int())
 }
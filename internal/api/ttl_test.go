@@ -63,3 +63,31 @@ func TestTTLInt(t *testing.T) {
 		})
 	}
 }
+
+func TestTTLPresets(t *testing.T) {
+	t.Parallel()
+	// The values are from https://developers.cloudflare.com/dns/manage-dns-records/reference/ttl/
+	for ttl, seconds := range map[api.TTL]int{
+		api.TTLAuto:   1,
+		api.TTL2Min:   2 * 60,
+		api.TTL5Min:   5 * 60,
+		api.TTL10Min:  10 * 60,
+		api.TTL15Min:  15 * 60,
+		api.TTL30Min:  30 * 60,
+		api.TTL1Hour:  60 * 60,
+		api.TTL2Hour:  2 * 60 * 60,
+		api.TTL5Hour:  5 * 60 * 60,
+		api.TTL12Hour: 12 * 60 * 60,
+		api.TTL1Day:   24 * 60 * 60,
+	} {
+		require.Equal(t, seconds, ttl.Int())
+		require.True(t, ttl.IsPreset())
+	}
+}
+
+func TestTTLIsPreset(t *testing.T) {
+	t.Parallel()
+	for _, i := range [...]int{0, 2, 30, 60, 293, 842, 37284789} {
+		require.False(t, api.TTL(i).IsPreset())
+	}
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A zoneFileRecord is an A or AAAA record found in a zone file.
+type zoneFileRecord struct {
+	domain domain.Domain
+	ipNet  ipnet.Type
+	ip     netip.Addr
+	ttl    TTL
+}
+
+// zoneFileParser keeps the state (the origin, the default TTL, and the last owner) of parsing a zone file.
+type zoneFileParser struct {
+	origin     string
+	defaultTTL TTL
+	lastOwner  string
+}
+
+var (
+	errZoneFileNoOwner      = errors.New("the owner name is missing")
+	errZoneFileNoType       = errors.New("the record type is missing")
+	errZoneFileParentheses  = errors.New("unbalanced parentheses")
+	errZoneFileRData        = errors.New("expected exactly one IP address")
+	errZoneFileUnsupported  = errors.New("unsupported directive")
+	errZoneFileBadDirective = errors.New("the directive needs exactly one argument")
+)
+
+// stripComment removes the comment (starting with ';') in a line.
+// Quoted strings are not supported because only A and AAAA records are relevant.
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, ';'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// absoluteName resolves a name in the zone file relative to the current origin.
+func (p *zoneFileParser) absoluteName(name string) string {
+	switch {
+	case name == "@":
+		return p.origin
+	case strings.HasSuffix(name, "."):
+		return name
+	default:
+		return name + "." + p.origin
+	}
+}
+
+func parseTTL(s string) (TTL, bool) {
+	ttl, err := strconv.ParseUint(s, 10, 31)
+	if err != nil {
+		return 0, false
+	}
+	return TTL(ttl), true
+}
+
+func isClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CS", "CH", "HS":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseDirective handles $ORIGIN and $TTL.
+func (p *zoneFileParser) parseDirective(fields []string) error {
+	directive := strings.ToUpper(fields[0])
+	if directive != "$ORIGIN" && directive != "$TTL" {
+		return fmt.Errorf("%w %s", errZoneFileUnsupported, fields[0])
+	}
+	if len(fields) != 2 { //nolint:gomnd
+		return fmt.Errorf("%w: %s", errZoneFileBadDirective, fields[0])
+	}
+
+	switch directive {
+	case "$ORIGIN":
+		p.origin = p.absoluteName(fields[1])
+	case "$TTL":
+		ttl, ok := parseTTL(fields[1])
+		if !ok {
+			return fmt.Errorf("invalid TTL %q", fields[1])
+		}
+		p.defaultTTL = ttl
+	}
+	return nil
+}
+
+// parseRecord parses one (logical) line of resource records. A nil record is returned
+// for records that are not A or AAAA.
+func (p *zoneFileParser) parseRecord(fields []string, inheritOwner bool) (*zoneFileRecord, error) {
+	owner := p.lastOwner
+	if !inheritOwner {
+		owner = p.absoluteName(fields[0])
+		fields = fields[1:]
+	}
+	if owner == "" {
+		return nil, errZoneFileNoOwner
+	}
+	p.lastOwner = owner
+
+	// The TTL and the class can appear in any order before the type.
+	ttl := p.defaultTTL
+	for i := 0; i < 2 && len(fields) > 0; i++ {
+		if t, ok := parseTTL(fields[0]); ok {
+			ttl = t
+			fields = fields[1:]
+		} else if isClass(fields[0]) {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errZoneFileNoType
+	}
+
+	var ipNet ipnet.Type
+	switch strings.ToUpper(fields[0]) {
+	case "A":
+		ipNet = ipnet.IP4
+	case "AAAA":
+		ipNet = ipnet.IP6
+	default:
+		return nil, nil //nolint:nilnil // other records are ignored
+	}
+
+	if len(fields) != 2 { //nolint:gomnd
+		return nil, errZoneFileRData
+	}
+
+	ip, err := netip.ParseAddr(fields[1])
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if (ipNet == ipnet.IP4 && !ip.Is4()) || (ipNet == ipnet.IP6 && !ip.Is6()) {
+		return nil, fmt.Errorf("%q is not a valid %s address", fields[1], ipNet.Describe())
+	}
+
+	d, err := domain.New(owner)
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain %q: %w", owner, err)
+	}
+
+	return &zoneFileRecord{domain: d, ipNet: ipNet, ip: ip, ttl: ttl}, nil
+}
+
+// parseZoneFile parses the A and AAAA records in an RFC 1035 zone file. Each ill-formed line is reported.
+// The directives $INCLUDE and $GENERATE are not supported.
+func parseZoneFile(ppfmt pp.PP, zoneName string, content string) ([]zoneFileRecord, bool) {
+	p := zoneFileParser{
+		origin:     domain.FQDN(zoneName).DNSName(),
+		defaultTTL: TTLAuto,
+		lastOwner:  "",
+	}
+
+	var (
+		records []zoneFileRecord
+		ok      = true
+	)
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := stripComment(lines[i])
+		inheritOwner := line != "" && (line[0] == ' ' || line[0] == '\t')
+
+		// Join lines within parentheses into one logical line.
+		depth := strings.Count(line, "(") - strings.Count(line, ")")
+		for depth > 0 && i+1 < len(lines) {
+			i++
+			next := stripComment(lines[i])
+			depth += strings.Count(next, "(") - strings.Count(next, ")")
+			line += " " + next
+		}
+		if depth != 0 {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", lineNum, errZoneFileParentheses)
+			ok = false
+			continue
+		}
+
+		fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(fields[0], "$") {
+			if err := p.parseDirective(fields); err != nil {
+				ppfmt.Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", lineNum, err)
+				ok = false
+			}
+			continue
+		}
+
+		record, err := p.parseRecord(fields, inheritOwner)
+		if err != nil {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse line %d of the zone file: %v", lineNum, err)
+			ok = false
+			continue
+		}
+		if record != nil {
+			records = append(records, *record)
+		}
+	}
+
+	return records, ok
+}
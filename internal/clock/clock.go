@@ -0,0 +1,72 @@
+// Package clock abstracts away the system clock so that time-dependent code can be tested deterministically.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// A Clock tells the current time and waits.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep waits for the duration.
+	Sleep(d time.Duration)
+	// After returns a channel that receives the time once the duration has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the system clock.
+type Real struct{}
+
+// Now calls time.Now.
+func (Real) Now() time.Time { return time.Now() }
+
+// Sleep calls time.Sleep.
+func (Real) Sleep(d time.Duration) { time.Sleep(d) }
+
+// After calls time.After.
+func (Real) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Mock is a clock that only moves when it is advanced or when Sleep is called.
+type Mock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+// NewMock creates a Mock starting at the time.
+func NewMock(now time.Time) *Mock {
+	return &Mock{mutex: sync.Mutex{}, now: now}
+}
+
+// Now returns the current time of the mock clock.
+func (m *Mock) Now() time.Time {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.now
+}
+
+// Sleep advances the mock clock immediately instead of waiting.
+func (m *Mock) Sleep(d time.Duration) { m.Advance(d) }
+
+// After advances the mock clock immediately and returns a channel that already holds the new time.
+func (m *Mock) After(d time.Duration) <-chan time.Time {
+	m.Advance(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- m.Now()
+	return ch
+}
+
+// Advance moves the mock clock forward. Negative durations are ignored.
+func (m *Mock) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.now = m.now.Add(d)
+}
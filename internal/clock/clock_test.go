@@ -0,0 +1,42 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+)
+
+func TestReal(t *testing.T) {
+	t.Parallel()
+
+	var c clock.Clock = clock.Real{}
+	before := time.Now()
+	c.Sleep(time.Millisecond)
+	require.True(t, c.Now().After(before))
+
+	fired := <-c.After(time.Millisecond)
+	require.True(t, fired.After(before))
+}
+
+func TestMock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+	m := clock.NewMock(start)
+	require.Equal(t, start, m.Now())
+
+	m.Advance(time.Hour)
+	require.Equal(t, start.Add(time.Hour), m.Now())
+
+	m.Sleep(time.Minute)
+	require.Equal(t, start.Add(time.Hour+time.Minute), m.Now())
+
+	m.Advance(-time.Hour)
+	require.Equal(t, start.Add(time.Hour+time.Minute), m.Now())
+
+	require.Equal(t, start.Add(time.Hour+2*time.Minute), <-m.After(time.Minute))
+	require.Equal(t, start.Add(time.Hour+2*time.Minute), m.Now())
+}
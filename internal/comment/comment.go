@@ -0,0 +1,45 @@
+// Package comment renders the comments of DNS records from templates.
+package comment
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Names of the variables in comment templates.
+const (
+	Hostname  = "hostname"
+	Timestamp = "timestamp"
+	Domain    = "domain"
+	IPNet     = "ipnet"
+)
+
+// ErrUnknownVariable means a template uses an unsupported variable.
+var ErrUnknownVariable = fmt.Errorf("unknown variable")
+
+//nolint:gochecknoglobals
+var (
+	variablePattern = regexp.MustCompile(`\{([^{}]*)\}`)
+	knownVariables  = map[string]bool{Hostname: true, Timestamp: true, Domain: true, IPNet: true}
+)
+
+// Validate checks that a template only uses the variables {hostname}, {timestamp}, {domain}, and {ipnet}.
+func Validate(template string) error {
+	for _, match := range variablePattern.FindAllStringSubmatch(template, -1) {
+		if !knownVariables[match[1]] {
+			return fmt.Errorf("%w {%s}", ErrUnknownVariable, match[1])
+		}
+	}
+
+	return nil
+}
+
+// Render substitutes the variables in a template. Variables without values are kept as they are.
+func Render(template string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(template, func(variable string) string {
+		if val, ok := vars[variable[1:len(variable)-1]]; ok {
+			return val
+		}
+		return variable
+	})
+}
@@ -0,0 +1,80 @@
+package comment_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/comment"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	vars := map[string]string{
+		comment.Hostname:  "myhost",
+		comment.Timestamp: "2022-11-05T12:00:00Z",
+		comment.Domain:    "sub.example.org",
+		comment.IPNet:     "IPv6",
+	}
+
+	for name, tc := range map[string]struct {
+		template string
+		expected string
+	}{
+		"empty":     {"", ""},
+		"plain":     {"managed by cloudflare-ddns", "managed by cloudflare-ddns"},
+		"hostname":  {"{hostname}", "myhost"},
+		"timestamp": {"at {timestamp}", "at 2022-11-05T12:00:00Z"},
+		"domain":    {"for {domain}", "for sub.example.org"},
+		"ipnet":     {"{ipnet} record", "IPv6 record"},
+		"multiple": {
+			"managed by cloudflare-ddns on {hostname} at {timestamp}",
+			"managed by cloudflare-ddns on myhost at 2022-11-05T12:00:00Z",
+		},
+		"repeated": {"{domain}/{domain}", "sub.example.org/sub.example.org"},
+		"unknown":  {"{weird}", "{weird}"},
+		"unclosed": {"{hostname", "{hostname"},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, comment.Render(tc.template, vars))
+		})
+	}
+}
+
+func TestRenderMissing(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "on {hostname} for a.org",
+		comment.Render("on {hostname} for {domain}", map[string]string{comment.Domain: "a.org"}))
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		template string
+		ok       bool
+	}{
+		"empty":    {"", true},
+		"plain":    {"managed by cloudflare-ddns", true},
+		"all":      {"{hostname} {timestamp} {domain} {ipnet}", true},
+		"unknown":  {"on {host}", false},
+		"empty-id": {"{}", false},
+		"case":     {"{Hostname}", false},
+		"mixed":    {"{hostname} {ip}", false},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := comment.Validate(tc.template)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, comment.ErrUnknownVariable)
+			}
+		})
+	}
+}
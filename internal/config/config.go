@@ -1,11 +1,14 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/netip"
 	"strings"
 	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/clock"
 	"github.com/favonia/cloudflare-ddns/internal/cron"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/domainexp"
@@ -17,19 +20,24 @@ import (
 )
 
 type Config struct {
-	Auth             api.Auth
-	Provider         map[ipnet.Type]provider.Provider
-	Domains          map[ipnet.Type][]domain.Domain
-	UpdateCron       cron.Schedule
-	UpdateOnStart    bool
-	DeleteOnStop     bool
-	CacheExpiration  time.Duration
-	TTL              api.TTL
-	ProxiedTemplate  string
-	Proxied          map[domain.Domain]bool
-	DetectionTimeout time.Duration
-	UpdateTimeout    time.Duration
-	Monitors         []monitor.Monitor
+	Auth                  api.Auth
+	Provider              map[ipnet.Type]provider.Provider
+	Domains               map[ipnet.Type][]domain.Domain
+	IP6PrefixLength       int
+	IP6HostSuffix         netip.Addr
+	UpdateCron            cron.Schedule
+	UpdateOnStart         bool
+	DeleteOnStop          bool
+	CacheExpiration       time.Duration
+	TTL                   api.TTL
+	ProxiedTemplate       string
+	Proxied               map[ipnet.Type]map[domain.Domain]bool
+	DomainInterval        map[domain.Domain]time.Duration
+	RecordCommentTemplate string
+	MaxRecordsPerDomain   int
+	DetectionTimeout      time.Duration
+	UpdateTimeout         time.Duration
+	Monitors              []monitor.Monitor
 }
 
 // Default gives default values.
@@ -44,16 +52,21 @@ func Default() *Config {
 			ipnet.IP4: nil,
 			ipnet.IP6: nil,
 		},
-		UpdateCron:       cron.MustNew("@every 5m"),
-		UpdateOnStart:    true,
-		DeleteOnStop:     false,
-		CacheExpiration:  time.Hour * 6, //nolint:gomnd
-		TTL:              api.TTLAuto,
-		ProxiedTemplate:  "false",
-		Proxied:          map[domain.Domain]bool{},
-		UpdateTimeout:    time.Second * 30, //nolint:gomnd
-		DetectionTimeout: time.Second * 5,  //nolint:gomnd
-		Monitors:         nil,
+		IP6PrefixLength:       0,
+		IP6HostSuffix:         netip.Addr{},
+		UpdateCron:            cron.MustNew("@every 5m"),
+		UpdateOnStart:         true,
+		DeleteOnStop:          false,
+		CacheExpiration:       time.Hour * 6, //nolint:gomnd
+		TTL:                   api.TTLAuto,
+		ProxiedTemplate:       "false",
+		Proxied:               map[ipnet.Type]map[domain.Domain]bool{},
+		DomainInterval:        map[domain.Domain]time.Duration{},
+		RecordCommentTemplate: "",
+		MaxRecordsPerDomain:   1,
+		UpdateTimeout:         time.Second * 30, //nolint:gomnd
+		DetectionTimeout:      time.Second * 5,  //nolint:gomnd
+		Monitors:              nil,
 	}
 }
 
@@ -101,14 +114,65 @@ func ReadAuth(ppfmt pp.PP, field *api.Auth) bool {
 
 	accountID := Getenv("CF_ACCOUNT_ID")
 
-	*field = &api.CloudflareAuth{Token: token, AccountID: accountID, BaseURL: ""}
+	tokenExpiryWarning := api.DefaultTokenExpiryWarning
+	if !ReadNonnegDuration(ppfmt, "CF_API_TOKEN_EXPIRY_WARNING", &tokenExpiryWarning) {
+		return false
+	}
+
+	rejectCloudflareIPs := false
+	if !ReadBool(ppfmt, "REJECT_CLOUDFLARE_IPS", &rejectCloudflareIPs) {
+		return false
+	}
+
+	startupTimeout := time.Duration(0)
+	if !ReadNonnegDuration(ppfmt, "STARTUP_TIMEOUT", &startupTimeout) {
+		return false
+	}
+
+	maxRetries := 0
+	if !ReadNonnegInt(ppfmt, "CF_API_MAX_RETRIES", &maxRetries) {
+		return false
+	}
+
+	safeRetry := false
+	if !ReadBool(ppfmt, "CF_API_SAFE_RETRY", &safeRetry) {
+		return false
+	}
+
+	*field = &api.CloudflareAuth{
+		Token:               token,
+		AccountID:           accountID,
+		BaseURL:             "",
+		TokenExpiryWarning:  tokenExpiryWarning,
+		RejectCloudflareIPs: rejectCloudflareIPs,
+		ClientCert:          tls.Certificate{},
+		ClientCA:            nil,
+		ProxyURL:            "",
+		Clock:               clock.Real{},
+		TransportConfig:     api.TransportConfig{},
+		UseBatchAPI:         false,
+		Retry: api.RetryConfig{
+			MaxRetries: maxRetries,
+			MinDelay:   api.DefaultRetryMinDelay,
+			MaxDelay:   api.DefaultRetryMaxDelay,
+			SafeRetry:  safeRetry,
+		},
+		TokenVerifyPath:      api.DefaultTokenVerifyPath,
+		StartupTimeout:       startupTimeout,
+		StartupRetryInterval: 0,
+		Resolver:             nil,
+		PropagationInterval:  0,
+		AccessClientID:       "",
+		AccessClientSecret:   "",
+		RateLimitWarning:     api.DefaultRateLimitWarning,
+	}
 	return true
 }
 
 // deduplicate always sorts and deduplicates the input list,
 // returning true if elements are already distinct.
 func deduplicate(list []domain.Domain) []domain.Domain {
-	domain.SortDomains(list)
+	domain.Sort(list)
 
 	if len(list) == 0 {
 		return list
@@ -178,6 +242,20 @@ func describeDomains(domains []domain.Domain) string {
 	return strings.Join(descriptions, ", ")
 }
 
+func describeDomainIntervals(intervals map[domain.Domain]time.Duration) string {
+	domains := make([]domain.Domain, 0, len(intervals))
+	for dom := range intervals {
+		domains = append(domains, dom)
+	}
+	domain.Sort(domains)
+
+	descriptions := make([]string, 0, len(domains))
+	for _, dom := range domains {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%v)", dom.Describe(), intervals[dom]))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
 func getInverseMap[V comparable](m map[domain.Domain]V) ([]V, map[V][]domain.Domain) {
 	inverse := map[V][]domain.Domain{}
 
@@ -187,7 +265,7 @@ func getInverseMap[V comparable](m map[domain.Domain]V) ([]V, map[V][]domain.Dom
 
 	vals := make([]V, 0, len(inverse))
 	for val := range inverse {
-		domain.SortDomains(inverse[val])
+		domain.Sort(inverse[val])
 		vals = append(vals, val)
 	}
 
@@ -218,6 +296,12 @@ func (c *Config) Print(ppfmt pp.PP) {
 	item("IPv6 provider:", "%s", provider.Name(c.Provider[ipnet.IP6]))
 	if c.Provider[ipnet.IP6] != nil {
 		item("IPv6 domains:", "%s", describeDomains(c.Domains[ipnet.IP6]))
+		if c.IP6PrefixLength > 0 {
+			item("IPv6 prefix length:", "%d", c.IP6PrefixLength)
+			if c.IP6HostSuffix.IsValid() {
+				item("IPv6 host suffix:", "%v", c.IP6HostSuffix)
+			}
+		}
 	}
 
 	section("Scheduling:")
@@ -226,15 +310,26 @@ func (c *Config) Print(ppfmt pp.PP) {
 	item("Update on start?", "%t", c.UpdateOnStart)
 	item("Delete on stop?", "%t", c.DeleteOnStop)
 	item("Cache expiration:", "%v", c.CacheExpiration)
+	if len(c.DomainInterval) > 0 {
+		item("Domain intervals:", "%s", describeDomainIntervals(c.DomainInterval))
+	}
 
 	section("New DNS records:")
 	item("TTL:", "%s", c.TTL.Describe())
-	if len(c.Proxied) > 0 {
-		_, inverseMap := getInverseMap(c.Proxied)
-		item("Proxied domains:", "%s", describeDomains(inverseMap[true]))
-		item("Unproxied domains:", "%s", describeDomains(inverseMap[false]))
+	for _, ipNet := range ipnet.All() {
+		if len(c.Proxied[ipNet]) > 0 {
+			_, inverseMap := getInverseMap(c.Proxied[ipNet])
+			item(ipNet.Describe()+" proxied domains:", "%s", describeDomains(inverseMap[true]))
+			item(ipNet.Describe()+" unproxied domains:", "%s", describeDomains(inverseMap[false]))
+		}
+	}
+	if c.RecordCommentTemplate != "" {
+		item("Comment template:", "%s", c.RecordCommentTemplate)
 	}
 
+	section("Existing DNS records:")
+	item("Max records per domain:", "%d", c.MaxRecordsPerDomain)
+
 	section("Timeouts:")
 	item("IP detection:", "%v", c.DetectionTimeout)
 	item("Record updating:", "%v", c.UpdateTimeout)
@@ -256,12 +351,17 @@ func (c *Config) ReadEnv(ppfmt pp.PP) bool {
 	if !ReadAuth(ppfmt, &c.Auth) ||
 		!ReadProviderMap(ppfmt, &c.Provider) ||
 		!ReadDomainMap(ppfmt, &c.Domains) ||
+		!ReadIP6PrefixLength(ppfmt, "IP6_PREFIX_LENGTH", &c.IP6PrefixLength) ||
+		!ReadIP6(ppfmt, "IP6_HOST_SUFFIX", &c.IP6HostSuffix) ||
 		!ReadCron(ppfmt, "UPDATE_CRON", &c.UpdateCron) ||
 		!ReadBool(ppfmt, "UPDATE_ON_START", &c.UpdateOnStart) ||
 		!ReadBool(ppfmt, "DELETE_ON_STOP", &c.DeleteOnStop) ||
 		!ReadNonnegDuration(ppfmt, "CACHE_EXPIRATION", &c.CacheExpiration) ||
+		!ReadDomainIntervals(ppfmt, "DOMAIN_INTERVALS", &c.DomainInterval) ||
 		!ReadTTL(ppfmt, "TTL", &c.TTL) ||
 		!ReadString(ppfmt, "PROXIED", &c.ProxiedTemplate) ||
+		!ReadCommentTemplate(ppfmt, "RECORD_COMMENT", &c.RecordCommentTemplate) ||
+		!ReadNonnegInt(ppfmt, "MAX_RECORDS_PER_DOMAIN", &c.MaxRecordsPerDomain) ||
 		!ReadNonnegDuration(ppfmt, "DETECTION_TIMEOUT", &c.DetectionTimeout) ||
 		!ReadNonnegDuration(ppfmt, "UPDATE_TIMEOUT", &c.UpdateTimeout) ||
 		!ReadHealthChecksURL(ppfmt, "HEALTHCHECKS", &c.Monitors) {
@@ -278,7 +378,7 @@ func (c *Config) ReadEnv(ppfmt pp.PP) bool {
 func (c *Config) NormalizeDomains(ppfmt pp.PP) bool {
 	// New maps
 	providerMap := map[ipnet.Type]provider.Provider{}
-	proxiedMap := map[domain.Domain]bool{}
+	proxiedMap := map[ipnet.Type]map[domain.Domain]bool{}
 	activeDomainSet := map[domain.Domain]bool{}
 
 	if ppfmt.IsEnabledFor(pp.Info) {
@@ -338,8 +438,8 @@ func (c *Config) NormalizeDomains(ppfmt pp.PP) bool {
 	if !ok {
 		return false
 	}
-	for dom := range activeDomainSet {
-		proxiedMap[dom] = proxiedPred(dom)
+	for ipNet := range providerMap {
+		proxiedMap[ipNet] = proxiedPred.MatchBatch(c.Domains[ipNet], ipNet)
 	}
 
 	c.Provider = providerMap
@@ -1,12 +1,14 @@
 package config
 
 import (
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/comment"
 	"github.com/favonia/cloudflare-ddns/internal/cron"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/domainexp"
@@ -31,6 +33,23 @@ func ReadString(ppfmt pp.PP, key string, field *string) bool {
 	return true
 }
 
+// ReadCommentTemplate reads an environment variable as a template of record comments.
+func ReadCommentTemplate(ppfmt pp.PP, key string, field *string) bool {
+	val := Getenv(key)
+	if val == "" {
+		ppfmt.Infof(pp.EmojiBullet, "Use default %s=%s", key, *field)
+		return true
+	}
+
+	if err := comment.Validate(val); err != nil {
+		ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: %v", val, err)
+		return false
+	}
+
+	*field = val
+	return true
+}
+
 // ReadQuiet reads an environment variable as quiet/verbose.
 func ReadQuiet(key string, ppfmt *pp.PP) bool {
 	val := Getenv(key)
@@ -53,6 +72,26 @@ func ReadQuiet(key string, ppfmt *pp.PP) bool {
 	return true
 }
 
+// ReadEmoji reads an environment variable as whether to use emoji in the output.
+func ReadEmoji(key string, ppfmt *pp.PP) bool {
+	val := Getenv(key)
+	if val == "" {
+		return true
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		(*ppfmt).Errorf(pp.EmojiUserError, "Failed to parse %q: %v", val, err)
+		return false
+	}
+
+	if !b {
+		*ppfmt = pp.NewNoEmojiPP(*ppfmt)
+	}
+
+	return true
+}
+
 // ReadBool reads an environment variable as a boolean value.
 func ReadBool(ppfmt pp.PP, key string, field *bool) bool {
 	val := Getenv(key)
@@ -123,6 +162,56 @@ func ReadTTL(ppfmt pp.PP, key string, field *api.TTL) bool {
 	return true
 }
 
+// ReadIP6PrefixLength reads the length of an IPv6 prefix, which should be between 0 and 128.
+func ReadIP6PrefixLength(ppfmt pp.PP, key string, field *int) bool {
+	val := Getenv(key)
+	if val == "" {
+		ppfmt.Infof(pp.EmojiBullet, "Use default %s=%d", key, *field)
+		return true
+	}
+
+	res, err := strconv.Atoi(val)
+	switch {
+	case err != nil:
+		ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: %v", val, err)
+		return false
+
+	case res < 0 || res > 128:
+		ppfmt.Errorf(pp.EmojiUserError, "The IPv6 prefix length (%d) should be between 0 and 128", res)
+		return false
+	}
+
+	*field = res
+	return true
+}
+
+// ReadIP6 reads an IPv6 address.
+func ReadIP6(ppfmt pp.PP, key string, field *netip.Addr) bool {
+	val := Getenv(key)
+	if val == "" {
+		if field.IsValid() {
+			ppfmt.Infof(pp.EmojiBullet, "Use default %s=%v", key, *field)
+		} else {
+			ppfmt.Infof(pp.EmojiBullet, "Use default %s=%s", key, "")
+		}
+		return true
+	}
+
+	ip, err := netip.ParseAddr(val)
+	switch {
+	case err != nil:
+		ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: %v", val, err)
+		return false
+
+	case !ip.Is6():
+		ppfmt.Errorf(pp.EmojiUserError, "%q is not an IPv6 address", val)
+		return false
+	}
+
+	*field = ip
+	return true
+}
+
 // ReadDomains reads an environment variable as a comma-separated list of domains.
 func ReadDomains(ppfmt pp.PP, key string, field *[]domain.Domain) bool {
 	if list, ok := domainexp.ParseList(ppfmt, Getenv(key)); ok {
@@ -259,6 +348,51 @@ func ReadNonnegDuration(ppfmt pp.PP, key string, field *time.Duration) bool {
 	return true
 }
 
+// ReadDomainIntervals reads an environment variable as a comma-separated list of
+// domains and their update intervals, such as "slow.example.org=30m,fast.example.org=5m".
+func ReadDomainIntervals(ppfmt pp.PP, key string, field *map[domain.Domain]time.Duration) bool {
+	val := Getenv(key)
+	if val == "" {
+		ppfmt.Infof(pp.EmojiBullet, "Use default %s=%s", key, "")
+		return true
+	}
+
+	intervals := map[domain.Domain]time.Duration{}
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, duration, found := strings.Cut(item, "=")
+		if !found {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: wanted a domain and an interval separated by %q", item, "=")
+			return false
+		}
+
+		dom, err := domain.New(strings.TrimSpace(name))
+		if err != nil {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: %v", item, err)
+			return false
+		}
+
+		t, err := time.ParseDuration(strings.TrimSpace(duration))
+		switch {
+		case err != nil:
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: %v", item, err)
+			return false
+		case t < 0:
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: %v is negative", item, t)
+			return false
+		}
+
+		intervals[dom] = t
+	}
+
+	*field = intervals
+	return true
+}
+
 // ReadCron reads an environment variable and parses it as a Cron expression.
 func ReadCron(ppfmt pp.PP, key string, field *cron.Schedule) bool {
 	val := Getenv(key)
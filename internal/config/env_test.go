@@ -1,6 +1,7 @@
 package config_test
 
 import (
+	"net/netip"
 	"net/url"
 	"os"
 	"testing"
@@ -109,6 +110,48 @@ func TestReadString(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // environment vars are global
+func TestReadCommentTemplate(t *testing.T) {
+	key := keyPrefix + "COMMENT"
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		oldField      string
+		newField      string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"nil": {
+			false, "", "old", "old", true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", key, "old")
+			},
+		},
+		"plain":     {true, " managed ", "", "managed", true, nil},
+		"variables": {true, "on {hostname} at {timestamp} for {domain} ({ipnet})", "", "on {hostname} at {timestamp} for {domain} ({ipnet})", true, nil}, //nolint:lll
+		"unknown": {
+			true, "on {host}", "old", "old", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "on {host}", gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			field := tc.oldField
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadCommentTemplate(mockPP, key, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.newField, field)
+		})
+	}
+}
+
 //nolint:paralleltest // environment vars are global
 func TestReadQuiet(t *testing.T) {
 	key := keyPrefix + "QUIET"
@@ -156,6 +199,50 @@ func TestReadQuiet(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // environment vars are global
+func TestReadEmoji(t *testing.T) {
+	key := keyPrefix + "EMOJI"
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		ok            bool
+		wrapped       bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"nil":   {false, "", true, false, nil},
+		"empty": {true, " ", true, false, nil},
+		"true":  {true, " true", true, false, nil},
+		"false": {true, "    false ", true, true, nil},
+		"illform": {
+			true, "weird", false, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "weird", gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			var wrappedPP pp.PP = mockPP
+
+			ok := config.ReadEmoji(key, &wrappedPP)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.wrapped, wrappedPP != pp.PP(mockPP))
+
+			if tc.wrapped {
+				mockPP.EXPECT().Infof(pp.Emoji("OK"), "done")
+				wrappedPP.Infof(pp.EmojiGood, "done")
+			}
+		})
+	}
+}
+
 //nolint:funlen,paralleltest // environment vars are global
 func TestReadBool(t *testing.T) {
 	key := keyPrefix + "BOOL"
@@ -284,6 +371,114 @@ func TestReadNonnegInt(t *testing.T) {
 	}
 }
 
+//nolint:funlen,paralleltest // environment vars are global
+func TestReadIP6PrefixLength(t *testing.T) {
+	key := keyPrefix + "IP6_PREFIX_LENGTH"
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		oldField      int
+		newField      int
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"nil": {
+			false, "", 0, 0, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%d", key, 0)
+			},
+		},
+		"48":  {true, " 48 ", 0, 48, true, nil},
+		"64":  {true, "64", 0, 64, true, nil},
+		"128": {true, "128", 0, 128, true, nil},
+		"129": {
+			true, "129", 0, 0, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "The IPv6 prefix length (%d) should be between 0 and 128", 129)
+			},
+		},
+		"-1": {
+			true, "-1", 0, 0, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "The IPv6 prefix length (%d) should be between 0 and 128", -1)
+			},
+		},
+		"words": {
+			true, "word", 0, 0, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "word", gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			field := tc.oldField
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadIP6PrefixLength(mockPP, key, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.newField, field)
+		})
+	}
+}
+
+//nolint:funlen,paralleltest // environment vars are global
+func TestReadIP6(t *testing.T) {
+	key := keyPrefix + "IP6"
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		oldField      netip.Addr
+		newField      netip.Addr
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"nil": {
+			false, "", netip.Addr{}, netip.Addr{}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", key, "")
+			},
+		},
+		"nil/default": {
+			false, "", netip.MustParseAddr("::1"), netip.MustParseAddr("::1"), true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", key, netip.MustParseAddr("::1"))
+			},
+		},
+		"::abcd": {true, " ::abcd ", netip.Addr{}, netip.MustParseAddr("::abcd"), true, nil},
+		"1.2.3.4": {
+			true, "1.2.3.4", netip.Addr{}, netip.Addr{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "%q is not an IPv6 address", "1.2.3.4")
+			},
+		},
+		"words": {
+			true, "word", netip.Addr{}, netip.Addr{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "word", gomock.Any())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			field := tc.oldField
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadIP6(mockPP, key, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.newField, field)
+		})
+	}
+}
+
 //nolint:funlen,paralleltest // environment vars are global
 func TestReadTTL(t *testing.T) {
 	key := keyPrefix + "TTL"
@@ -644,6 +839,78 @@ func TestReadNonnegDuration(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest,funlen // environment vars are global
+func TestReadDomainIntervals(t *testing.T) {
+	key := keyPrefix + "DOMAIN_INTERVALS"
+
+	type ds = map[domain.Domain]time.Duration
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		oldField      ds
+		newField      ds
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"nil": {
+			false, "", ds{}, ds{}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", key, "")
+			},
+		},
+		"empty": {
+			true, "", ds{domain.FQDN("a.org"): time.Second}, ds{domain.FQDN("a.org"): time.Second}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", key, "")
+			},
+		},
+		"list": {
+			true, " A.org = 30m ,, *.b.org=5m ", ds{},
+			ds{domain.FQDN("a.org"): 30 * time.Minute, domain.Wildcard("b.org"): 5 * time.Minute}, true,
+			nil,
+		},
+		"no-equal": {
+			true, "a.org", ds{}, ds{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError,
+					"Failed to parse %q: wanted a domain and an interval separated by %q", "a.org", "=")
+			},
+		},
+		"illformed-domain": {
+			true, "*.*=1m", ds{}, ds{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "*.*=1m", gomock.Any())
+			},
+		},
+		"illformed-interval": {
+			true, "a.org=1", ds{}, ds{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "a.org=1", gomock.Any())
+			},
+		},
+		"negative": {
+			true, "a.org=-1s", ds{}, ds{}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v is negative", "a.org=-1s", -time.Second)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			field := tc.oldField
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadDomainIntervals(mockPP, key, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.newField, field)
+		})
+	}
+}
+
 //nolint:paralleltest // environment vars are global
 func TestReadCron(t *testing.T) {
 	key := keyPrefix + "CRON"
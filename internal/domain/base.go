@@ -14,8 +14,21 @@ type Splitter interface {
 type Domain interface {
 	// DNSNameASCII gives a name suitable for accessing the Cloudflare API
 	DNSNameASCII() string
+	// ACEEncoded gives the normalized ASCII-compatible encoding (ACE) of the domain without the final dot
+	ACEEncoded() string
+	// DNSName gives the normalized ACE of the domain with the final dot, such as "example.org."
+	DNSName() string
+	// Unicode gives the Unicode form of the domain, keeping labels that cannot be decoded as they are
+	// and returning the first decoding error
+	Unicode() (string, error)
 	// Describe gives the most human-readable domain name that is still unambiguous
 	Describe() string
 	// Split gives a Splitter that can be used to find zones
 	Split() Splitter
+	// Normalize gives the canonical form of the domain: lowercase ACE without the final dot
+	Normalize() Domain
+	// Equal checks whether the other domain is of the same kind with the same ACE form, ignoring cases
+	Equal(other Domain) bool
+	// Less checks whether the domain goes before the other one in the canonical ordering
+	Less(other Domain) bool
 }
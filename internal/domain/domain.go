@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"sort"
 	"strings"
 
@@ -26,19 +27,49 @@ var (
 	)
 )
 
-// safelyToUnicode takes an ASCII form and returns the Unicode form
-// when the round trip gives the same ASCII form back without errors.
-// Otherwise, the input ASCII form is returned.
-func safelyToUnicode(ascii string) string {
-	unicode, errToA := profileKeepingLeadingDots.ToUnicode(ascii)
-	roundTrip, errToU := profileKeepingLeadingDots.ToASCII(unicode)
-	if errToA != nil || errToU != nil || roundTrip != ascii {
-		return ascii
+var errNotRoundTrip = errors.New("the Unicode form does not give back the same ASCII form")
+
+// toUnicode takes an ASCII form and returns the Unicode form if the round trip gives
+// the same ASCII form back without errors. Otherwise, the input ASCII form is returned with an error.
+func toUnicode(ascii string) (string, error) {
+	unicode, err := profileKeepingLeadingDots.ToUnicode(ascii)
+	if err != nil {
+		return ascii, err //nolint:wrapcheck
+	}
+	roundTrip, err := profileKeepingLeadingDots.ToASCII(unicode)
+	if err != nil {
+		return ascii, err //nolint:wrapcheck
 	}
+	if roundTrip != ascii {
+		return ascii, errNotRoundTrip
+	}
+
+	return unicode, nil
+}
 
+// safelyToUnicode is toUnicode with the errors ignored.
+func safelyToUnicode(ascii string) string {
+	unicode, _ := toUnicode(ascii)
 	return unicode
 }
 
+// ToUnicode decodes each label of an ASCII form into Unicode. Labels that cannot be decoded
+// are kept in their ASCII forms, and the first decoding error is returned.
+func ToUnicode(ascii string) (string, error) {
+	var firstErr error
+
+	labels := strings.Split(ascii, ".")
+	for i, label := range labels {
+		unicode, err := toUnicode(label)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		labels[i] = unicode
+	}
+
+	return strings.Join(labels, "."), firstErr
+}
+
 // StringToASCII normalizes a domain with best efforts, ignoring errors.
 func StringToASCII(domain string) string {
 	normalized, _ := profileDroppingLeadingDots.ToASCII(domain)
@@ -71,6 +102,40 @@ func New(domain string) (Domain, error) {
 	}
 }
 
+// isWildcard tells wildcards apart from FQDNs, which go first in the canonical ordering.
+func isWildcard(d Domain) bool {
+	_, ok := d.(Wildcard)
+	return ok
+}
+
+// foldedACE gives the ACE form of a domain in lower cases, which is the key for comparing domains.
+func foldedACE(d Domain) string {
+	return strings.ToLower(d.ACEEncoded())
+}
+
+// Less is the canonical total ordering of domains. FQDNs go before wildcards, and then domains of
+// the same kind are compared by their ACE forms ignoring cases. Two domains are incomparable exactly
+// when they are Equal.
+func Less(a, b Domain) bool {
+	if wildcardA, wildcardB := isWildcard(a), isWildcard(b); wildcardA != wildcardB {
+		return wildcardB
+	}
+	return foldedACE(a) < foldedACE(b)
+}
+
+// Equal checks whether two domains are of the same kind and have the same ACE form, ignoring cases.
+func Equal(a, b Domain) bool {
+	return isWildcard(a) == isWildcard(b) && foldedACE(a) == foldedACE(b)
+}
+
+// Sort sorts domains in the canonical ordering given by Less.
+func Sort(s []Domain) {
+	sort.SliceStable(s, func(i, j int) bool { return Less(s[i], s[j]) })
+}
+
+// SortDomains sorts domains in the canonical ordering given by Less.
+//
+// Deprecated: use Sort.
 func SortDomains(s []Domain) {
-	sort.Slice(s, func(i, j int) bool { return s[i].DNSNameASCII() < s[j].DNSNameASCII() })
+	Sort(s)
 }
@@ -1,6 +1,7 @@
 package domain_test
 
 import (
+	"fmt"
 	"sort"
 	"testing"
 	"testing/quick"
@@ -104,6 +105,7 @@ func TestNew(t *testing.T) {
 			if tc.ok {
 				require.NoError(t, err)
 				require.Empty(t, tc.errString)
+				require.Equal(t, normalized, normalized.Normalize())
 			} else {
 				require.EqualError(t, err, tc.errString)
 			}
@@ -111,6 +113,178 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func merge(fs []domain.FQDN, ws []domain.Wildcard) []domain.Domain {
+	merged := make([]domain.Domain, 0, len(fs)+len(ws))
+	for _, f := range fs {
+		merged = append(merged, f)
+	}
+	for _, w := range ws {
+		merged = append(merged, w)
+	}
+	return merged
+}
+
+func TestLess(t *testing.T) {
+	t.Parallel()
+
+	type f = domain.FQDN
+	type w = domain.Wildcard
+	for _, tc := range [...]struct {
+		a, b     domain.Domain
+		expected bool
+	}{
+		{f("a.com"), f("b.com"), true},
+		{f("b.com"), f("a.com"), false},
+		{f("a.com"), f("a.com"), false},
+		{f("a.com"), w("a.com"), true},
+		{w("a.com"), f("a.com"), false},
+		{w("a.com"), w("a.com"), false},
+		{w("a.com"), f("b.com"), false},
+		{f("b.com"), w("a.com"), true},
+		{w("z.com"), f("a.com"), false},
+		{w("a.com"), w("b.com"), true},
+		{f("*.a.com"), w("a.com"), true},
+		{w("a.com"), f("*.a.com"), false},
+		{f("A.com"), f("a.com"), false},
+		{f("a.com"), f("A.com"), false},
+		{f("A.com"), f("b.com"), true},
+		{f("xn--fa-hia.de"), f("fass.de"), false},
+	} {
+		tc := tc
+		t.Run(fmt.Sprintf("%T(%s)<%T(%s)", tc.a, tc.a.DNSNameASCII(), tc.b, tc.b.DNSNameASCII()), func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, domain.Less(tc.a, tc.b))
+		})
+	}
+}
+
+func TestLessStrictWeakOrdering(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, quick.Check(
+		func(fs [3]domain.FQDN, ws [3]domain.Wildcard, choices [3]bool) bool {
+			var ds [3]domain.Domain
+			for i := range ds {
+				if choices[i] {
+					ds[i] = fs[i]
+				} else {
+					ds[i] = ws[i]
+				}
+			}
+			a, b, c := ds[0], ds[1], ds[2]
+
+			// irreflexivity
+			require.False(t, domain.Less(a, a))
+			// asymmetry
+			require.False(t, domain.Less(a, b) && domain.Less(b, a))
+			// transitivity
+			if domain.Less(a, b) && domain.Less(b, c) {
+				require.True(t, domain.Less(a, c))
+			}
+			// transitivity of incomparability
+			incomparable := func(x, y domain.Domain) bool { return !domain.Less(x, y) && !domain.Less(y, x) }
+			if incomparable(a, b) && incomparable(b, c) {
+				require.True(t, incomparable(a, c))
+			}
+
+			return true
+		},
+		nil,
+	))
+}
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	type f = domain.FQDN
+	type w = domain.Wildcard
+	for _, tc := range [...]struct {
+		a, b     domain.Domain
+		expected bool
+	}{
+		{f("a.com"), f("a.com"), true},
+		{f("a.com"), f("b.com"), false},
+		{f("A.com"), f("a.com"), true},
+		{f("a.com"), w("a.com"), false},
+		{w("A.com"), w("a.com"), true},
+		{f("*.a.com"), w("a.com"), false},
+		{f("xn--fa-hia.de"), f("faß.de"), true},
+		{w(""), f("*"), false},
+	} {
+		tc := tc
+		t.Run(fmt.Sprintf("%T(%s)=%T(%s)", tc.a, tc.a.DNSNameASCII(), tc.b, tc.b.DNSNameASCII()), func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.a.Equal(tc.b))
+			require.Equal(t, tc.expected, tc.b.Equal(tc.a))
+			require.Equal(t, tc.expected, !domain.Less(tc.a, tc.b) && !domain.Less(tc.b, tc.a))
+		})
+	}
+}
+
+func TestEqualEquivalence(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, quick.Check(
+		func(fs [3]domain.FQDN, ws [3]domain.Wildcard, choices [3]bool) bool {
+			var ds [3]domain.Domain
+			for i := range ds {
+				if choices[i] {
+					ds[i] = fs[i]
+				} else {
+					ds[i] = ws[i]
+				}
+			}
+			a, b, c := ds[0], ds[1], ds[2]
+
+			// reflexivity
+			require.True(t, a.Equal(a))
+			// symmetry
+			require.Equal(t, a.Equal(b), b.Equal(a))
+			// transitivity
+			if a.Equal(b) && b.Equal(c) {
+				require.True(t, a.Equal(c))
+			}
+			// the method Less is the canonical ordering
+			require.Equal(t, domain.Less(a, b), a.Less(b))
+			// two domains are incomparable exactly when they are equal
+			require.Equal(t, a.Equal(b), !domain.Less(a, b) && !domain.Less(b, a))
+
+			return true
+		},
+		nil,
+	))
+}
+
+func TestSort(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, quick.Check(
+		func(fs []domain.FQDN, ws []domain.Wildcard) bool {
+			merged := merge(fs, ws)
+
+			copied := make([]domain.Domain, len(merged))
+			copy(copied, merged)
+			domain.Sort(merged)
+
+			require.ElementsMatch(t, copied, merged)
+			require.True(t, sort.SliceIsSorted(merged,
+				func(i, j int) bool {
+					return domain.Less(merged[i], merged[j])
+				}))
+
+			// Sorting a permutation gives the same result.
+			for i, j := 0, len(copied)-1; i < j; i, j = i+1, j-1 {
+				copied[i], copied[j] = copied[j], copied[i]
+			}
+			domain.Sort(copied)
+			require.Equal(t, merged, copied)
+
+			return true
+		},
+		nil,
+	))
+}
+
 func TestSortDomains(t *testing.T) {
 	t.Parallel()
 
@@ -132,11 +306,63 @@ func TestSortDomains(t *testing.T) {
 			require.ElementsMatch(t, copied, merged)
 			require.True(t, sort.SliceIsSorted(merged,
 				func(i, j int) bool {
-					return merged[i].DNSNameASCII() < merged[j].DNSNameASCII()
+					return domain.Less(merged[i], merged[j])
 				}))
 
+			// SortDomains is the same as Sort.
+			domain.Sort(copied)
+			require.Equal(t, copied, merged)
+
 			return true
 		},
 		nil,
 	))
+
+	type f = domain.FQDN
+	type w = domain.Wildcard
+	ds := []domain.Domain{w("a.com"), f("b.com"), f("A.com")}
+	domain.SortDomains(ds)
+	require.Equal(t, []domain.Domain{f("A.com"), f("b.com"), w("a.com")}, ds)
+}
+
+func TestSortMixed(t *testing.T) {
+	t.Parallel()
+
+	type f = domain.FQDN
+	type w = domain.Wildcard
+	ds := []domain.Domain{w("b.org"), f("b.org"), f("*.b.org"), w(""), f("a.org"), f("xn--fa-hia.de")}
+	domain.Sort(ds)
+	require.Equal(t,
+		[]domain.Domain{f("*.b.org"), f("a.org"), f("b.org"), f("xn--fa-hia.de"), w(""), w("b.org")},
+		ds)
+
+	ds = []domain.Domain{w("a.com"), f("b.com")}
+	domain.Sort(ds)
+	require.Equal(t, []domain.Domain{f("b.com"), w("a.com")}, ds)
+}
+
+func TestToUnicode(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"fass.de", "fass.de", true},
+		{"xn--53h.de", "☕.de", true},
+		{"xn--a.xn--53h.de", "xn--a.☕.de", false},
+		{"", "", true},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			unicode, err := domain.ToUnicode(tc.input)
+			require.Equal(t, tc.expected, unicode)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
 }
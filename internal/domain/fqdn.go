@@ -1,16 +1,38 @@
 package domain
 
-import "strings"
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
 
 // FQDN is a fully qualified domain in its ASCII form.
 type FQDN string
 
 func (f FQDN) DNSNameASCII() string { return string(f) }
 
+func (f FQDN) ACEEncoded() string { return StringToASCII(string(f)) }
+
+func (f FQDN) DNSName() string { return f.ACEEncoded() + "." }
+
+func (f FQDN) Unicode() (string, error) { return ToUnicode(string(f)) }
+
 func (f FQDN) Describe() string {
 	return safelyToUnicode(string(f))
 }
 
+func (f FQDN) Normalize() Domain { return FQDN(StringToASCII(string(f))) }
+
+func (f FQDN) Equal(other Domain) bool { return Equal(f, other) }
+
+func (f FQDN) Less(other Domain) bool { return Less(f, other) }
+
+// RegisteredDomain returns the registered domain (also known as eTLD+1) according to the public suffix list.
+// For example, the registered domain of "sub.example.co.uk" is "example.co.uk".
+func (f FQDN) RegisteredDomain() (string, error) {
+	return publicsuffix.EffectiveTLDPlusOne(string(f)) //nolint:wrapcheck
+}
+
 type FQDNSplitter struct {
 	domain    string
 	cursor    int
@@ -66,6 +66,101 @@ func TestFQDNDescribe(t *testing.T) {
 	}
 }
 
+func TestFQDNACEEncoded(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected string
+	}{
+		{"fass.de", "fass.de"},
+		{"xn--fa-hia.de", "xn--fa-hia.de"},
+		{"faß.de", "xn--fa-hia.de"},
+		{"☕.de", "xn--53h.de"},
+		{"日本.co.jp", "xn--wgv71a.co.jp"},
+		{"Example.ORG.", "example.org"},
+		{"a.com", "a.com"},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, domain.FQDN(tc.input).ACEEncoded())
+		})
+	}
+}
+
+func TestFQDNDNSName(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected string
+	}{
+		{"", "."},
+		{"fass.de", "fass.de."},
+		{"faß.de", "xn--fa-hia.de."},
+		{"日本.co.jp", "xn--wgv71a.co.jp."},
+		{"Example.ORG.", "example.org."},
+		{"example.com", "example.com."},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, domain.FQDN(tc.input).DNSName())
+		})
+	}
+}
+
+func TestFQDNNormalize(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected domain.Domain
+	}{
+		{"sub.example.com", domain.FQDN("sub.example.com")},
+		{"SUB.EXAMPLE.COM", domain.FQDN("sub.example.com")},
+		{"Sub.EXAMPLE.com", domain.FQDN("sub.example.com")},
+		{"sub.example.com.", domain.FQDN("sub.example.com")},
+		{"Sub.EXAMPLE.COM.", domain.FQDN("sub.example.com")},
+		{"Faß.DE", domain.FQDN("xn--fa-hia.de")},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			normalized := domain.FQDN(tc.input).Normalize()
+			require.Equal(t, tc.expected, normalized)
+			require.Equal(t, normalized, normalized.Normalize())
+		})
+	}
+}
+
+func TestFQDNUnicode(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"fass.de", "fass.de", true},
+		{"xn--fa-hia.de", "faß.de", true},
+		{"xn--53h.de", "☕.de", true},
+		{"xn--wgv71a.co.jp", "日本.co.jp", true},
+		{"xn--a.com", "xn--a.com", false},
+		{"xn--53h.xn--a.com", "☕.xn--a.com", false},
+		{"a.com", "a.com", true},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			unicode, err := domain.FQDN(tc.input).Unicode()
+			require.Equal(t, tc.expected, unicode)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
 //nolint:dupl
 func TestFQDNSplitter(t *testing.T) {
 	t.Parallel()
@@ -92,3 +187,35 @@ func TestFQDNSplitter(t *testing.T) {
 		})
 	}
 }
+
+func TestFQDNRegisteredDomain(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input    string
+		ok       bool
+		expected string
+	}{
+		"com/1":     {"example.com", true, "example.com"},
+		"com/2":     {"a.b.example.com", true, "example.com"},
+		"ccsld/1":   {"sub.example.co.uk", true, "example.co.uk"},
+		"ccsld/2":   {"example.co.uk", true, "example.co.uk"},
+		"ccsld/3":   {"sub.xn--wgv71a.co.jp", true, "xn--wgv71a.co.jp"},
+		"private/1": {"a.b.user.github.io", true, "user.github.io"},
+		"private/2": {"user.github.io", true, "user.github.io"},
+		"suffix/1":  {"co.uk", false, ""},
+		"suffix/2":  {"github.io", false, ""},
+		"suffix/3":  {"com", false, ""},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			registered, err := domain.FQDN(tc.input).RegisteredDomain()
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+			require.Equal(t, tc.expected, registered)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxLabelLength is the maximum length of a label in its ASCII form.
+	maxLabelLength = 63
+	// maxNameLength is the maximum length of a domain name in its ASCII form, without the final dot.
+	maxNameLength = 253
+)
+
+var (
+	// ErrNoLabels means that a domain was constructed from an empty list of labels.
+	ErrNoLabels = errors.New("no labels")
+	// ErrEmptyLabel means that one of the labels is empty.
+	ErrEmptyLabel = errors.New("empty label")
+	// ErrInvalidLabel means that one of the labels cannot be converted to a valid ASCII label.
+	ErrInvalidLabel = errors.New("invalid label")
+	// ErrNameTooLong means that the domain name is longer than 253 characters in its ASCII form.
+	ErrNameTooLong = errors.New("domain name too long")
+)
+
+// isLDH checks whether an ASCII label consists of letters, digits, and hyphens.
+func isLDH(label string) bool {
+	for _, c := range label {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// labelsToASCII converts each label to its ASCII form and joins them with dots.
+func labelsToASCII(labels []string) (string, error) {
+	asciiLabels := make([]string, 0, len(labels))
+	for i, label := range labels {
+		if label == "" {
+			return "", fmt.Errorf("%w at position %d", ErrEmptyLabel, i)
+		}
+
+		ascii, err := profileKeepingLeadingDots.ToASCII(label)
+		if err != nil || strings.Contains(ascii, ".") || !isLDH(ascii) || len(ascii) > maxLabelLength {
+			return "", fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+		}
+		asciiLabels = append(asciiLabels, ascii)
+	}
+
+	return strings.Join(asciiLabels, "."), nil
+}
+
+// FQDNFromLabels constructs an FQDN from its labels, such as []string{"www", "example", "org"}.
+// Each label is checked and normalized to its ASCII form; a label cannot contain dots.
+func FQDNFromLabels(labels []string) (FQDN, error) {
+	if len(labels) == 0 {
+		return "", ErrNoLabels
+	}
+
+	name, err := labelsToASCII(labels)
+	if err != nil {
+		return "", err
+	}
+
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("%w: %d characters", ErrNameTooLong, len(name))
+	}
+
+	return FQDN(name), nil
+}
+
+// WildcardFromLabels constructs a Wildcard from the labels of its zone. For example,
+// []string{"example", "org"} gives the wildcard domain "*.example.org". An empty list gives "*".
+func WildcardFromLabels(apexLabels []string) (Wildcard, error) {
+	name, err := labelsToASCII(apexLabels)
+	if err != nil {
+		return "", err
+	}
+
+	w := Wildcard(name)
+	if l := len(w.DNSNameASCII()); l > maxNameLength {
+		return "", fmt.Errorf("%w: %d characters", ErrNameTooLong, l)
+	}
+
+	return w, nil
+}
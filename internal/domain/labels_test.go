@@ -0,0 +1,85 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+)
+
+// label63 is a label of the maximum length.
+var label63 = strings.Repeat("a", 63) //nolint:gochecknoglobals
+
+func TestFQDNFromLabels(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		labels   []string
+		expected domain.FQDN
+		err      error
+	}{
+		"simple": {[]string{"www", "example", "org"}, "www.example.org", nil},
+		"single": {[]string{"localhost"}, "localhost", nil},
+		"case":   {[]string{"WWW", "Example", "ORG"}, "www.example.org", nil},
+		"idn":    {[]string{"☕", "de"}, "xn--53h.de", nil},
+		"max-length": {
+			[]string{label63, label63, label63, strings.Repeat("a", 61)},
+			domain.FQDN(strings.Repeat(label63+".", 3) + strings.Repeat("a", 61)), nil,
+		},
+		"too-long":   {[]string{label63, label63, label63, strings.Repeat("a", 62)}, "", domain.ErrNameTooLong},
+		"long-label": {[]string{strings.Repeat("a", 64), "org"}, "", domain.ErrInvalidLabel},
+		"none":       {[]string{}, "", domain.ErrNoLabels},
+		"empty":      {[]string{"www", "", "org"}, "", domain.ErrEmptyLabel},
+		"dot":        {[]string{"www.example", "org"}, "", domain.ErrInvalidLabel},
+		"space":      {[]string{"w w", "org"}, "", domain.ErrInvalidLabel},
+		"star":       {[]string{"*", "org"}, "", domain.ErrInvalidLabel},
+		"underscore": {[]string{"_dmarc", "example", "org"}, "", domain.ErrInvalidLabel},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fqdn, err := domain.FQDNFromLabels(tc.labels)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expected, fqdn)
+		})
+	}
+}
+
+func TestWildcardFromLabels(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		labels   []string
+		expected domain.Wildcard
+		err      error
+	}{
+		"simple": {[]string{"example", "org"}, "example.org", nil},
+		"root":   {[]string{}, "", nil},
+		"idn":    {[]string{"☕", "de"}, "xn--53h.de", nil},
+		"max-length": {
+			[]string{label63, label63, label63, strings.Repeat("a", 59)},
+			domain.Wildcard(strings.Repeat(label63+".", 3) + strings.Repeat("a", 59)), nil,
+		},
+		"too-long": {[]string{label63, label63, label63, strings.Repeat("a", 60)}, "", domain.ErrNameTooLong},
+		"empty":    {[]string{"", "org"}, "", domain.ErrEmptyLabel},
+		"invalid":  {[]string{"exa$mple", "org"}, "", domain.ErrInvalidLabel},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w, err := domain.WildcardFromLabels(tc.labels)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expected, w)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidIP means that the IP address is not a valid IPv4 or IPv6 address.
+var ErrInvalidIP = errors.New("invalid IP address")
+
+const hexDigits = "0123456789abcdef"
+
+// PTRDomain returns the domain of the PTR record of the IP address for reverse DNS.
+// For example, the PTR domain of 1.2.3.4 is "4.3.2.1.in-addr.arpa". IPv4-mapped IPv6 addresses
+// are treated as IPv4 addresses, and the zone of an IPv6 address is ignored.
+func PTRDomain(ip netip.Addr) (FQDN, error) {
+	if !ip.IsValid() {
+		return "", fmt.Errorf("%w: %v", ErrInvalidIP, ip)
+	}
+
+	ip = ip.Unmap()
+	if ip.Is4() {
+		bytes := ip.As4()
+		labels := make([]string, 0, len(bytes)+2)
+		for i := len(bytes) - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprint(bytes[i]))
+		}
+		return FQDN(strings.Join(append(labels, "in-addr", "arpa"), ".")), nil
+	}
+
+	bytes := ip.As16()
+	labels := make([]string, 0, 2*len(bytes)+2)
+	for i := len(bytes) - 1; i >= 0; i-- {
+		labels = append(labels, string(hexDigits[bytes[i]&0xf]), string(hexDigits[bytes[i]>>4]))
+	}
+	return FQDN(strings.Join(append(labels, "ip6", "arpa"), ".")), nil
+}
+
+// ReverseZonePrefix returns the IP prefix represented by a domain under "in-addr.arpa" or "ip6.arpa",
+// which is the reverse of PTRDomain. For example, "3.2.1.in-addr.arpa" represents 1.2.3.0/24 and
+// "8.b.d.0.1.0.0.2.ip6.arpa" represents 2001:db8::/32. Other domains represent no prefixes.
+func ReverseZonePrefix(d FQDN) (netip.Prefix, bool) {
+	name := strings.ToLower(d.DNSNameASCII())
+
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+		if len(labels) > 4 {
+			return netip.Prefix{}, false
+		}
+
+		var bytes [4]byte
+		for i, label := range labels {
+			n, err := strconv.ParseUint(label, 10, 8)
+			if err != nil || strconv.FormatUint(n, 10) != label {
+				return netip.Prefix{}, false
+			}
+			bytes[len(labels)-1-i] = byte(n)
+		}
+		return netip.PrefixFrom(netip.AddrFrom4(bytes), 8*len(labels)), true
+
+	case strings.HasSuffix(name, ".ip6.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".ip6.arpa"), ".")
+		if len(labels) > 32 {
+			return netip.Prefix{}, false
+		}
+
+		var bytes [16]byte
+		for i, label := range labels {
+			nibble := strings.Index(hexDigits, label)
+			if len(label) != 1 || nibble < 0 {
+				return netip.Prefix{}, false
+			}
+			pos := len(labels) - 1 - i
+			bytes[pos/2] |= byte(nibble) << (4 * (1 - pos%2))
+		}
+		return netip.PrefixFrom(netip.AddrFrom16(bytes), 4*len(labels)), true
+
+	default:
+		return netip.Prefix{}, false
+	}
+}
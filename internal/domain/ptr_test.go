@@ -0,0 +1,89 @@
+package domain_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+)
+
+func TestPTRDomain(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input    string
+		expected domain.FQDN
+	}{
+		"ip4":        {"1.2.3.4", "4.3.2.1.in-addr.arpa"},
+		"ip4/zeros":  {"10.0.0.255", "255.0.0.10.in-addr.arpa"},
+		"ip4-in-ip6": {"::ffff:1.2.3.4", "4.3.2.1.in-addr.arpa"},
+		"ip6": {
+			"2001:db8::567:89ab",
+			"b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		},
+		"ip6/zone": {
+			"fe80::1%eth0",
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.f.ip6.arpa",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ptr, err := domain.PTRDomain(netip.MustParseAddr(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ptr)
+		})
+	}
+}
+
+func TestPTRDomainInvalid(t *testing.T) {
+	t.Parallel()
+
+	ptr, err := domain.PTRDomain(netip.Addr{})
+	require.ErrorIs(t, err, domain.ErrInvalidIP)
+	require.Empty(t, ptr)
+}
+
+func TestReverseZonePrefix(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input    domain.FQDN
+		ok       bool
+		expected string
+	}{
+		"ip4/8":        {"10.in-addr.arpa", true, "10.0.0.0/8"},
+		"ip4/24":       {"3.2.1.in-addr.arpa", true, "1.2.3.0/24"},
+		"ip4/32":       {"4.3.2.1.in-addr.arpa", true, "1.2.3.4/32"},
+		"ip4/case":     {"3.2.1.IN-ADDR.ARPA", true, "1.2.3.0/24"},
+		"ip4/too-long": {"5.4.3.2.1.in-addr.arpa", false, ""},
+		"ip4/256":      {"256.in-addr.arpa", false, ""},
+		"ip4/zero":     {"01.in-addr.arpa", false, ""},
+		"ip6/32":       {"8.b.d.0.1.0.0.2.ip6.arpa", true, "2001:db8::/32"},
+		"ip6/64": {
+			"0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", true, "2001:db8::/64",
+		},
+		"ip6/128": {
+			"b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", true, "2001:db8::567:89ab/128",
+		},
+		"ip6/odd":     {"d.0.1.0.0.2.ip6.arpa", true, "2001:d00::/24"},
+		"ip6/invalid": {"g.ip6.arpa", false, ""},
+		"ip6/wide":    {"00.ip6.arpa", false, ""},
+		"other":       {"example.org", false, ""},
+		"arpa":        {"in-addr.arpa", false, ""},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prefix, ok := domain.ReverseZonePrefix(tc.input)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, netip.MustParsePrefix(tc.expected), prefix)
+			} else {
+				require.Equal(t, netip.Prefix{}, prefix)
+			}
+		})
+	}
+}
@@ -14,6 +14,25 @@ func (w Wildcard) DNSNameASCII() string {
 	return "*." + string(w)
 }
 
+func (w Wildcard) ACEEncoded() string {
+	if string(w) == "" {
+		return "*"
+	}
+
+	return "*." + StringToASCII(string(w))
+}
+
+func (w Wildcard) DNSName() string { return w.ACEEncoded() + "." }
+
+func (w Wildcard) Unicode() (string, error) {
+	if string(w) == "" {
+		return "*", nil
+	}
+
+	unicode, err := ToUnicode(string(w))
+	return "*." + unicode, err
+}
+
 func (w Wildcard) Describe() string {
 	if string(w) == "" {
 		return "*"
@@ -22,6 +41,16 @@ func (w Wildcard) Describe() string {
 	return "*." + safelyToUnicode(string(w))
 }
 
+// Normalize keeps the leading dots, as New does for wildcards.
+func (w Wildcard) Normalize() Domain {
+	normalized, _ := profileKeepingLeadingDots.ToASCII(string(w))
+	return Wildcard(strings.TrimRight(normalized, "."))
+}
+
+func (w Wildcard) Equal(other Domain) bool { return Equal(w, other) }
+
+func (w Wildcard) Less(other Domain) bool { return Less(w, other) }
+
 type WildcardSplitter struct {
 	domain    string
 	cursor    int
@@ -70,6 +70,96 @@ func TestWildcardDescribe(t *testing.T) {
 	}
 }
 
+func TestWildcardACEEncoded(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected string
+	}{
+		{"", "*"},
+		{"fass.de", "*.fass.de"},
+		{"xn--fa-hia.de", "*.xn--fa-hia.de"},
+		{"faß.de", "*.xn--fa-hia.de"},
+		{"☕.de", "*.xn--53h.de"},
+		{"日本.co.jp", "*.xn--wgv71a.co.jp"},
+		{"Example.ORG", "*.example.org"},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, domain.Wildcard(tc.input).ACEEncoded())
+		})
+	}
+}
+
+func TestWildcardDNSName(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected string
+	}{
+		{"", "*."},
+		{"fass.de", "*.fass.de."},
+		{"faß.de", "*.xn--fa-hia.de."},
+		{"Example.ORG", "*.example.org."},
+		{"example.com", "*.example.com."},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, domain.Wildcard(tc.input).DNSName())
+		})
+	}
+}
+
+func TestWildcardNormalize(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected domain.Domain
+	}{
+		{"", domain.Wildcard("")},
+		{"example.com", domain.Wildcard("example.com")},
+		{"EXAMPLE.COM", domain.Wildcard("example.com")},
+		{"Example.com.", domain.Wildcard("example.com")},
+		{"Faß.DE", domain.Wildcard("xn--fa-hia.de")},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			normalized := domain.Wildcard(tc.input).Normalize()
+			require.Equal(t, tc.expected, normalized)
+			require.Equal(t, normalized, normalized.Normalize())
+		})
+	}
+}
+
+func TestWildcardUnicode(t *testing.T) {
+	t.Parallel()
+	for _, tc := range [...]struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"", "*", true},
+		{"fass.de", "*.fass.de", true},
+		{"xn--53h.de", "*.☕.de", true},
+		{"xn--a.com", "*.xn--a.com", false},
+	} {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			unicode, err := domain.Wildcard(tc.input).Unicode()
+			require.Equal(t, tc.expected, unicode)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
 //nolint:dupl
 func TestWildcardSplitter(t *testing.T) {
 	t.Parallel()
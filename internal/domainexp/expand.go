@@ -0,0 +1,46 @@
+package domainexp
+
+import (
+	"os"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// expandEnv replaces every ${VAR} in the input with the value of the environment variable VAR.
+// The form $VAR is kept as it is. The values are not expanded again, and a value containing
+// another ${...} is rejected to rule out circular references.
+func expandEnv(ppfmt pp.PP, input string) (string, bool) {
+	var b strings.Builder
+	rest := input
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), true
+		}
+		b.WriteString(rest[:start])
+		rest = rest[start+len("${"):]
+
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			ppfmt.Errorf(pp.EmojiUserError, `Failed to parse %q: missing "}" after "${"`, input)
+			return "", false
+		}
+		name := rest[:end]
+		rest = rest[end+1:]
+
+		if name == "" {
+			ppfmt.Errorf(pp.EmojiUserError, `Failed to parse %q: empty variable name in "${}"`, input)
+			return "", false
+		}
+
+		value := os.Getenv(name)
+		if strings.Contains(value, "${") {
+			ppfmt.Errorf(pp.EmojiUserError,
+				`Failed to parse %q: the value of %s refers to other variables, which might be circular`, input, name)
+			return "", false
+		}
+		b.WriteString(value)
+	}
+}
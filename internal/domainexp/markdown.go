@@ -0,0 +1,26 @@
+package domainexp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stripListMarkers turns Markdown list items ("- a.org", "* a.org", or "+ a.org") into
+// comma-separated entries so that domain lists can be copied from Markdown files.
+// A marker must be followed by whitespace; thus, "*.a.org" is still a wildcard domain.
+func stripListMarkers(input string) string {
+	lines := strings.Split(input, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+		if len(trimmed) < 2 || !strings.ContainsRune("-*+", rune(trimmed[0])) {
+			continue
+		}
+
+		rest := trimmed[1:]
+		if strings.TrimLeftFunc(rest, unicode.IsSpace) == rest {
+			continue
+		}
+		lines[i] = "," + rest + ","
+	}
+	return strings.Join(lines, "\n")
+}
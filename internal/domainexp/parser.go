@@ -1,9 +1,12 @@
 package domainexp
 
 import (
+	"net/netip"
+	"strconv"
 	"strings"
 
 	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
 
@@ -41,6 +44,21 @@ func scanASCIIDomainList(ppfmt pp.PP, input string, tokens []string) ([]string,
 	return domains, tokens
 }
 
+// scanASCIISuffixList is like scanASCIIDomainList but keeps a leading dot, if any,
+// so that ".example.com" does not match "badexample.com".
+func scanASCIISuffixList(ppfmt pp.PP, input string, tokens []string) ([]string, []string) {
+	list, tokens := scanList(ppfmt, input, tokens)
+	suffixes := make([]string, 0, len(list))
+	for _, raw := range list {
+		suffix := domain.StringToASCII(raw)
+		if strings.HasPrefix(raw, ".") {
+			suffix = "." + suffix
+		}
+		suffixes = append(suffixes, suffix)
+	}
+	return suffixes, tokens
+}
+
 func scanDomainList(ppfmt pp.PP, input string, tokens []string) ([]domain.Domain, []string) {
 	list, tokens := scanList(ppfmt, input, tokens)
 	domains := make([]domain.Domain, 0, len(list))
@@ -81,7 +99,49 @@ func scanMustConstant(ppfmt pp.PP, input string, tokens []string, wanted string)
 	return nil
 }
 
-type predicate = func(domain.Domain) bool
+// An evaluator decides whether a setting applies to a domain for an IP network. Conditions on the domain
+// (such as sub(example.com)) and on the IP network (ip4 and ip6) can be freely combined. It also sees
+// the batch of domains being evaluated together, which is needed by count(...). A single domain forms
+// a batch by itself.
+type evaluator func(batch []domain.Domain, d domain.Domain, ipNet ipnet.Type) bool
+
+// An Expression is a parsed boolean expression. It can be evaluated for one domain at a time
+// or for a batch of domains, the latter being needed for count(...) to see the other domains.
+type Expression struct {
+	eval evaluator
+}
+
+// Match decides whether the expression holds for the domain and the IP network.
+// The domain is evaluated by itself, and thus count(...) only counts the domain itself.
+func (e Expression) Match(d domain.Domain, ipNet ipnet.Type) bool {
+	return e.eval([]domain.Domain{d}, d, ipNet)
+}
+
+// MatchBatch evaluates the expression for every domain in the batch with the IP network.
+// The function count(...) counts the matching domains in the whole batch.
+func (e Expression) MatchBatch(batch []domain.Domain, ipNet ipnet.Type) map[domain.Domain]bool {
+	results := make(map[domain.Domain]bool, len(batch))
+	for _, d := range batch {
+		results[d] = e.eval(batch, d, ipNet)
+	}
+	return results
+}
+
+// registeredDomain returns the registered domain of a domain. A wildcard domain uses its zone.
+func registeredDomain(d domain.Domain) (string, bool) {
+	var fqdn domain.FQDN
+	switch d := d.(type) {
+	case domain.FQDN:
+		fqdn = d
+	case domain.Wildcard:
+		fqdn = domain.FQDN(string(d))
+	default:
+		return "", false
+	}
+
+	registered, err := fqdn.RegisteredDomain()
+	return registered, err == nil
+}
 
 func hasStrictSuffix(s, suffix string) bool {
 	return strings.HasSuffix(s, suffix) && (len(s) > len(suffix) && s[len(s)-len(suffix)-1] == '.')
@@ -89,30 +149,38 @@ func hasStrictSuffix(s, suffix string) bool {
 
 // scanAtomic mimics ParseBool, call scanFunction, and then check parenthesized expressions.
 //
-// <factor> --> true | false | <fun> | ! <factor> | ( <expression> )
+// <factor> --> true | false | <fun> | ip4 | ip6 | <reverse_zone> | <count> | ! <factor> | ( <expression> )
+//
+// where <fun> is one of is, sub, not_is, not_sub, has_suffix, and registered applied to a list,
+// <reverse_zone> is reverse_zone applied to a list of CIDRs, and <count> is count(<expression>, <min>, <max>).
 //
 //nolint:funlen
-func scanFactor(ppfmt pp.PP, input string, tokens []string) (predicate, []string) {
+func scanFactor(ppfmt pp.PP, input string, tokens []string) (evaluator, []string) {
 	// fmt.Printf("scanFactor(tokens = %#v)\n", tokens)
 
 	if _, newTokens := scanConstants(ppfmt, input, tokens,
 		[]string{"1", "t", "T", "TRUE", "true", "True"}); newTokens != nil {
-		return func(_ domain.Domain) bool { return true }, newTokens
+		return func(_ []domain.Domain, _ domain.Domain, _ ipnet.Type) bool { return true }, newTokens
 	}
 
 	if _, newTokens := scanConstants(ppfmt, input, tokens,
 		[]string{"0", "f", "F", "FALSE", "false", "False"}); newTokens != nil {
-		return func(_ domain.Domain) bool { return false }, newTokens
+		return func(_ []domain.Domain, _ domain.Domain, _ ipnet.Type) bool { return false }, newTokens
 	}
 
 	{
 		//nolint:nestif
-		if funName, newTokens := scanConstants(ppfmt, input, tokens, []string{"is", "sub"}); newTokens != nil {
+		if funName, newTokens := scanConstants(ppfmt, input, tokens,
+			[]string{"is", "sub", "not_is", "not_sub", "has_suffix", "registered"}); newTokens != nil {
 			newTokens = scanMustConstant(ppfmt, input, newTokens, "(")
 			if newTokens == nil {
 				return nil, nil
 			}
-			ASCIIDomains, newTokens := scanASCIIDomainList(ppfmt, input, newTokens)
+			scanArgs := scanASCIIDomainList
+			if funName == "has_suffix" {
+				scanArgs = scanASCIISuffixList
+			}
+			ASCIIDomains, newTokens := scanArgs(ppfmt, input, newTokens)
 			if newTokens == nil {
 				return nil, nil
 			}
@@ -121,8 +189,10 @@ func scanFactor(ppfmt pp.PP, input string, tokens []string) (predicate, []string
 				return nil, nil
 			}
 
-			return map[string]predicate{
-				"is": func(d domain.Domain) bool {
+			// not_is(...) and not_sub(...) are the negations of is(...) and sub(...).
+			negated := strings.HasPrefix(funName, "not_")
+			pred := map[string]evaluator{
+				"is": func(_ []domain.Domain, d domain.Domain, _ ipnet.Type) bool {
 					asciiD := d.DNSNameASCII()
 					for _, pat := range ASCIIDomains {
 						if pat == asciiD {
@@ -131,7 +201,7 @@ func scanFactor(ppfmt pp.PP, input string, tokens []string) (predicate, []string
 					}
 					return false
 				},
-				"sub": func(d domain.Domain) bool {
+				"sub": func(_ []domain.Domain, d domain.Domain, _ ipnet.Type) bool {
 					asciiD := d.DNSNameASCII()
 					for _, pat := range ASCIIDomains {
 						if hasStrictSuffix(asciiD, pat) {
@@ -140,15 +210,65 @@ func scanFactor(ppfmt pp.PP, input string, tokens []string) (predicate, []string
 					}
 					return false
 				},
-			}[funName], newTokens
+				"has_suffix": func(_ []domain.Domain, d domain.Domain, _ ipnet.Type) bool {
+					aceD := d.ACEEncoded()
+					for _, pat := range ASCIIDomains {
+						if strings.HasSuffix(aceD, pat) {
+							return true
+						}
+					}
+					return false
+				},
+				"registered": func(_ []domain.Domain, d domain.Domain, _ ipnet.Type) bool {
+					registered, ok := registeredDomain(d)
+					if !ok {
+						return false
+					}
+					for _, pat := range ASCIIDomains {
+						if pat == registered {
+							return true
+						}
+					}
+					return false
+				},
+			}[strings.TrimPrefix(funName, "not_")]
+			if negated {
+				return func(batch []domain.Domain, d domain.Domain, ipNet ipnet.Type) bool {
+					return !pred(batch, d, ipNet)
+				}, newTokens
+			}
+			return pred, newTokens
+		}
+	}
+
+	if _, newTokens := scanConstants(ppfmt, input, tokens, []string{"reverse_zone"}); newTokens != nil {
+		return scanReverseZone(ppfmt, input, newTokens)
+	}
+
+	if funName, newTokens := scanConstants(ppfmt, input, tokens, []string{"ip4", "ip6"}); newTokens != nil {
+		// The empty argument list is optional: both "ip4" and "ip4()" are accepted.
+		if _, afterParen := scanConstants(ppfmt, input, newTokens, []string{"("}); afterParen != nil {
+			newTokens = scanMustConstant(ppfmt, input, afterParen, ")")
+			if newTokens == nil {
+				return nil, nil
+			}
 		}
+
+		wanted := map[string]ipnet.Type{"ip4": ipnet.IP4, "ip6": ipnet.IP6}[funName]
+		return func(_ []domain.Domain, _ domain.Domain, ipNet ipnet.Type) bool { return ipNet == wanted }, newTokens
+	}
+
+	if _, newTokens := scanConstants(ppfmt, input, tokens, []string{"count"}); newTokens != nil {
+		return scanCount(ppfmt, input, newTokens)
 	}
 
 	{
 		_, newTokens := scanConstants(ppfmt, input, tokens, []string{"!"})
 		if newTokens != nil {
 			if pred, newTokens := scanFactor(ppfmt, input, newTokens); newTokens != nil {
-				return func(d domain.Domain) bool { return !(pred(d)) }, newTokens
+				return func(batch []domain.Domain, d domain.Domain, ipNet ipnet.Type) bool {
+					return !(pred(batch, d, ipNet))
+				}, newTokens
 			}
 			return nil, nil
 		}
@@ -177,10 +297,122 @@ func scanFactor(ppfmt pp.PP, input string, tokens []string) (predicate, []string
 	return nil, nil
 }
 
+// scanReverseZone scans the arguments of reverse_zone(...), which are CIDRs. The result holds if the domain
+// is a reverse zone (under "in-addr.arpa" or "ip6.arpa") covering any of the CIDRs.
+func scanReverseZone(ppfmt pp.PP, input string, tokens []string) (evaluator, []string) {
+	tokens = scanMustConstant(ppfmt, input, tokens, "(")
+	if tokens == nil {
+		return nil, nil
+	}
+	list, tokens := scanList(ppfmt, input, tokens)
+	if tokens == nil {
+		return nil, nil
+	}
+	tokens = scanMustConstant(ppfmt, input, tokens, ")")
+	if tokens == nil {
+		return nil, nil
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, raw := range list {
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: %q is not a valid CIDR: %v", input, raw, err)
+			return nil, nil
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return func(_ []domain.Domain, d domain.Domain, _ ipnet.Type) bool {
+		fqdn, ok := d.(domain.FQDN)
+		if !ok {
+			return false
+		}
+		zone, ok := domain.ReverseZonePrefix(fqdn)
+		if !ok {
+			return false
+		}
+		for _, prefix := range prefixes {
+			if zone.Bits() <= prefix.Bits() && zone.Contains(prefix.Addr()) {
+				return true
+			}
+		}
+		return false
+	}, tokens
+}
+
+// scanCount scans the arguments of count(...) with this grammar:
+//
+//	<count> --> "count" "(" <expression> "," <min> "," <max> ")"
+//
+// The result holds if the number of domains in the batch matching the expression is between
+// <min> and <max>, inclusively.
+func scanCount(ppfmt pp.PP, input string, tokens []string) (evaluator, []string) {
+	tokens = scanMustConstant(ppfmt, input, tokens, "(")
+	if tokens == nil {
+		return nil, nil
+	}
+	pred, tokens := scanExpression(ppfmt, input, tokens)
+	if tokens == nil {
+		return nil, nil
+	}
+	tokens = scanMustConstant(ppfmt, input, tokens, ",")
+	if tokens == nil {
+		return nil, nil
+	}
+	lower, tokens := scanNatural(ppfmt, input, tokens)
+	if tokens == nil {
+		return nil, nil
+	}
+	tokens = scanMustConstant(ppfmt, input, tokens, ",")
+	if tokens == nil {
+		return nil, nil
+	}
+	upper, tokens := scanNatural(ppfmt, input, tokens)
+	if tokens == nil {
+		return nil, nil
+	}
+	tokens = scanMustConstant(ppfmt, input, tokens, ")")
+	if tokens == nil {
+		return nil, nil
+	}
+
+	if lower > upper {
+		ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: the minimum %d is larger than the maximum %d",
+			input, lower, upper)
+		return nil, nil
+	}
+
+	return func(batch []domain.Domain, _ domain.Domain, ipNet ipnet.Type) bool {
+		count := 0
+		for _, other := range batch {
+			if pred(batch, other, ipNet) {
+				count++
+			}
+		}
+		return lower <= count && count <= upper
+	}, tokens
+}
+
+// scanNatural scans a non-negative integer.
+func scanNatural(ppfmt pp.PP, input string, tokens []string) (int, []string) {
+	if len(tokens) == 0 {
+		ppfmt.Errorf(pp.EmojiUserError,
+			"Failed to parse %q: wanted a non-negative integer; reached end of string", input)
+		return 0, nil
+	}
+	n, err := strconv.Atoi(tokens[0])
+	if err != nil || n < 0 {
+		ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: wanted a non-negative integer; got %q", input, tokens[0])
+		return 0, nil
+	}
+	return n, tokens[1:]
+}
+
 // scanTerm scans a term with this grammar:
 //
 //	<term> --> <factor> "&&" <term> | <factor>
-func scanTerm(ppfmt pp.PP, input string, tokens []string) (predicate, []string) {
+func scanTerm(ppfmt pp.PP, input string, tokens []string) (evaluator, []string) {
 	// fmt.Printf("scanTerm(tokens = %#v)\n", tokens)
 
 	pred1, tokens := scanFactor(ppfmt, input, tokens)
@@ -195,7 +427,9 @@ func scanTerm(ppfmt pp.PP, input string, tokens []string) (predicate, []string)
 
 	pred2, newTokens := scanTerm(ppfmt, input, newTokens)
 	if newTokens != nil {
-		return func(d domain.Domain) bool { return pred1(d) && pred2(d) }, newTokens
+		return func(batch []domain.Domain, d domain.Domain, ipNet ipnet.Type) bool {
+			return pred1(batch, d, ipNet) && pred2(batch, d, ipNet)
+		}, newTokens
 	}
 
 	return nil, nil
@@ -204,7 +438,7 @@ func scanTerm(ppfmt pp.PP, input string, tokens []string) (predicate, []string)
 // scanExpression scans an expression with this grammar:
 //
 //	<expression> --> <term> "||" <expression> | <term>
-func scanExpression(ppfmt pp.PP, input string, tokens []string) (predicate, []string) {
+func scanExpression(ppfmt pp.PP, input string, tokens []string) (evaluator, []string) {
 	pred1, tokens := scanTerm(ppfmt, input, tokens)
 	if tokens == nil {
 		return nil, nil
@@ -217,13 +451,23 @@ func scanExpression(ppfmt pp.PP, input string, tokens []string) (predicate, []st
 
 	pred2, newTokens := scanExpression(ppfmt, input, newTokens)
 	if newTokens != nil {
-		return func(d domain.Domain) bool { return pred1(d) || pred2(d) }, newTokens
+		return func(batch []domain.Domain, d domain.Domain, ipNet ipnet.Type) bool {
+			return pred1(batch, d, ipNet) || pred2(batch, d, ipNet)
+		}, newTokens
 	}
 
 	return nil, nil
 }
 
+// ParseList parses a comma-separated list of domains. Environment variables in the form ${VAR}
+// are expanded first, and Markdown list items are also accepted.
 func ParseList(ppfmt pp.PP, input string) ([]domain.Domain, bool) {
+	input, ok := expandEnv(ppfmt, input)
+	if !ok {
+		return nil, false
+	}
+	input = stripListMarkers(input)
+
 	tokens, ok := tokenize(ppfmt, input)
 	if !ok {
 		return nil, false
@@ -240,19 +484,25 @@ func ParseList(ppfmt pp.PP, input string) ([]domain.Domain, bool) {
 	return list, true
 }
 
-func ParseExpression(ppfmt pp.PP, input string) (predicate, bool) {
+// ParseExpression parses a boolean expression over domains and IP networks into an Expression.
+func ParseExpression(ppfmt pp.PP, input string) (Expression, bool) {
 	tokens, ok := tokenize(ppfmt, input)
 	if !ok {
-		return nil, false
+		return Expression{eval: nil}, false
 	}
 
+	return parseTokens(ppfmt, input, tokens)
+}
+
+// parseTokens parses the tokens of a boolean expression into an Expression.
+func parseTokens(ppfmt pp.PP, input string, tokens []string) (Expression, bool) {
 	pred, tokens := scanExpression(ppfmt, input, tokens)
 	if tokens == nil {
-		return nil, false
+		return Expression{eval: nil}, false
 	} else if len(tokens) > 0 {
 		ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: unexpected token %q", input, tokens[0])
-		return nil, false
+		return Expression{eval: nil}, false
 	}
 
-	return pred, true
+	return Expression{eval: pred}, true
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/domainexp"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/mocks"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
@@ -37,6 +38,16 @@ func TestParseList(t *testing.T) {
 				)
 			},
 		},
+		"markdown/dash":     {"- example.com", true, ds{f("example.com")}, nil},
+		"markdown/wildcard": {"* *.example.org", true, ds{w("example.org")}, nil},
+		"markdown/list": {
+			"\n  - a.org\n  + b.org\n  * *.c.org\n", true,
+			ds{f("a.org"), f("b.org"), w("c.org")}, nil,
+		},
+		"markdown/mixed": {
+			"a.org, b.org\n- c.org\n*.d.org, e.org\n* f.org", true,
+			ds{f("a.org"), f("b.org"), f("c.org"), w("d.org"), f("e.org"), f("f.org")}, nil,
+		},
 		"illformed/1": {
 			"&", false, nil,
 			func(m *mocks.MockPP) {
@@ -61,6 +72,67 @@ func TestParseList(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // environment vars are global
+func TestParseListExpandEnv(t *testing.T) {
+	type f = domain.FQDN
+	type ds = []domain.Domain
+
+	t.Setenv("DOMAINEXP_TEST_INTERNAL", "a, b")
+	t.Setenv("DOMAINEXP_TEST_EXTRA", "c")
+	t.Setenv("DOMAINEXP_TEST_CIRCULAR", "${DOMAINEXP_TEST_CIRCULAR}")
+
+	for name, tc := range map[string]struct {
+		input         string
+		ok            bool
+		expected      ds
+		prepareMockPP func(m *mocks.MockPP)
+	}{
+		"single":   {"${DOMAINEXP_TEST_INTERNAL}", true, ds{f("a"), f("b")}, nil},
+		"multiple": {"${DOMAINEXP_TEST_INTERNAL},${DOMAINEXP_TEST_EXTRA}, d", true, ds{f("a"), f("b"), f("c"), f("d")}, nil},
+		"unset":    {"${DOMAINEXP_TEST_UNSET}, d", true, ds{f("d")}, nil},
+		"dollar": {
+			"$DOMAINEXP_TEST_EXTRA", true, ds{f("$domainexp_test_extra")},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Warningf(pp.EmojiUserError, "Domain %q was added but it is ill-formed: %v",
+					"$domainexp_test_extra", gomock.Any())
+			},
+		},
+		"circular": {
+			"${DOMAINEXP_TEST_CIRCULAR}", false, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError,
+					`Failed to parse %q: the value of %s refers to other variables, which might be circular`,
+					"${DOMAINEXP_TEST_CIRCULAR}", "DOMAINEXP_TEST_CIRCULAR")
+			},
+		},
+		"unterminated": {
+			"${DOMAINEXP_TEST_EXTRA", false, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: missing "}" after "${"`, "${DOMAINEXP_TEST_EXTRA")
+			},
+		},
+		"empty": {
+			"${}", false, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: empty variable name in "${}"`, "${}")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			list, ok := domainexp.ParseList(mockPP, tc.input)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, list)
+		})
+	}
+}
+
 //nolint:funlen
 func TestParseExpression(t *testing.T) {
 	t.Parallel()
@@ -70,106 +142,158 @@ func TestParseExpression(t *testing.T) {
 		input         string
 		ok            bool
 		domain        domain.Domain
+		ipNet         ipnet.Type
 		expected      bool
 		prepareMockPP func(m *mocks.MockPP)
 	}{
 		"empty": {
-			"", false, nil, true,
+			"", false, nil, ipnet.IP4, true,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: wanted a boolean expression; reached end of string`, "")
 			},
 		},
-		"const/1": {"true", true, nil, true, nil},
-		"const/2": {"f", true, nil, false, nil},
-		"&&/1":    {"t && 0", true, nil, false, nil},
+		"const/1": {"true", true, nil, ipnet.IP4, true, nil},
+		"const/2": {"f", true, nil, ipnet.IP4, false, nil},
+		"&&/1":    {"t && 0", true, nil, ipnet.IP4, false, nil},
 		"&&/2": {
-			"t &&", false, nil, false,
+			"t &&", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: wanted a boolean expression; reached end of string`, "t &&") //nolint:lll
 			},
 		},
 		"&&/&/1": {
-			"true & true", false, nil, false,
+			"true & true", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "true & true", domainexp.ErrSingleAnd)
 			},
 		},
 		"&&/&/2": {
-			"true &", false, nil, false,
+			"true &", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "true &", domainexp.ErrSingleAnd)
 			},
 		},
-		"||/1": {"F || 1", true, nil, true, nil},
+		"||/1": {"F || 1", true, nil, ipnet.IP4, true, nil},
 		"||/2": {
-			"F ||", false, nil, false,
+			"F ||", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: wanted a boolean expression; reached end of string`, "F ||") //nolint:lll
 			},
 		},
 		"||/|/1": {
-			"false | false", false, nil, false,
+			"false | false", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "false | false", domainexp.ErrSingleOr)
 			},
 		},
 		"||/|/2": {
-			"false |", false, nil, false,
+			"false |", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %v", "false |", domainexp.ErrSingleOr)
 			},
 		},
-		"is/1":          {"is(example.com)", true, f("example.com"), true, nil},
-		"is/2":          {"is(example.com)", true, f("sub.example.com"), false, nil},
-		"is/3":          {"is(example.org)", true, f("example.com"), false, nil},
-		"is/wildcard/1": {"is(example.com)", true, w("example.com"), false, nil},
-		"is/wildcard/2": {"is(*.example.com)", true, w("example.com"), true, nil},
-		"is/wildcard/3": {"is(*.example.com)", true, f("example.com"), false, nil},
-		"is/idn/1":      {"is(☕.de)", true, f("xn--53h.de"), true, nil},
-		"is/idn/2":      {"is(Xn--53H.de)", true, f("xn--53h.de"), true, nil},
-		"is/idn/3":      {"is(*.Xn--53H.de)", true, w("xn--53h.de"), true, nil},
+		"is/1":          {"is(example.com)", true, f("example.com"), ipnet.IP4, true, nil},
+		"is/2":          {"is(example.com)", true, f("sub.example.com"), ipnet.IP4, false, nil},
+		"is/3":          {"is(example.org)", true, f("example.com"), ipnet.IP4, false, nil},
+		"is/wildcard/1": {"is(example.com)", true, w("example.com"), ipnet.IP4, false, nil},
+		"is/wildcard/2": {"is(*.example.com)", true, w("example.com"), ipnet.IP4, true, nil},
+		"is/wildcard/3": {"is(*.example.com)", true, f("example.com"), ipnet.IP4, false, nil},
+		"is/idn/1":      {"is(☕.de)", true, f("xn--53h.de"), ipnet.IP6, true, nil},
+		"is/idn/2":      {"is(Xn--53H.de)", true, f("xn--53h.de"), ipnet.IP6, true, nil},
+		"is/idn/3":      {"is(*.Xn--53H.de)", true, w("xn--53h.de"), ipnet.IP6, true, nil},
 		"is/error/1": {
-			"is)", false, nil, false,
+			"is)", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: wanted %q; got %q`, "is)", "(", ")")
 			},
 		},
 		"is/error/2": {
-			"is(&&", false, nil, false,
+			"is(&&", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: unexpected token %q`, "is(&&", "&&")
 			},
 		},
-		"sub/1":     {"sub(example.com)", true, f("example.com"), false, nil},
-		"sub/2":     {"sub(example.com)", true, w("example.com"), true, nil},
-		"sub/3":     {"sub(example.com)", true, f("sub.example.com"), true, nil},
-		"sub/4":     {"sub(example.com)", true, f("subexample.com"), false, nil},
-		"sub/idn/1": {"sub(☕.de)", true, f("www.xn--53h.de"), true, nil},
-		"sub/idn/2": {"sub(Xn--53H.de)", true, f("www.xn--53h.de"), true, nil},
-		"sub/idn/3": {"sub(Xn--53H.de)", true, w("xn--53h.de"), true, nil},
-		"not/1":     {"!0", true, nil, true, nil},
-		"not/2":     {"!!!!!!!!!!!0", true, nil, true, nil},
+		"not_is/1":        {"not_is(example.com)", true, f("example.com"), ipnet.IP4, false, nil},
+		"not_is/2":        {"not_is(example.com)", true, f("sub.example.com"), ipnet.IP4, true, nil},
+		"not_is/3":        {"not_is(example.com, example.org)", true, f("example.org"), ipnet.IP4, false, nil},
+		"not_is/wildcard": {"not_is(*.example.com)", true, w("example.com"), ipnet.IP4, false, nil},
+		"not_sub/1":       {"not_sub(example.com)", true, f("example.com"), ipnet.IP4, true, nil},
+		"not_sub/2":       {"not_sub(example.com)", true, w("example.com"), ipnet.IP4, false, nil},
+		"not_sub/3":       {"not_sub(example.com)", true, f("sub.example.com"), ipnet.IP4, false, nil},
+		"not_sub/4":       {"not_sub(example.com, example.org)", true, f("sub.example.org"), ipnet.IP4, false, nil},
+		"not_sub/ip4":     {"not_sub(example.com) && ip4", true, f("example.com"), ipnet.IP4, true, nil},
+		"not_sub/error": {
+			"not_sub(example.com", false, nil, ipnet.IP4, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: wanted %q; reached end of string`,
+					"not_sub(example.com", ")")
+			},
+		},
+		"sub/1":               {"sub(example.com)", true, f("example.com"), ipnet.IP4, false, nil},
+		"sub/2":               {"sub(example.com)", true, w("example.com"), ipnet.IP4, true, nil},
+		"sub/3":               {"sub(example.com)", true, f("sub.example.com"), ipnet.IP4, true, nil},
+		"sub/4":               {"sub(example.com)", true, f("subexample.com"), ipnet.IP4, false, nil},
+		"sub/idn/1":           {"sub(☕.de)", true, f("www.xn--53h.de"), ipnet.IP6, true, nil},
+		"sub/idn/2":           {"sub(Xn--53H.de)", true, f("www.xn--53h.de"), ipnet.IP6, true, nil},
+		"sub/idn/3":           {"sub(Xn--53H.de)", true, w("xn--53h.de"), ipnet.IP6, true, nil},
+		"has_suffix/1":        {"has_suffix(.example.com)", true, f("sub.example.com"), ipnet.IP4, true, nil},
+		"has_suffix/2":        {"has_suffix(.example.com)", true, f("example.com"), ipnet.IP4, false, nil},
+		"has_suffix/3":        {"has_suffix(.example.com)", true, f("badexample.com"), ipnet.IP4, false, nil},
+		"has_suffix/4":        {"has_suffix(example.com)", true, f("badexample.com"), ipnet.IP4, true, nil},
+		"has_suffix/5":        {"has_suffix(.co.uk, .example.com)", true, f("a.co.uk"), ipnet.IP4, true, nil},
+		"has_suffix/wildcard": {"has_suffix(.example.com)", true, w("example.com"), ipnet.IP4, true, nil},
+		"has_suffix/idn/1":    {"has_suffix(.☕.de)", true, f("www.xn--53h.de"), ipnet.IP6, true, nil},
+		"has_suffix/idn/2":    {"has_suffix(.Xn--53H.de)", true, w("xn--53h.de"), ipnet.IP6, true, nil},
+		"has_suffix/idn/3":    {"has_suffix(.☕.de)", true, f("xn--53h.de"), ipnet.IP6, false, nil},
+		"registered/1":        {"registered(example.co.uk)", true, f("a.b.example.co.uk"), ipnet.IP4, true, nil},
+		"registered/2":        {"registered(example.co.uk)", true, f("example.co.uk"), ipnet.IP4, true, nil},
+		"registered/3":        {"registered(example.co.uk)", true, f("other.co.uk"), ipnet.IP4, false, nil},
+		"registered/4":        {"registered(co.uk)", true, f("example.co.uk"), ipnet.IP4, false, nil},
+		"registered/5":        {"registered(user.github.io)", true, f("sub.user.github.io"), ipnet.IP4, true, nil},
+		"registered/6":        {"registered(example.com)", true, f("com"), ipnet.IP4, false, nil},
+		"registered/wildcard": {"registered(example.co.uk)", true, w("example.co.uk"), ipnet.IP6, true, nil},
+		"registered/idn":      {"registered(日本.co.jp)", true, f("www.xn--wgv71a.co.jp"), ipnet.IP6, true, nil},
+		"ip4/1":               {"ip4", true, f("example.com"), ipnet.IP4, true, nil},
+		"ip4/2":               {"ip4()", true, f("example.com"), ipnet.IP6, false, nil},
+		"ip6/1":               {"ip6", true, f("example.com"), ipnet.IP4, false, nil},
+		"ip6/2":               {"ip6()", true, f("example.com"), ipnet.IP6, true, nil},
+		"ip4/sub/1":           {"sub(example.com) && ip4", true, f("sub.example.com"), ipnet.IP4, true, nil},
+		"ip4/sub/2":           {"sub(example.com) && ip4", true, f("sub.example.com"), ipnet.IP6, false, nil},
+		"ip4/sub/3":           {"ip4 && sub(example.com)", true, f("sub.example.com"), ipnet.IP4, true, nil},
+		"ip4/sub/4":           {"ip4 && sub(example.com)", true, f("sub.example.com"), ipnet.IP6, false, nil},
+		"ip4/sub/5":           {"ip4 && sub(example.com)", true, f("example.com"), ipnet.IP4, false, nil},
+		"ip4/sub/6":           {"ip4 && sub(example.com)", true, f("sub.example.org"), ipnet.IP4, false, nil},
+		"ip6/not":             {"!ip6 || is(example.com)", true, f("example.com"), ipnet.IP6, true, nil},
+		"ip4/error": {
+			"ip4(example.com)", false, nil, ipnet.IP4, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: wanted %q; got %q`,
+					"ip4(example.com)", ")", "example.com")
+			},
+		},
+		"not/1": {"!0", true, nil, ipnet.IP4, true, nil},
+		"not/2": {"!!!!!!!!!!!0", true, nil, ipnet.IP4, true, nil},
 		"not/3": {
-			"!(", false, nil, true,
+			"!(", false, nil, ipnet.IP4, true,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: wanted a boolean expression; reached end of string", "!(")
 			},
 		},
-		"nested/1": {"((true)||(false))&&((false)||(true))", true, nil, true, nil},
+		"nested/1": {"((true)||(false))&&((false)||(true))", true, nil, ipnet.IP4, true, nil},
 		"nested/2": {
-			"((", false, nil, true,
+			"((", false, nil, ipnet.IP4, true,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: wanted a boolean expression; reached end of string", "((")
 			},
 		},
 		"nested/3": {
-			"(true", false, nil, true,
+			"(true", false, nil, ipnet.IP4, true,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: wanted %q; reached end of string", "(true", ")")
 			},
 		},
 		"error/extra": {
-			"0 1", false, nil, false,
+			"0 1", false, nil, ipnet.IP4, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: unexpected token %q", "0 1", "1")
 			},
@@ -188,8 +312,191 @@ func TestParseExpression(t *testing.T) {
 			pred, ok := domainexp.ParseExpression(mockPP, tc.input)
 			require.Equal(t, tc.ok, ok)
 			if ok {
-				require.Equal(t, tc.expected, pred(tc.domain))
+				require.Equal(t, tc.expected, pred.Match(tc.domain, tc.ipNet))
+			}
+		})
+	}
+}
+
+func TestParseExpressionNegatedAliases(t *testing.T) {
+	t.Parallel()
+
+	domains := []domain.Domain{
+		domain.FQDN("example.com"),
+		domain.FQDN("sub.example.com"),
+		domain.FQDN("example.org"),
+		domain.Wildcard("example.com"),
+	}
+
+	for alias, negation := range map[string]string{
+		"not_is(example.com, *.example.com)": "!is(example.com, *.example.com)",
+		"not_sub(example.com)":               "!sub(example.com)",
+	} {
+		alias, negation := alias, negation
+		t.Run(alias, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+
+			aliasPred, ok := domainexp.ParseExpression(mockPP, alias)
+			require.True(t, ok)
+			negationPred, ok := domainexp.ParseExpression(mockPP, negation)
+			require.True(t, ok)
+
+			for _, d := range domains {
+				for _, ipNet := range ipnet.All() {
+					require.Equal(t, negationPred.Match(d, ipNet), aliasPred.Match(d, ipNet))
+				}
 			}
 		})
 	}
 }
+
+func TestParseExpressionCount(t *testing.T) {
+	t.Parallel()
+	type f = domain.FQDN
+	batch := []domain.Domain{f("a.example.com"), f("b.example.com"), f("c.example.com"), f("example.org")}
+	for name, tc := range map[string]struct {
+		input    string
+		expected bool
+	}{
+		"min":   {"count(sub(example.com), 3, 5)", true},
+		"max":   {"count(sub(example.com), 1, 3)", true},
+		"below": {"count(sub(example.com), 4, 5)", false},
+		"above": {"count(sub(example.com), 0, 2)", false},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+
+			expr, ok := domainexp.ParseExpression(mockPP, tc.input)
+			require.True(t, ok)
+			results := expr.MatchBatch(batch, ipnet.IP4)
+			require.Len(t, results, len(batch))
+			for _, d := range batch {
+				require.Equal(t, tc.expected, results[d])
+			}
+		})
+	}
+}
+
+func TestParseExpressionCountPointwise(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	expr, ok := domainexp.ParseExpression(mockPP, "is(example.org) || count(sub(example.com), 1, 1)")
+	require.True(t, ok)
+	require.True(t, expr.Match(domain.FQDN("example.org"), ipnet.IP4))
+	require.True(t, expr.Match(domain.FQDN("a.example.com"), ipnet.IP4))
+	require.False(t, expr.Match(domain.FQDN("example.net"), ipnet.IP4))
+	require.Equal(t,
+		map[domain.Domain]bool{
+			domain.FQDN("example.org"):   true,
+			domain.FQDN("a.example.com"): false,
+			domain.FQDN("b.example.com"): false,
+		},
+		expr.MatchBatch([]domain.Domain{
+			domain.FQDN("example.org"), domain.FQDN("a.example.com"), domain.FQDN("b.example.com"),
+		}, ipnet.IP4))
+}
+
+func TestParseExpressionCountErrors(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input         string
+		prepareMockPP func(m *mocks.MockPP)
+	}{
+		"negative": {
+			"count(true, -1, 2)",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: wanted a non-negative integer; got %q",
+					"count(true, -1, 2)", "-1")
+			},
+		},
+		"missing-max": {
+			"count(true, 1",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, `Failed to parse %q: wanted %q; reached end of string`,
+					"count(true, 1", ",")
+			},
+		},
+		"end": {
+			"count(true, 1,",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError,
+					"Failed to parse %q: wanted a non-negative integer; reached end of string", "count(true, 1,")
+			},
+		},
+		"inverted": {
+			"count(true, 3, 2)",
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: the minimum %d is larger than the maximum %d",
+					"count(true, 3, 2)", 3, 2)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+
+			_, ok := domainexp.ParseExpression(mockPP, tc.input)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestParseExpressionReverseZone(t *testing.T) {
+	t.Parallel()
+	type f = domain.FQDN
+	type w = domain.Wildcard
+	for name, tc := range map[string]struct {
+		input    string
+		domain   domain.Domain
+		expected bool
+	}{
+		"ip4/24/exact":     {"reverse_zone(192.0.2.0/24)", f("2.0.192.in-addr.arpa"), true},
+		"ip4/24/covering":  {"reverse_zone(192.0.2.0/24)", f("0.192.in-addr.arpa"), true},
+		"ip4/24/narrower":  {"reverse_zone(192.0.2.0/24)", f("1.2.0.192.in-addr.arpa"), false},
+		"ip4/24/other":     {"reverse_zone(192.0.2.0/24)", f("3.0.192.in-addr.arpa"), false},
+		"ip4/24/unmasked":  {"reverse_zone(192.0.2.1/24)", f("2.0.192.in-addr.arpa"), true},
+		"ip6/64/exact":     {"reverse_zone(2001:db8::/64)", f("0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"), true},
+		"ip6/64/covering":  {"reverse_zone(2001:db8::/64)", f("8.b.d.0.1.0.0.2.ip6.arpa"), true},
+		"ip6/64/other":     {"reverse_zone(2001:db8::/64)", f("1.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"), false},
+		"ip6/64/ip4-zone":  {"reverse_zone(2001:db8::/64)", f("2.0.192.in-addr.arpa"), false},
+		"list":             {"reverse_zone(192.0.2.0/24, 2001:db8::/64)", f("8.b.d.0.1.0.0.2.ip6.arpa"), true},
+		"non-reverse":      {"reverse_zone(192.0.2.0/24)", f("example.org"), false},
+		"non-reverse/wild": {"reverse_zone(192.0.2.0/24)", w("2.0.192.in-addr.arpa"), false},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+
+			expr, ok := domainexp.ParseExpression(mockPP, tc.input)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, expr.Match(tc.domain, ipnet.IP6))
+		})
+	}
+}
+
+func TestParseExpressionReverseZoneInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: %q is not a valid CIDR: %v",
+		"reverse_zone(192.0.2.0)", "192.0.2.0", gomock.Any())
+	_, ok := domainexp.ParseExpression(mockPP, "reverse_zone(192.0.2.0)")
+	require.False(t, ok)
+}
@@ -0,0 +1,53 @@
+package domainexp
+
+import (
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A Predicate is a compiled boolean expression that remembers its source, so that it can be
+// stored, compared, and printed. Use ParseExpression when only the Expression is needed.
+type Predicate struct {
+	input  string
+	tokens []string
+	expr   Expression
+}
+
+// Compile parses a boolean expression into a Predicate. See ParseExpression.
+func Compile(ppfmt pp.PP, input string) (*Predicate, bool) {
+	expr, ok := ParseExpression(ppfmt, input)
+	if !ok {
+		return nil, false
+	}
+
+	// ParseExpression has already accepted the input, so tokenize cannot fail here.
+	tokens, _ := tokenize(ppfmt, input)
+
+	return &Predicate{input: input, tokens: tokens, expr: expr}, true
+}
+
+// Eval decides whether the predicate holds for the domain and the IP network.
+func (p Predicate) Eval(d domain.Domain, ipNet ipnet.Type) bool {
+	return p.expr.Match(d, ipNet)
+}
+
+// String returns the expression exactly as it was given to Compile. Aliases such as "TRUE",
+// "ip4()", and "not_is(...)" are not rewritten to their canonical forms.
+func (p Predicate) String() string {
+	return p.input
+}
+
+// Equal checks whether the two predicates were compiled from the same expression,
+// ignoring the differences in whitespace. Logically equivalent expressions are not necessarily equal.
+func (p Predicate) Equal(other Predicate) bool {
+	if len(p.tokens) != len(other.tokens) {
+		return false
+	}
+	for i := range p.tokens {
+		if p.tokens[i] != other.tokens[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package domainexp_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/domainexp"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	p, ok := domainexp.Compile(mockPP, "sub(example.com) && ip6")
+	require.True(t, ok)
+	require.Equal(t, "sub(example.com) && ip6", p.String())
+	require.True(t, p.Eval(domain.FQDN("a.example.com"), ipnet.IP6))
+	require.False(t, p.Eval(domain.FQDN("a.example.com"), ipnet.IP4))
+	require.False(t, p.Eval(domain.FQDN("example.org"), ipnet.IP6))
+}
+
+func TestCompileInvalid(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	mockPP.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: unexpected token %q", "true false", "false")
+	p, ok := domainexp.Compile(mockPP, "true false")
+	require.False(t, ok)
+	require.Nil(t, p)
+}
+
+func TestPredicateString(t *testing.T) {
+	t.Parallel()
+
+	for name, input := range map[string]string{
+		"true":       "TRUE",
+		"false":      "0",
+		"ip4":        "ip4()",
+		"not_is":     "not_is(example.com)",
+		"not_sub":    "not_sub(example.com) && T",
+		"whitespace": " is( example.com ) || ip6 ",
+	} {
+		input := input
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+
+			p, ok := domainexp.Compile(mockPP, input)
+			require.True(t, ok)
+			require.Equal(t, input, p.String())
+		})
+	}
+}
+
+func TestPredicateEqual(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		input1   string
+		input2   string
+		expected bool
+	}{
+		"same":       {"is(example.com)", "is(example.com)", true},
+		"whitespace": {"is(example.com)||ip4", " is( example.com ) || ip4 ", true},
+		"different":  {"is(example.com)", "is(example.org)", false},
+		"prefix":     {"is(example.com)", "is(example.com) && ip4", false},
+		"equivalent": {"ip4 || ip6", "ip6 || ip4", false},
+		"alias":      {"not_is(example.com)", "!is(example.com)", false},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+
+			p1, ok := domainexp.Compile(mockPP, tc.input1)
+			require.True(t, ok)
+			p2, ok := domainexp.Compile(mockPP, tc.input2)
+			require.True(t, ok)
+
+			require.Equal(t, tc.expected, p1.Equal(*p2))
+			require.Equal(t, tc.expected, p2.Equal(*p1))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package domainexp
+
+import (
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// listFunctions are the functions whose arguments are lists (of domains or CIDRs) instead of expressions.
+//
+//nolint:gochecknoglobals
+var listFunctions = map[string]bool{
+	"is": true, "sub": true, "not_is": true, "not_sub": true, "has_suffix": true, "registered": true,
+	"reverse_zone": true,
+}
+
+// isReserved checks whether a name has a meaning in boolean expressions.
+func isReserved(name string) bool {
+	switch name {
+	case "t", "T", "TRUE", "true", "True", "f", "F", "FALSE", "false", "False", "ip4", "ip6", "count":
+		return true
+	default:
+		return listFunctions[name]
+	}
+}
+
+// isIdentifier checks whether a name consists of ASCII letters, digits, and underscores,
+// and does not start with a digit.
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// substituteVars replaces the variables in the tokens with the constants "true" and "false".
+// The arguments of is(...), sub(...), and other list functions are domains, not expressions,
+// and thus are never replaced. Any other identifier that is neither reserved nor a variable is an error.
+func substituteVars(ppfmt pp.PP, input string, tokens []string, vars map[string]bool) ([]string, bool) {
+	substituted := make([]string, 0, len(tokens))
+	inList := false
+	for i, token := range tokens {
+		switch {
+		case inList:
+			inList = token != ")"
+		case listFunctions[token] && i+1 < len(tokens) && tokens[i+1] == "(":
+			inList = true
+		default:
+			if value, found := vars[token]; found {
+				if value {
+					token = "true"
+				} else {
+					token = "false"
+				}
+			} else if isIdentifier(token) && !isReserved(token) {
+				ppfmt.Errorf(pp.EmojiUserError, "Failed to parse %q: undefined variable %q", input, token)
+				return nil, false
+			}
+		}
+		substituted = append(substituted, token)
+	}
+	return substituted, true
+}
+
+// ParseExpressionWithVars is like Compile, but the expression may refer to the boolean variables,
+// such as WANT_V4 in "WANT_V4 && is(example.com)". The variables are replaced by their values
+// as constants before parsing. A variable name must be an identifier that is not already
+// meaningful in expressions (such as "ip4" or "true"). Using an undefined variable is an error.
+// Predicates using the same variables with different values are not Equal.
+func ParseExpressionWithVars(ppfmt pp.PP, input string, vars map[string]bool) (*Predicate, bool) {
+	for name := range vars {
+		switch {
+		case !isIdentifier(name):
+			ppfmt.Errorf(pp.EmojiUserError, "Invalid variable name %q", name)
+			return nil, false
+		case isReserved(name):
+			ppfmt.Errorf(pp.EmojiUserError, "The variable name %q is reserved", name)
+			return nil, false
+		}
+	}
+
+	tokens, ok := tokenize(ppfmt, input)
+	if !ok {
+		return nil, false
+	}
+	tokens, ok = substituteVars(ppfmt, input, tokens, vars)
+	if !ok {
+		return nil, false
+	}
+
+	expr, ok := parseTokens(ppfmt, input, tokens)
+	if !ok {
+		return nil, false
+	}
+
+	return &Predicate{input: input, tokens: tokens, expr: expr}, true
+}
@@ -0,0 +1,112 @@
+package domainexp_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/domainexp"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestParseExpressionWithVars(t *testing.T) {
+	t.Parallel()
+	type f = domain.FQDN
+	for name, tc := range map[string]struct {
+		input    string
+		vars     map[string]bool
+		domain   domain.Domain
+		expected bool
+	}{
+		"true":       {"WANT_PROXY", map[string]bool{"WANT_PROXY": true}, f("example.com"), true},
+		"false":      {"WANT_PROXY", map[string]bool{"WANT_PROXY": false}, f("example.com"), false},
+		"combined/1": {"A && !B", map[string]bool{"A": true, "B": false}, f("example.com"), true},
+		"combined/2": {"A && is(example.com)", map[string]bool{"A": true}, f("example.org"), false},
+		"count":      {"count(A, 1, 1)", map[string]bool{"A": true}, f("example.com"), true},
+		"list":       {"is(A)", map[string]bool{"A": false}, f("a"), true},
+		"unused":     {"true", map[string]bool{"A": false}, f("a"), true},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+
+			p, ok := domainexp.ParseExpressionWithVars(mockPP, tc.input, tc.vars)
+			require.True(t, ok)
+			require.Equal(t, tc.input, p.String())
+			require.Equal(t, tc.expected, p.Eval(tc.domain, ipnet.IP4))
+		})
+	}
+}
+
+func TestParseExpressionWithVarsEqual(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	p1, ok := domainexp.ParseExpressionWithVars(mockPP, "A || ip6", map[string]bool{"A": true})
+	require.True(t, ok)
+	p2, ok := domainexp.ParseExpressionWithVars(mockPP, "A || ip6", map[string]bool{"A": false})
+	require.True(t, ok)
+	p3, ok := domainexp.Compile(mockPP, "true || ip6")
+	require.True(t, ok)
+	require.False(t, p1.Equal(*p2))
+	require.True(t, p1.Equal(*p3))
+}
+
+func TestParseExpressionWithVarsInvalid(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input         string
+		vars          map[string]bool
+		prepareMockPP func(m *mocks.MockPP)
+	}{
+		"undefined": {
+			"A && B", map[string]bool{"A": true},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: undefined variable %q", "A && B", "B")
+			},
+		},
+		"undefined/count": {
+			"count(A, B, 1, 1)", map[string]bool{"A": true},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Failed to parse %q: undefined variable %q", "count(A, B, 1, 1)", "B")
+			},
+		},
+		"reserved": {
+			"ip4", map[string]bool{"ip4": false},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "The variable name %q is reserved", "ip4")
+			},
+		},
+		"invalid-name": {
+			"true", map[string]bool{"example.com": false},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Invalid variable name %q", "example.com")
+			},
+		},
+		"numeric-name": {
+			"true", map[string]bool{"1": false},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiUserError, "Invalid variable name %q", "1")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+
+			p, ok := domainexp.ParseExpressionWithVars(mockPP, tc.input, tc.vars)
+			require.False(t, ok)
+			require.Nil(t, p)
+		})
+	}
+}
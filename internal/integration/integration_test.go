@@ -0,0 +1,335 @@
+// Package integration_test runs the whole update flow (detecting IP addresses, looking up zones,
+// listing records, and then creating, updating, or deleting them) against a fake Cloudflare server.
+//
+// The tests are not parallel because the package updater keeps global states.
+//
+//nolint:paralleltest
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/clock"
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+	"github.com/favonia/cloudflare-ddns/internal/setter"
+	"github.com/favonia/cloudflare-ddns/internal/updater"
+)
+
+const mockToken = "token123"
+
+// A FakeCloudflareServer keeps zones and DNS records in memory and serves the parts of
+// the Cloudflare API used by the updater.
+type FakeCloudflareServer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mutex   sync.Mutex
+	zones   map[string]string                           // zone names to zone IDs
+	records map[string]map[string]*cloudflare.DNSRecord // zone IDs to record IDs to records
+	nextID  int
+}
+
+func NewFakeCloudflareServer(t *testing.T) *FakeCloudflareServer {
+	t.Helper()
+
+	s := &FakeCloudflareServer{
+		t:       t,
+		server:  nil,
+		mutex:   sync.Mutex{},
+		zones:   map[string]string{},
+		records: map[string]map[string]*cloudflare.DNSRecord{},
+		nextID:  0,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *FakeCloudflareServer) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%032x", s.nextID)
+}
+
+// AddZone adds an active zone.
+func (s *FakeCloudflareServer) AddZone(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := s.newID()
+	s.zones[name] = id
+	s.records[id] = map[string]*cloudflare.DNSRecord{}
+}
+
+// AddRecord adds a DNS record to a zone and returns its ID.
+func (s *FakeCloudflareServer) AddRecord(zoneName, recordType, name, content string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	zoneID, ok := s.zones[zoneName]
+	require.True(s.t, ok)
+
+	id := s.newID()
+	s.records[zoneID][id] = &cloudflare.DNSRecord{ //nolint:exhaustruct
+		ID: id, Type: recordType, Name: name, Content: content, TTL: 1, ZoneID: zoneID, ZoneName: zoneName,
+	}
+	return id
+}
+
+// Records returns the contents of the DNS records of the type and the name, keyed by record IDs.
+func (s *FakeCloudflareServer) Records(recordType, name string) map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	contents := map[string]string{}
+	for _, rs := range s.records {
+		for id, r := range rs {
+			if r.Type == recordType && r.Name == name {
+				contents[id] = r.Content
+			}
+		}
+	}
+	return contents
+}
+
+func (s *FakeCloudflareServer) reply(w http.ResponseWriter, status int, result any, resultInfo any) {
+	body := map[string]any{
+		"success":  status < http.StatusBadRequest,
+		"errors":   []any{},
+		"messages": []any{},
+		"result":   result,
+	}
+	if status >= http.StatusBadRequest {
+		body["errors"] = []any{map[string]any{"code": 1000, "message": http.StatusText(status)}}
+	}
+	if resultInfo != nil {
+		body["result_info"] = resultInfo
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	require.NoError(s.t, json.NewEncoder(w).Encode(body))
+}
+
+func singlePage(count int) map[string]any {
+	return map[string]any{"page": 1, "per_page": count, "count": count, "total_count": count, "total_pages": 1}
+}
+
+func (s *FakeCloudflareServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	require.Equal(s.t, []string{"Bearer " + mockToken}, r.Header["Authorization"])
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/user/tokens/verify":
+		s.reply(w, http.StatusOK, map[string]any{"id": "token", "status": "active"}, nil)
+
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		s.listZones(w, r)
+
+	case len(path) == 3 && path[0] == "zones" && path[2] == "dns_records":
+		rs, ok := s.records[path[1]]
+		if !ok {
+			s.reply(w, http.StatusNotFound, nil, nil)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			s.listRecords(w, r, rs)
+		case http.MethodPost:
+			s.createRecord(w, r, path[1], rs)
+		default:
+			s.reply(w, http.StatusMethodNotAllowed, nil, nil)
+		}
+
+	case len(path) == 4 && path[0] == "zones" && path[2] == "dns_records":
+		record, ok := s.records[path[1]][path[3]]
+		if !ok {
+			s.reply(w, http.StatusNotFound, nil, nil)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPatch:
+			var patch cloudflare.DNSRecord
+			require.NoError(s.t, json.NewDecoder(r.Body).Decode(&patch))
+			if patch.Content != "" {
+				record.Content = patch.Content
+			}
+			s.reply(w, http.StatusOK, record, nil)
+		case http.MethodDelete:
+			delete(s.records[path[1]], path[3])
+			s.reply(w, http.StatusOK, map[string]any{"id": path[3]}, nil)
+		default:
+			s.reply(w, http.StatusMethodNotAllowed, nil, nil)
+		}
+
+	default:
+		s.reply(w, http.StatusNotFound, nil, nil)
+	}
+}
+
+func (s *FakeCloudflareServer) listZones(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	var zones []cloudflare.Zone
+	if id, ok := s.zones[name]; ok {
+		zones = append(zones, cloudflare.Zone{ID: id, Name: name, Status: "active"}) //nolint:exhaustruct
+	}
+	s.reply(w, http.StatusOK, zones, singlePage(len(zones)))
+}
+
+func (s *FakeCloudflareServer) listRecords(w http.ResponseWriter, r *http.Request,
+	rs map[string]*cloudflare.DNSRecord,
+) {
+	query := r.URL.Query()
+
+	results := []*cloudflare.DNSRecord{}
+	for _, record := range rs {
+		if (query.Get("name") == "" || query.Get("name") == record.Name) &&
+			(query.Get("type") == "" || query.Get("type") == record.Type) {
+			results = append(results, record)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+
+	s.reply(w, http.StatusOK, results, singlePage(len(results)))
+}
+
+func (s *FakeCloudflareServer) createRecord(w http.ResponseWriter, r *http.Request,
+	zoneID string, rs map[string]*cloudflare.DNSRecord,
+) {
+	var record cloudflare.DNSRecord
+	require.NoError(s.t, json.NewDecoder(r.Body).Decode(&record))
+
+	record.ID = s.newID()
+	record.ZoneID = zoneID
+	rs[record.ID] = &record
+	s.reply(w, http.StatusOK, &record, nil)
+}
+
+// staticProvider always detects the same IP address.
+type staticProvider struct{ ip netip.Addr }
+
+func (p *staticProvider) Name() string { return "static" }
+
+func (p *staticProvider) GetIP(context.Context, pp.PP, ipnet.Type) netip.Addr { return p.ip }
+
+// newUpdater prepares the configuration and the setter for updating the A records of the domains.
+func newUpdater(t *testing.T, server *FakeCloudflareServer, p provider.Provider, domains ...domain.Domain,
+) (*config.Config, setter.Setter) {
+	t.Helper()
+
+	ppfmt := pp.New(io.Discard)
+
+	c := config.Default()
+	c.Auth = &api.CloudflareAuth{ //nolint:exhaustruct // Other fields are intentionally omitted
+		Token:              mockToken,
+		BaseURL:            server.server.URL,
+		TokenExpiryWarning: api.DefaultTokenExpiryWarning,
+		Clock:              clock.Real{},
+	}
+	c.Provider = map[ipnet.Type]provider.Provider{ipnet.IP4: p, ipnet.IP6: nil}
+	c.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: domains, ipnet.IP6: nil}
+	c.Proxied = map[ipnet.Type]map[domain.Domain]bool{ipnet.IP4: {}, ipnet.IP6: {}}
+	for _, d := range domains {
+		c.Proxied[ipnet.IP4][d] = false
+	}
+
+	h, ok := c.Auth.New(context.Background(), ppfmt, c.CacheExpiration, time.Second)
+	require.True(t, ok)
+
+	s, ok := setter.New(ppfmt, h, c.MaxRecordsPerDomain, c.IP6PrefixLength, c.IP6HostSuffix, c.RecordCommentTemplate)
+	require.True(t, ok)
+
+	return c, s
+}
+
+func update(c *config.Config, s setter.Setter) bool {
+	return updater.UpdateIPs(context.Background(), pp.New(io.Discard), c, updater.NewState(), s)
+}
+
+func TestFirstRun(t *testing.T) {
+	server := NewFakeCloudflareServer(t)
+	server.AddZone("test.org")
+
+	p := &staticProvider{ip: netip.MustParseAddr("1.1.1.1")}
+	c, s := newUpdater(t, server, p, domain.FQDN("test.org"), domain.FQDN("sub.test.org"))
+
+	require.True(t, update(c, s))
+	for _, name := range [...]string{"test.org", "sub.test.org"} {
+		records := server.Records("A", name)
+		require.Len(t, records, 1)
+		for _, content := range records {
+			require.Equal(t, "1.1.1.1", content)
+		}
+	}
+}
+
+func TestIPChange(t *testing.T) {
+	server := NewFakeCloudflareServer(t)
+	server.AddZone("test.org")
+	id := server.AddRecord("test.org", "A", "sub.test.org", "1.1.1.1")
+
+	p := &staticProvider{ip: netip.MustParseAddr("1.1.1.1")}
+	c, s := newUpdater(t, server, p, domain.FQDN("sub.test.org"))
+
+	require.True(t, update(c, s))
+	require.Equal(t, map[string]string{id: "1.1.1.1"}, server.Records("A", "sub.test.org"))
+
+	// The existing record should be reused
+	p.ip = netip.MustParseAddr("2.2.2.2")
+	require.True(t, update(c, s))
+	require.Equal(t, map[string]string{id: "2.2.2.2"}, server.Records("A", "sub.test.org"))
+}
+
+func TestDuplicateCleanup(t *testing.T) {
+	server := NewFakeCloudflareServer(t)
+	server.AddZone("test.org")
+	id1 := server.AddRecord("test.org", "A", "sub.test.org", "1.1.1.1")
+	server.AddRecord("test.org", "A", "sub.test.org", "1.1.1.1")
+	server.AddRecord("test.org", "A", "sub.test.org", "3.3.3.3")
+	other := server.AddRecord("test.org", "A", "other.test.org", "3.3.3.3")
+
+	p := &staticProvider{ip: netip.MustParseAddr("1.1.1.1")}
+	c, s := newUpdater(t, server, p, domain.FQDN("sub.test.org"))
+
+	require.True(t, update(c, s))
+	require.Equal(t, map[string]string{id1: "1.1.1.1"}, server.Records("A", "sub.test.org"))
+	require.Equal(t, map[string]string{other: "3.3.3.3"}, server.Records("A", "other.test.org"))
+}
+
+func TestZoneNotFound(t *testing.T) {
+	server := NewFakeCloudflareServer(t)
+	server.AddZone("test.org")
+
+	p := &staticProvider{ip: netip.MustParseAddr("1.1.1.1")}
+	c, s := newUpdater(t, server, p, domain.FQDN("sub.test.org"), domain.FQDN("sub.example.org"))
+
+	// The domain in the missing zone fails, but the other one is still updated
+	require.False(t, update(c, s))
+	require.Len(t, server.Records("A", "sub.test.org"), 1)
+	require.Empty(t, server.Records("A", "sub.example.org"))
+}
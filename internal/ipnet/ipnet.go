@@ -10,15 +10,37 @@ import (
 type Type int
 
 const (
+	// Any is a sentinel meaning both IPv4 and IPv6. It is not a real IP network;
+	// functions taking a Type only accept Any if they say so.
+	Any Type = 0
 	// IP4 is IP version 4.
 	IP4 Type = 4
 	// IP6 is IP version 6.
 	IP6 Type = 6
 )
 
-// Describe returns a description of the IP network.
+// All returns all the IP networks in the canonical order (IPv4 first). It does not include Any.
+func All() []Type {
+	return []Type{IP4, IP6}
+}
+
+// ForEach calls f on all the IP networks in the canonical order.
+func ForEach(f func(Type)) {
+	for _, t := range All() {
+		f(t)
+	}
+}
+
+// IsSpecific checks whether the IP network is either IPv4 or IPv6, but not Any.
+func (t Type) IsSpecific() bool {
+	return t == IP4 || t == IP6
+}
+
+// Describe returns a description of the IP network. It accepts Any.
 func (t Type) Describe() string {
 	switch t {
+	case Any:
+		return "IPv4 and IPv6"
 	case IP4, IP6:
 		return fmt.Sprintf("IPv%d", t)
 	default:
@@ -27,8 +49,11 @@ func (t Type) Describe() string {
 }
 
 // RecordType prints out the type of DNS records for the IP network.
+// It panics on Any, which has no single type of DNS records.
 func (t Type) RecordType() string {
 	switch t {
+	case Any:
+		panic("ipnet.Any has no record type")
 	case IP4:
 		return "A"
 	case IP6:
@@ -38,7 +63,7 @@ func (t Type) RecordType() string {
 	}
 }
 
-// Int returns the version of the IP networks. It is either 4 or 6.
+// Int returns the version of the IP networks. It is either 4 or 6, or 0 for Any.
 func (t Type) Int() int {
 	switch t {
 	case IP4, IP6:
@@ -48,6 +73,8 @@ func (t Type) Int() int {
 	}
 }
 
+// NormalizeIP checks whether the IP address belongs to the IP network and puts it into the canonical form.
+// It accepts Any, for which any valid IP address is accepted as it is.
 func (t Type) NormalizeIP(ip netip.Addr) (netip.Addr, bool) {
 	if !ip.IsValid() {
 		return ip, false
@@ -66,9 +93,33 @@ func (t Type) NormalizeIP(ip netip.Addr) (netip.Addr, bool) {
 	}
 }
 
-// UDPNetwork gives the network name for net.Dial.
+// CombinePrefixHost takes the first prefixLength bits from prefix and the remaining bits from host.
+// Both addresses are treated as IPv6 addresses.
+func CombinePrefixHost(prefix, host netip.Addr, prefixLength int) netip.Addr {
+	const bitsPerByte = 8
+
+	p, h := prefix.As16(), host.As16()
+
+	for i := range p {
+		switch bits := prefixLength - i*bitsPerByte; {
+		case bits >= bitsPerByte:
+			// the whole byte belongs to the prefix
+		case bits <= 0:
+			p[i] = h[i]
+		default:
+			mask := byte(0xff << (bitsPerByte - bits))
+			p[i] = p[i]&mask | h[i]&^mask
+		}
+	}
+
+	return netip.AddrFrom16(p)
+}
+
+// UDPNetwork gives the network name for net.Dial. It accepts Any, for which both IPv4 and IPv6 can be used.
 func (t Type) UDPNetwork() string {
 	switch t {
+	case Any:
+		return "udp"
 	case IP4:
 		return "udp4"
 	case IP6:
@@ -13,12 +13,42 @@ func mustIP(ip string) netip.Addr {
 	return netip.MustParseAddr(ip)
 }
 
+func TestAll(t *testing.T) {
+	t.Parallel()
+	require.Len(t, ipnet.All(), 2)
+	require.Equal(t, []ipnet.Type{ipnet.IP4, ipnet.IP6}, ipnet.All())
+
+	var visited []ipnet.Type
+	ipnet.ForEach(func(ipNet ipnet.Type) { visited = append(visited, ipNet) })
+	require.Equal(t, ipnet.All(), visited)
+}
+
+func TestIsSpecific(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input    ipnet.Type
+		expected bool
+	}{
+		"any": {ipnet.Any, false},
+		"4":   {ipnet.IP4, true},
+		"6":   {ipnet.IP6, true},
+		"100": {ipnet.Type(100), false},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.input.IsSpecific())
+		})
+	}
+}
+
 func TestDescribe(t *testing.T) {
 	t.Parallel()
 	for name, tc := range map[string]struct {
 		input    ipnet.Type
 		expected string
 	}{
+		"any": {ipnet.Any, "IPv4 and IPv6"},
 		"4":   {ipnet.IP4, "IPv4"},
 		"6":   {ipnet.IP6, "IPv6"},
 		"100": {ipnet.Type(100), "<unrecognized IP network>"},
@@ -49,12 +79,18 @@ func TestRecordType(t *testing.T) {
 	}
 }
 
+func TestRecordTypeAny(t *testing.T) {
+	t.Parallel()
+	require.Panics(t, func() { _ = ipnet.Any.RecordType() })
+}
+
 func TestInt(t *testing.T) {
 	t.Parallel()
 	for name, tc := range map[string]struct {
 		input    ipnet.Type
 		expected int
 	}{
+		"any": {ipnet.Any, 0},
 		"4":   {ipnet.IP4, 4},
 		"6":   {ipnet.IP6, 6},
 		"100": {ipnet.Type(100), 0},
@@ -79,6 +115,9 @@ func TestNormalizeIP(t *testing.T) {
 		"4-::ffff:0a0a:0a0a": {ipnet.IP4, mustIP("::ffff:0a0a:0a0a"), mustIP("10.10.10.10"), true},
 		"6-1::2":             {ipnet.IP6, mustIP("1::2"), mustIP("1::2"), true},
 		"6-10.10.10.10":      {ipnet.IP6, mustIP("10.10.10.10"), mustIP("::ffff:10.10.10.10"), true},
+		"any-nil":            {ipnet.Any, netip.Addr{}, netip.Addr{}, false},
+		"any-10.10.10.10":    {ipnet.Any, mustIP("10.10.10.10"), mustIP("10.10.10.10"), true},
+		"any-1::2":           {ipnet.Any, mustIP("1::2"), mustIP("1::2"), true},
 		"100-nil":            {100, netip.Addr{}, netip.Addr{}, false},
 		"100-10.10.10.10":    {100, mustIP("10.10.10.10"), mustIP("10.10.10.10"), true},
 	} {
@@ -98,6 +137,7 @@ func TestUDPNetwork(t *testing.T) {
 		input    ipnet.Type
 		expected string
 	}{
+		"any": {ipnet.Any, "udp"},
 		"4":   {ipnet.IP4, "udp4"},
 		"6":   {ipnet.IP6, "udp6"},
 		"100": {ipnet.Type(100), ""},
@@ -109,3 +149,26 @@ func TestUDPNetwork(t *testing.T) {
 		})
 	}
 }
+
+func TestCombinePrefixHost(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		prefix       netip.Addr
+		host         netip.Addr
+		prefixLength int
+		expected     netip.Addr
+	}{
+		"48":  {mustIP("2001:db8:1:2:3:4:5:6"), mustIP("2001:db8:a:b:c:d:e:f"), 48, mustIP("2001:db8:1:b:c:d:e:f")},
+		"56":  {mustIP("2001:db8:1:2233:3:4:5:6"), mustIP("2001:db8:a:bbcc:c:d:e:f"), 56, mustIP("2001:db8:1:22cc:c:d:e:f")},
+		"60":  {mustIP("2001:db8:1:2233:3:4:5:6"), mustIP("2001:db8:a:bbcc:c:d:e:f"), 60, mustIP("2001:db8:1:223c:c:d:e:f")},
+		"64":  {mustIP("2001:db8:1:2:3:4:5:6"), mustIP("2001:db8:a:b:c:d:e:f"), 64, mustIP("2001:db8:1:2:c:d:e:f")},
+		"0":   {mustIP("2001:db8:1:2:3:4:5:6"), mustIP("2001:db8:a:b:c:d:e:f"), 0, mustIP("2001:db8:a:b:c:d:e:f")},
+		"128": {mustIP("2001:db8:1:2:3:4:5:6"), mustIP("2001:db8:a:b:c:d:e:f"), 128, mustIP("2001:db8:1:2:3:4:5:6")},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, ipnet.CombinePrefixHost(tc.prefix, tc.host, tc.prefixLength))
+		})
+	}
+}
@@ -6,8 +6,10 @@ type PP interface {
 	SetLevel(Level) PP
 	IsEnabledFor(Level) bool
 	IncIndent() PP
+	WithPrefix(string) PP
 	Infof(Emoji, string, ...any)
 	Noticef(Emoji, string, ...any)
 	Warningf(Emoji, string, ...any)
 	Errorf(Emoji, string, ...any)
+	Fatalf(Emoji, string, ...any) // Errorf followed by exiting the program
 }
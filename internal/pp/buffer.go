@@ -0,0 +1,84 @@
+package pp
+
+import "sync"
+
+// flushMutex makes sure that the messages of different buffers are never interleaved.
+var flushMutex sync.Mutex //nolint:gochecknoglobals
+
+// A buffer keeps the messages shared by a buffered PP and all PPs derived from it.
+type buffer struct {
+	mutex    sync.Mutex
+	messages []func()
+}
+
+type buffered struct {
+	delegate PP
+	buffer   *buffer
+}
+
+// NewBufferedPP creates a PP that holds all messages until the returned function is called.
+// The function then writes the messages to delegate in their original order, without interleaving
+// with other buffered PPs flushing at the same time.
+func NewBufferedPP(delegate PP) (PP, func()) {
+	b := &buffer{mutex: sync.Mutex{}, messages: nil}
+	return &buffered{delegate: delegate, buffer: b}, b.flush
+}
+
+func (b *buffer) add(message func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.messages = append(b.messages, message)
+}
+
+func (b *buffer) flush() {
+	b.mutex.Lock()
+	messages := b.messages
+	b.messages = nil
+	b.mutex.Unlock()
+
+	flushMutex.Lock()
+	defer flushMutex.Unlock()
+
+	for _, message := range messages {
+		message()
+	}
+}
+
+func (b *buffered) SetLevel(lvl Level) PP {
+	return &buffered{delegate: b.delegate.SetLevel(lvl), buffer: b.buffer}
+}
+
+func (b *buffered) IsEnabledFor(lvl Level) bool {
+	return b.delegate.IsEnabledFor(lvl)
+}
+
+func (b *buffered) IncIndent() PP {
+	return &buffered{delegate: b.delegate.IncIndent(), buffer: b.buffer}
+}
+
+func (b *buffered) WithPrefix(prefix string) PP {
+	return &buffered{delegate: b.delegate.WithPrefix(prefix), buffer: b.buffer}
+}
+
+func (b *buffered) Infof(emoji Emoji, format string, args ...any) {
+	b.buffer.add(func() { b.delegate.Infof(emoji, format, args...) })
+}
+
+func (b *buffered) Noticef(emoji Emoji, format string, args ...any) {
+	b.buffer.add(func() { b.delegate.Noticef(emoji, format, args...) })
+}
+
+func (b *buffered) Warningf(emoji Emoji, format string, args ...any) {
+	b.buffer.add(func() { b.delegate.Warningf(emoji, format, args...) })
+}
+
+func (b *buffered) Errorf(emoji Emoji, format string, args ...any) {
+	b.buffer.add(func() { b.delegate.Errorf(emoji, format, args...) })
+}
+
+// Fatalf writes out all the held messages before the program exits.
+func (b *buffered) Fatalf(emoji Emoji, format string, args ...any) {
+	b.buffer.flush()
+	b.delegate.Fatalf(emoji, format, args...)
+}
@@ -0,0 +1,84 @@
+package pp_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestBufferedPP(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	ppfmt, flush := pp.NewBufferedPP(pp.New(&buf))
+
+	require.True(t, ppfmt.IsEnabledFor(pp.Info))
+
+	ppfmt.Infof(pp.EmojiStar, "info")
+	ppfmt.IncIndent().WithPrefix("p").Noticef(pp.EmojiBullet, "notice")
+	ppfmt.SetLevel(pp.Error).Warningf(pp.EmojiWarning, "warning")
+	ppfmt.Errorf(pp.EmojiError, "error")
+	require.Empty(t, buf.String())
+
+	flush()
+	require.Equal(t, "🌟 info\n   🔸 [p] notice\n😞 error\n", buf.String())
+
+	// The messages are only written once
+	flush()
+	require.Equal(t, "🌟 info\n   🔸 [p] notice\n😞 error\n", buf.String())
+}
+
+// lockedWriter is a strings.Builder that is safe for concurrent use.
+type lockedWriter struct {
+	mutex   sync.Mutex
+	builder strings.Builder
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.builder.Write(p)
+}
+
+func TestBufferedPPAtomicFlush(t *testing.T) {
+	t.Parallel()
+
+	const (
+		buffers  = 10
+		messages = 100
+	)
+
+	var w lockedWriter
+	delegate := pp.New(&w)
+
+	var wg sync.WaitGroup
+	for i := 0; i < buffers; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ppfmt, flush := pp.NewBufferedPP(delegate)
+			for j := 0; j < messages; j++ {
+				ppfmt.Noticef(pp.EmojiBullet, "%d-%d", i, j)
+			}
+			flush()
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(w.builder.String(), "\n"), "\n")
+	require.Len(t, lines, buffers*messages)
+	for k := 0; k < len(lines); k += messages {
+		var i int
+		_, err := fmt.Sscanf(lines[k], "🔸 %d-0", &i)
+		require.NoError(t, err)
+		for j := 0; j < messages; j++ {
+			require.Equal(t, fmt.Sprintf("🔸 %d-%d", i, j), lines[k+j])
+		}
+	}
+}
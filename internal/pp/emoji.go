@@ -34,4 +34,53 @@ const (
 	EmojiImpossible  Emoji = "🤯" // the impossible happened
 )
 
+// Fallback returns a plain ASCII replacement of the emoji for outputs where emoji are not wanted.
+// Unknown emoji are replaced by the fallback of EmojiBullet.
+func (e Emoji) Fallback() string {
+	switch e {
+	case EmojiStar:
+		return "*"
+	case EmojiEnvVars:
+		return "ENV"
+	case EmojiConfig:
+		return "CONF"
+	case EmojiInternet:
+		return "NET"
+	case EmojiPriviledges:
+		return "PRIV"
+	case EmojiMute:
+		return "MUTE"
+	case EmojiExperimental:
+		return "EXP"
+	case EmojiAddRecord:
+		return "ADD"
+	case EmojiDelRecord:
+		return "DEL"
+	case EmojiUpdateRecord:
+		return "UPD"
+	case EmojiNotification:
+		return "PING"
+	case EmojiRepeatOnce:
+		return "RETRY"
+	case EmojiSignal:
+		return "SIG"
+	case EmojiAlreadyDone, EmojiGood:
+		return "OK"
+	case EmojiNow:
+		return "NOW"
+	case EmojiAlarm:
+		return "WAIT"
+	case EmojiBye:
+		return "BYE"
+	case EmojiUserError, EmojiError:
+		return "ERROR"
+	case EmojiUserWarning, EmojiWarning:
+		return "WARN"
+	case EmojiImpossible:
+		return "BUG"
+	default: // including EmojiBullet
+		return "-"
+	}
+}
+
 const indentPrefix = "   "
@@ -0,0 +1,8 @@
+package pp
+
+// SetExit replaces the function called by Fatalf and returns a function to restore it.
+func SetExit(f func(int)) func() {
+	old := exit
+	exit = f
+	return func() { exit = old }
+}
@@ -3,13 +3,18 @@ package pp
 import (
 	"fmt"
 	"io"
+	"os"
 	"strings"
 )
 
+// exit is called by Fatalf after the message is printed.
+var exit = os.Exit //nolint:gochecknoglobals
+
 type formatter struct {
 	writer io.Writer
 	indent int
 	level  Level
+	prefix string
 }
 
 func New(writer io.Writer) PP {
@@ -17,6 +22,7 @@ func New(writer io.Writer) PP {
 		writer: writer,
 		indent: 0,
 		level:  DefaultLevel,
+		prefix: "",
 	}
 }
 
@@ -25,6 +31,7 @@ func (f *formatter) SetLevel(lvl Level) PP {
 		writer: f.writer,
 		indent: f.indent,
 		level:  lvl,
+		prefix: f.prefix,
 	}
 }
 
@@ -37,6 +44,18 @@ func (f *formatter) IncIndent() PP {
 		writer: f.writer,
 		indent: f.indent + 1,
 		level:  f.level,
+		prefix: f.prefix,
+	}
+}
+
+// WithPrefix returns a new PP that prefixes every message with "[prefix] ".
+// Prefixes of nested calls are accumulated from the outermost to the innermost.
+func (f *formatter) WithPrefix(prefix string) PP {
+	return &formatter{
+		writer: f.writer,
+		indent: f.indent,
+		level:  f.level,
+		prefix: f.prefix + "[" + prefix + "] ",
 	}
 }
 
@@ -48,7 +67,7 @@ func (f *formatter) output(lvl Level, emoji Emoji, msg string) {
 	line := fmt.Sprintf("%s%s %s",
 		strings.Repeat(indentPrefix, f.indent),
 		string(emoji),
-		msg)
+		f.prefix+msg)
 	line = strings.TrimSuffix(line, "\n")
 	fmt.Fprintln(f.writer, line)
 }
@@ -72,3 +91,8 @@ func (f *formatter) Warningf(emoji Emoji, format string, args ...any) {
 func (f *formatter) Errorf(emoji Emoji, format string, args ...any) {
 	f.printf(Error, emoji, format, args...)
 }
+
+func (f *formatter) Fatalf(emoji Emoji, format string, args ...any) {
+	f.Errorf(emoji, format, args...)
+	exit(1)
+}
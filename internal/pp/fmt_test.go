@@ -84,3 +84,46 @@ func TestPrint(t *testing.T) {
 		})
 	}
 }
+
+func TestWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	outer := pp.New(&buf)
+
+	outer.Infof(pp.EmojiStar, "message1")
+	middle := outer.WithPrefix("example.com")
+	middle.Infof(pp.EmojiStar, "message2")
+	middle.Noticef(pp.EmojiStar, "message3")
+	inner := middle.IncIndent().WithPrefix("IPv6")
+	inner.Warningf(pp.EmojiStar, "message4")
+	inner.Errorf(pp.EmojiStar, "message5")
+	outer.Infof(pp.EmojiStar, "message6")
+	middle.SetLevel(pp.Error).Errorf(pp.EmojiStar, "message7")
+
+	require.Equal(t,
+		`🌟 message1
+🌟 [example.com] message2
+🌟 [example.com] message3
+   🌟 [example.com] [IPv6] message4
+   🌟 [example.com] [IPv6] message5
+🌟 message6
+🌟 [example.com] message7
+`,
+		buf.String())
+}
+
+//nolint:paralleltest // the exit function is a global variable
+func TestFatalf(t *testing.T) {
+	var codes []int
+	restore := pp.SetExit(func(code int) { codes = append(codes, code) })
+	defer restore()
+
+	var buf strings.Builder
+	fmt := pp.New(&buf).SetLevel(pp.Error)
+
+	fmt.Fatalf(pp.EmojiStar, "fatal %d", 1)
+
+	require.Equal(t, "🌟 fatal 1\n", buf.String())
+	require.Equal(t, []int{1}, codes)
+}
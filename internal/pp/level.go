@@ -3,7 +3,7 @@ package pp
 type Level int
 
 const (
-	Debug        Level = iota // debugging info, currently not used
+	Debug        Level = iota // debugging info, such as the logged API requests
 	Info                      // information not about actual actions
 	Notice                    // information about actual actions, but not an error
 	Warning                   // non-fatal errors where the program should continue updating IP addresses
@@ -0,0 +1,47 @@
+package pp
+
+type noEmoji struct {
+	delegate PP
+}
+
+// NewNoEmojiPP creates a PP that replaces every emoji with its plain ASCII fallback
+// (see Emoji.Fallback) before passing the message to delegate.
+func NewNoEmojiPP(delegate PP) PP {
+	return &noEmoji{delegate: delegate}
+}
+
+func (n *noEmoji) SetLevel(lvl Level) PP {
+	return &noEmoji{delegate: n.delegate.SetLevel(lvl)}
+}
+
+func (n *noEmoji) IsEnabledFor(lvl Level) bool {
+	return n.delegate.IsEnabledFor(lvl)
+}
+
+func (n *noEmoji) IncIndent() PP {
+	return &noEmoji{delegate: n.delegate.IncIndent()}
+}
+
+func (n *noEmoji) WithPrefix(prefix string) PP {
+	return &noEmoji{delegate: n.delegate.WithPrefix(prefix)}
+}
+
+func (n *noEmoji) Infof(emoji Emoji, format string, args ...any) {
+	n.delegate.Infof(Emoji(emoji.Fallback()), format, args...)
+}
+
+func (n *noEmoji) Noticef(emoji Emoji, format string, args ...any) {
+	n.delegate.Noticef(Emoji(emoji.Fallback()), format, args...)
+}
+
+func (n *noEmoji) Warningf(emoji Emoji, format string, args ...any) {
+	n.delegate.Warningf(Emoji(emoji.Fallback()), format, args...)
+}
+
+func (n *noEmoji) Errorf(emoji Emoji, format string, args ...any) {
+	n.delegate.Errorf(Emoji(emoji.Fallback()), format, args...)
+}
+
+func (n *noEmoji) Fatalf(emoji Emoji, format string, args ...any) {
+	n.delegate.Fatalf(Emoji(emoji.Fallback()), format, args...)
+}
@@ -0,0 +1,57 @@
+package pp_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestNoEmojiPP(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	ppfmt := pp.NewNoEmojiPP(pp.New(&buf))
+
+	require.True(t, ppfmt.IsEnabledFor(pp.Info))
+
+	ppfmt.Infof(pp.EmojiStar, "info")
+	ppfmt.IncIndent().WithPrefix("p").Noticef(pp.EmojiBullet, "notice")
+	ppfmt.SetLevel(pp.Error).Warningf(pp.EmojiWarning, "warning")
+	ppfmt.Warningf(pp.EmojiUserWarning, "warning")
+	ppfmt.Errorf(pp.EmojiError, "error")
+	require.Equal(t, "* info\n   - [p] notice\nWARN warning\nERROR error\n", buf.String())
+}
+
+func TestNoEmojiPPFatalf(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	testPP := pp.NewTestPP(pp.New(&buf))
+	ppfmt := pp.NewNoEmojiPP(testPP)
+
+	require.Panics(t, func() { ppfmt.Fatalf(pp.EmojiUserError, "fatal") })
+	require.Equal(t, "ERROR fatal\n", buf.String())
+	require.Equal(t, []pp.FatalCall{{Emoji: pp.Emoji("ERROR"), Message: "fatal"}}, testPP.FatalCalls())
+}
+
+func TestEmojiFallback(t *testing.T) {
+	t.Parallel()
+
+	for _, emoji := range [...]pp.Emoji{
+		pp.EmojiStar, pp.EmojiBullet,
+		pp.EmojiEnvVars, pp.EmojiConfig, pp.EmojiInternet, pp.EmojiPriviledges, pp.EmojiMute, pp.EmojiExperimental,
+		pp.EmojiAddRecord, pp.EmojiDelRecord, pp.EmojiUpdateRecord,
+		pp.EmojiNotification, pp.EmojiRepeatOnce,
+		pp.EmojiSignal, pp.EmojiAlreadyDone, pp.EmojiNow, pp.EmojiAlarm, pp.EmojiBye,
+		pp.EmojiGood, pp.EmojiUserError, pp.EmojiUserWarning, pp.EmojiError, pp.EmojiWarning, pp.EmojiImpossible,
+		pp.Emoji("🦄"),
+	} {
+		fallback := emoji.Fallback()
+		require.NotEmpty(t, fallback)
+		require.Equal(t, len(fallback), utf8.RuneCountInString(fallback), "%q is not ASCII", fallback)
+	}
+}
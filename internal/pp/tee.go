@@ -0,0 +1,66 @@
+package pp
+
+type tee struct {
+	first  PP
+	second PP
+}
+
+// NewTeePP creates a PP that duplicates every call to both a and b.
+// If either of them panics, the panic is recovered and reported to the other one.
+func NewTeePP(a, b PP) PP {
+	return &tee{first: a, second: b}
+}
+
+func (t *tee) SetLevel(lvl Level) PP {
+	return &tee{first: t.first.SetLevel(lvl), second: t.second.SetLevel(lvl)}
+}
+
+func (t *tee) IsEnabledFor(lvl Level) bool {
+	return t.first.IsEnabledFor(lvl) || t.second.IsEnabledFor(lvl)
+}
+
+func (t *tee) IncIndent() PP {
+	return &tee{first: t.first.IncIndent(), second: t.second.IncIndent()}
+}
+
+func (t *tee) WithPrefix(prefix string) PP {
+	return &tee{first: t.first.WithPrefix(prefix), second: t.second.WithPrefix(prefix)}
+}
+
+// guard calls f and reports any panic to the other PP. A panic during the report is dropped.
+func guard(f func(), other PP) {
+	defer func() {
+		if r := recover(); r != nil {
+			defer func() { _ = recover() }()
+			other.Errorf(EmojiImpossible, "A log output panicked: %v", r)
+		}
+	}()
+	f()
+}
+
+func (t *tee) each(call func(PP)) {
+	guard(func() { call(t.first) }, t.second)
+	guard(func() { call(t.second) }, t.first)
+}
+
+func (t *tee) Infof(emoji Emoji, format string, args ...any) {
+	t.each(func(p PP) { p.Infof(emoji, format, args...) })
+}
+
+func (t *tee) Noticef(emoji Emoji, format string, args ...any) {
+	t.each(func(p PP) { p.Noticef(emoji, format, args...) })
+}
+
+func (t *tee) Warningf(emoji Emoji, format string, args ...any) {
+	t.each(func(p PP) { p.Warningf(emoji, format, args...) })
+}
+
+func (t *tee) Errorf(emoji Emoji, format string, args ...any) {
+	t.each(func(p PP) { p.Errorf(emoji, format, args...) })
+}
+
+// Fatalf sends the message to the second PP as an error before the first one exits the program.
+func (t *tee) Fatalf(emoji Emoji, format string, args ...any) {
+	guard(func() { t.second.Errorf(emoji, format, args...) }, t.first)
+	t.first.Fatalf(emoji, format, args...)
+}
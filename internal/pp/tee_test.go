@@ -0,0 +1,55 @@
+package pp_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestTeePP(t *testing.T) {
+	t.Parallel()
+
+	var bufA, bufB strings.Builder
+	fmt := pp.NewTeePP(pp.New(&bufA), pp.New(&bufB).SetLevel(pp.Notice))
+
+	require.True(t, fmt.IsEnabledFor(pp.Info))
+
+	fmt.Infof(pp.EmojiStar, "info")
+	fmt.IncIndent().WithPrefix("p").Noticef(pp.EmojiBullet, "notice")
+	fmt.SetLevel(pp.Error).Warningf(pp.EmojiWarning, "warning")
+	fmt.Errorf(pp.EmojiError, "error")
+
+	require.Equal(t, "🌟 info\n   🔸 [p] notice\n😞 error\n", bufA.String())
+	require.Equal(t, "   🔸 [p] notice\n😞 error\n", bufB.String())
+}
+
+type panicWriter struct{}
+
+func (panicWriter) Write([]byte) (int, error) { panic("broken writer") }
+
+func TestTeePPPanic(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		newTee func(good, bad pp.PP) pp.PP
+	}{
+		"first":  {func(good, bad pp.PP) pp.PP { return pp.NewTeePP(bad, good) }},
+		"second": {func(good, bad pp.PP) pp.PP { return pp.NewTeePP(good, bad) }},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf strings.Builder
+			fmt := tc.newTee(pp.New(&buf), pp.New(panicWriter{}))
+
+			require.NotPanics(t, func() { fmt.Noticef(pp.EmojiStar, "message") })
+			require.ElementsMatch(t,
+				[]string{"🌟 message", "🤯 A log output panicked: broken writer"},
+				strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package pp
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A FatalCall is a call of Fatalf recorded by a TestPP.
+type FatalCall struct {
+	Emoji   Emoji
+	Message string
+}
+
+// fatalRecorder keeps the calls of Fatalf shared by a TestPP and all PPs derived from it.
+type fatalRecorder struct {
+	mutex sync.Mutex
+	calls []FatalCall
+}
+
+// A TestPP is a PP for tests. It passes all messages to its delegate, except that
+// Fatalf records the call and then panics with the FatalCall instead of exiting the program.
+// The panic can be caught by require.Panics or require.PanicsWithValue.
+type TestPP struct {
+	delegate PP
+	recorder *fatalRecorder
+}
+
+var _ PP = (*TestPP)(nil)
+
+// NewTestPP creates a TestPP that passes all messages to delegate.
+func NewTestPP(delegate PP) *TestPP {
+	return &TestPP{delegate: delegate, recorder: &fatalRecorder{mutex: sync.Mutex{}, calls: nil}}
+}
+
+// FatalCalls returns the calls of Fatalf made so far, including those made through derived PPs.
+func (t *TestPP) FatalCalls() []FatalCall {
+	t.recorder.mutex.Lock()
+	defer t.recorder.mutex.Unlock()
+
+	return append([]FatalCall(nil), t.recorder.calls...)
+}
+
+func (t *TestPP) SetLevel(lvl Level) PP {
+	return &TestPP{delegate: t.delegate.SetLevel(lvl), recorder: t.recorder}
+}
+
+func (t *TestPP) IsEnabledFor(lvl Level) bool {
+	return t.delegate.IsEnabledFor(lvl)
+}
+
+func (t *TestPP) IncIndent() PP {
+	return &TestPP{delegate: t.delegate.IncIndent(), recorder: t.recorder}
+}
+
+func (t *TestPP) WithPrefix(prefix string) PP {
+	return &TestPP{delegate: t.delegate.WithPrefix(prefix), recorder: t.recorder}
+}
+
+func (t *TestPP) Infof(emoji Emoji, format string, args ...any) {
+	t.delegate.Infof(emoji, format, args...)
+}
+
+func (t *TestPP) Noticef(emoji Emoji, format string, args ...any) {
+	t.delegate.Noticef(emoji, format, args...)
+}
+
+func (t *TestPP) Warningf(emoji Emoji, format string, args ...any) {
+	t.delegate.Warningf(emoji, format, args...)
+}
+
+func (t *TestPP) Errorf(emoji Emoji, format string, args ...any) {
+	t.delegate.Errorf(emoji, format, args...)
+}
+
+func (t *TestPP) Fatalf(emoji Emoji, format string, args ...any) {
+	t.delegate.Errorf(emoji, format, args...)
+
+	call := FatalCall{Emoji: emoji, Message: fmt.Sprintf(format, args...)}
+	t.recorder.mutex.Lock()
+	t.recorder.calls = append(t.recorder.calls, call)
+	t.recorder.mutex.Unlock()
+
+	panic(call)
+}
@@ -0,0 +1,70 @@
+package pp_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func TestTestPP(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	ppfmt := pp.NewTestPP(pp.New(&buf))
+
+	require.True(t, ppfmt.IsEnabledFor(pp.Info))
+
+	ppfmt.Infof(pp.EmojiStar, "info")
+	ppfmt.IncIndent().WithPrefix("p").Noticef(pp.EmojiBullet, "notice")
+	ppfmt.SetLevel(pp.Error).Warningf(pp.EmojiWarning, "warning")
+	ppfmt.Errorf(pp.EmojiError, "error")
+	require.Equal(t, "🌟 info\n   🔸 [p] notice\n😞 error\n", buf.String())
+	require.Empty(t, ppfmt.FatalCalls())
+}
+
+func TestTestPPFatalf(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	ppfmt := pp.NewTestPP(pp.New(&buf))
+
+	require.PanicsWithValue(t,
+		pp.FatalCall{Emoji: pp.EmojiUserError, Message: "bad config 1"},
+		func() { ppfmt.Fatalf(pp.EmojiUserError, "bad config %d", 1) })
+	require.Panics(t, func() { ppfmt.IncIndent().WithPrefix("p").Fatalf(pp.EmojiImpossible, "impossible") })
+
+	require.Equal(t, "😡 bad config 1\n   🤯 [p] impossible\n", buf.String())
+	require.Equal(t, []pp.FatalCall{
+		{Emoji: pp.EmojiUserError, Message: "bad config 1"},
+		{Emoji: pp.EmojiImpossible, Message: "impossible"},
+	}, ppfmt.FatalCalls())
+}
+
+func TestBufferedPPFatalf(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	ppfmt, _ := pp.NewBufferedPP(pp.NewTestPP(pp.New(&buf)))
+
+	ppfmt.Infof(pp.EmojiStar, "info")
+	require.Empty(t, buf.String())
+
+	require.Panics(t, func() { ppfmt.Fatalf(pp.EmojiUserError, "fatal") })
+	require.Equal(t, "🌟 info\n😡 fatal\n", buf.String())
+}
+
+func TestTeePPFatalf(t *testing.T) {
+	t.Parallel()
+
+	var bufA, bufB strings.Builder
+	testPP := pp.NewTestPP(pp.New(&bufA))
+	ppfmt := pp.NewTeePP(testPP, pp.New(&bufB))
+
+	require.Panics(t, func() { ppfmt.Fatalf(pp.EmojiUserError, "fatal") })
+	require.Equal(t, "😡 fatal\n", bufA.String())
+	require.Equal(t, "😡 fatal\n", bufB.String())
+	require.Len(t, testPP.FatalCalls(), 1)
+}
@@ -12,6 +12,14 @@ import (
 
 //go:generate mockgen -destination=../mocks/mock_setter.go -package=mocks . Setter
 
+// Stats counts the changes of DNS records. Failed counts the failed attempts to add, update, or delete records.
+type Stats struct {
+	Created int
+	Updated int
+	Deleted int
+	Failed  int
+}
+
 type Setter interface {
 	Set(
 		ctx context.Context,
@@ -22,4 +30,6 @@ type Setter interface {
 		ttl api.TTL,
 		proxied bool,
 	) bool
+	// TakeStats returns the changes of DNS records since the last call and resets the counters.
+	TakeStats() Stats
 }
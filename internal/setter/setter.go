@@ -3,16 +3,28 @@ package setter
 import (
 	"context"
 	"net/netip"
+	"os"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/comment"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
 
 type setter struct {
-	Handle api.Handle
+	Handle              api.Handle
+	MaxRecordsPerDomain int
+	IP6PrefixLength     int
+	IP6HostSuffix       netip.Addr
+	CommentTemplate     string // the template of the comments of new records (empty means no comments)
+	Hostname            string // the value of {hostname} in CommentTemplate (empty means unknown)
+
+	mutex sync.Mutex // the lock of stats
+	stats Stats
 }
 
 // partitionRecords partitions record maps into matched and unmatched ones.
@@ -42,12 +54,97 @@ func partitionRecords(rmap map[string]netip.Addr, target netip.Addr) (matchedIDs
 }
 
 // New creates a new Setter.
-func New(_ppfmt pp.PP, handle api.Handle) (Setter, bool) {
+//
+// maxRecordsPerDomain is the number of DNS records of the same type and name
+// that can be found before an error (instead of a warning) is reported.
+//
+// When ip6PrefixLength is positive, only the IPv6 prefix of that length is taken from the detected
+// address; the host part comes from an existing AAAA record or, if there are none, from ip6HostSuffix.
+//
+// commentTemplate is rendered into the comments of new records. An empty template means no comments.
+func New(_ppfmt pp.PP, handle api.Handle, maxRecordsPerDomain int,
+	ip6PrefixLength int, ip6HostSuffix netip.Addr, commentTemplate string,
+) (Setter, bool) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = ""
+	}
+
 	return &setter{
-		Handle: handle,
+		Handle:              handle,
+		MaxRecordsPerDomain: maxRecordsPerDomain,
+		IP6PrefixLength:     ip6PrefixLength,
+		IP6HostSuffix:       ip6HostSuffix,
+		CommentTemplate:     commentTemplate,
+		Hostname:            hostname,
+		mutex:               sync.Mutex{},
+		stats:               Stats{Created: 0, Updated: 0, Deleted: 0, Failed: 0},
 	}, true
 }
 
+// comment renders the comment of a new record. Unknown values (such as an unknown hostname) are kept
+// as they are in the template.
+func (s *setter) comment(d domain.Domain, ipNet ipnet.Type) string {
+	if s.CommentTemplate == "" {
+		return ""
+	}
+
+	vars := map[string]string{
+		comment.Timestamp: time.Now().UTC().Format(time.RFC3339),
+		comment.Domain:    d.Describe(),
+		comment.IPNet:     ipNet.Describe(),
+	}
+	if s.Hostname != "" {
+		vars[comment.Hostname] = s.Hostname
+	}
+	return comment.Render(s.CommentTemplate, vars)
+}
+
+// count increments one of the counters in s.stats.
+func (s *setter) count(counter *int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	*counter++
+}
+
+// TakeStats returns the changes of DNS records since the last call and resets the counters.
+func (s *setter) TakeStats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := s.stats
+	s.stats = Stats{Created: 0, Updated: 0, Deleted: 0, Failed: 0}
+	return stats
+}
+
+// preserveHost combines the IPv6 prefix of the detected address with the host part of
+// an existing record (or the configured host suffix). Records are checked in the order of their IDs
+// so that the result is deterministic.
+func (s *setter) preserveHost(rmap map[string]netip.Addr, ipNet ipnet.Type, ip netip.Addr) netip.Addr {
+	if ipNet != ipnet.IP6 || s.IP6PrefixLength <= 0 || !ip.IsValid() {
+		return ip
+	}
+
+	ids := make([]string, 0, len(rmap))
+	for id := range rmap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if rmap[id].Is6() {
+			return ipnet.CombinePrefixHost(ip, rmap[id], s.IP6PrefixLength)
+		}
+	}
+
+	if s.IP6HostSuffix.IsValid() {
+		return ipnet.CombinePrefixHost(ip, s.IP6HostSuffix, s.IP6PrefixLength)
+	}
+
+	return ip
+}
+
 // Set calls the DNS service API to update the API of one domain.
 //
 //nolint:funlen
@@ -61,6 +158,24 @@ func (s *setter) Set(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipn
 		return false
 	}
 
+	// Keep the host part of the existing IPv6 address if only the prefix is managed.
+	ip = s.preserveHost(rs, ipnet, ip)
+
+	// Multiple records of the same type and name are unusual for DDNS (unless Cloudflare is used for
+	// load balancing). Up to MaxRecordsPerDomain of them only deserve a warning, and more than that
+	// is an error. Either way, only one of them will survive the updating.
+	if ip.IsValid() && len(rs) > 1 {
+		if len(rs) <= s.MaxRecordsPerDomain {
+			ppfmt.Warningf(pp.EmojiWarning,
+				"Found %d %s records of %q; only one of them will be kept",
+				len(rs), recordType, domainDescription)
+		} else {
+			ppfmt.Errorf(pp.EmojiError,
+				"Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted",
+				len(rs), recordType, domainDescription, s.MaxRecordsPerDomain)
+		}
+	}
+
 	// The intention of these two lists is to find or create a good record and then delete everything else.
 	// We prefer recycling existing records (if possible) so that existing TTL and proxy can be preserved.
 	// However, when ip is not valid, we will delete all DNS records.
@@ -117,6 +232,7 @@ func (s *setter) Set(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipn
 				// If the updating succeeds, we can move on to the next stage!
 				ppfmt.Noticef(pp.EmojiUpdateRecord,
 					"Updated a stale %s record of %q (ID: %s)", recordType, domainDescription, id)
+				s.count(&s.stats.Updated)
 
 				// Now it's up to date! Note that matchedIDs must be empty; otherwise uptodate would have been true.
 				uptodate = true
@@ -125,13 +241,18 @@ func (s *setter) Set(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipn
 
 				break
 			} else {
+				s.count(&s.stats.Failed)
+
 				// If the updating fails, we will delete it.
 				if s.Handle.DeleteRecord(ctx, ppfmt, domain, ipnet, id) {
 					ppfmt.Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)",
 						recordType, domainDescription, id)
+					s.count(&s.stats.Deleted)
 
 					// Only when the deletion succeeds, we decrease the counter of remaining stale records.
 					numUndeletedUnmatched--
+				} else {
+					s.count(&s.stats.Failed)
 				}
 
 				// No matter whether the deletion succeeds, move on.
@@ -146,11 +267,14 @@ func (s *setter) Set(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipn
 	// any one of them. This leaves us no choices---we have to create a new record with the correct ip.
 	if !uptodate {
 		if id, ok := s.Handle.CreateRecord(ctx, ppfmt,
-			domain, ipnet, ip, ttl, proxied); ok {
+			domain, ipnet, ip, ttl, proxied, s.comment(domain, ipnet)); ok {
 			ppfmt.Noticef(pp.EmojiAddRecord, "Added a new %s record of %q (ID: %s)", recordType, domainDescription, id)
+			s.count(&s.stats.Created)
 
 			// Now it's up to date! matchedIDs and unmatchedIDsToUpdate must both be empty at this point
 			uptodate = true
+		} else {
+			s.count(&s.stats.Failed)
 		}
 	}
 
@@ -158,7 +282,10 @@ func (s *setter) Set(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipn
 	for _, id := range unmatchedIDsToUpdate {
 		if s.Handle.DeleteRecord(ctx, ppfmt, domain, ipnet, id) {
 			ppfmt.Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)", recordType, domainDescription, id)
+			s.count(&s.stats.Deleted)
 			numUndeletedUnmatched--
+		} else {
+			s.count(&s.stats.Failed)
 		}
 	}
 
@@ -168,6 +295,9 @@ func (s *setter) Set(ctx context.Context, ppfmt pp.PP, domain domain.Domain, ipn
 		if s.Handle.DeleteRecord(ctx, ppfmt, domain, ipnet, id) {
 			ppfmt.Noticef(pp.EmojiDelRecord, "Deleted a duplicate %s record of %q (ID: %s)",
 				recordType, domainDescription, id)
+			s.count(&s.stats.Deleted)
+		} else {
+			s.count(&s.stats.Failed)
 		}
 	}
 
@@ -2,6 +2,7 @@ package setter_test
 
 import (
 	"context"
+	"io"
 	"net/netip"
 	"testing"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/favonia/cloudflare-ddns/internal/mocks"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 	"github.com/favonia/cloudflare-ddns/internal/setter"
+	"github.com/favonia/cloudflare-ddns/internal/testapi"
 )
 
 //nolint:funlen
@@ -64,7 +66,7 @@ func TestSet(t *testing.T) {
 			func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle) {
 				gomock.InOrder(
 					m.EXPECT().ListRecords(ctx, ppfmt, domain, ipNetwork).Return(map[string]netip.Addr{}, true),
-					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(1), false).Return(record1, true),
+					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(1), false, "").Return(record1, true),
 				)
 			},
 		},
@@ -104,7 +106,7 @@ func TestSet(t *testing.T) {
 					m.EXPECT().ListRecords(ctx, ppfmt, domain, ipNetwork).Return(map[string]netip.Addr{record1: ip2}, true),
 					m.EXPECT().UpdateRecord(ctx, ppfmt, domain, ipNetwork, record1, ip1).Return(false),
 					m.EXPECT().DeleteRecord(ctx, ppfmt, domain, ipNetwork, record1).Return(true),
-					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false).Return(record2, true),
+					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false, "").Return(record2, true),
 				)
 			},
 		},
@@ -141,12 +143,15 @@ func TestSet(t *testing.T) {
 			300,
 			false,
 			func(m *mocks.MockPP) {
-				m.EXPECT().Noticef(
-					pp.EmojiDelRecord,
-					"Deleted a duplicate %s record of %q (ID: %s)",
-					"AAAA",
-					"sub.test.org",
-					record2,
+				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1), //nolint:lll
+					m.EXPECT().Noticef(
+						pp.EmojiDelRecord,
+						"Deleted a duplicate %s record of %q (ID: %s)",
+						"AAAA",
+						"sub.test.org",
+						record2,
+					),
 				)
 			},
 			func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle) {
@@ -161,7 +166,9 @@ func TestSet(t *testing.T) {
 			true,
 			300,
 			false,
-			nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1) //nolint:lll
+			},
 			func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle) {
 				gomock.InOrder(
 					m.EXPECT().ListRecords(ctx, ppfmt, domain, ipNetwork).Return(map[string]netip.Addr{record1: ip1, record2: ip1}, true), //nolint:lll
@@ -176,6 +183,7 @@ func TestSet(t *testing.T) {
 			false,
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1), //nolint:lll
 					m.EXPECT().Noticef(
 						pp.EmojiUpdateRecord,
 						"Updated a stale %s record of %q (ID: %s)",
@@ -201,6 +209,7 @@ func TestSet(t *testing.T) {
 			false,
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1), //nolint:lll
 					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)", "AAAA", "sub.test.org", record1),
 					m.EXPECT().Noticef(
 						pp.EmojiUpdateRecord,
@@ -227,6 +236,7 @@ func TestSet(t *testing.T) {
 			false,
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1), //nolint:lll
 					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)", "AAAA", "sub.test.org", record1),
 					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)", "AAAA", "sub.test.org", record2),
 					m.EXPECT().Noticef(pp.EmojiAddRecord, "Added a new %s record of %q (ID: %s)", "AAAA", "sub.test.org", record3),
@@ -239,7 +249,7 @@ func TestSet(t *testing.T) {
 					m.EXPECT().DeleteRecord(ctx, ppfmt, domain, ipNetwork, record1).Return(true),
 					m.EXPECT().UpdateRecord(ctx, ppfmt, domain, ipNetwork, record2, ip1).Return(false),
 					m.EXPECT().DeleteRecord(ctx, ppfmt, domain, ipNetwork, record2).Return(true),
-					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false).Return(record3, true),
+					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false, "").Return(record3, true),
 				)
 			},
 		},
@@ -251,6 +261,7 @@ func TestSet(t *testing.T) {
 			false,
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1), //nolint:lll
 					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)", "AAAA", "sub.test.org", record2),
 					m.EXPECT().Noticef(pp.EmojiAddRecord, "Added a new %s record of %q (ID: %s)", "AAAA", "sub.test.org", record3),
 					m.EXPECT().Errorf(pp.EmojiError, "Failed to complete updating of %s records of %q; records might be inconsistent", "AAAA", "sub.test.org"), //nolint:lll
@@ -263,7 +274,7 @@ func TestSet(t *testing.T) {
 					m.EXPECT().DeleteRecord(ctx, ppfmt, domain, ipNetwork, record1).Return(false),
 					m.EXPECT().UpdateRecord(ctx, ppfmt, domain, ipNetwork, record2, ip1).Return(false),
 					m.EXPECT().DeleteRecord(ctx, ppfmt, domain, ipNetwork, record2).Return(true),
-					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false).Return(record3, true),
+					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false, "").Return(record3, true),
 				)
 			},
 		},
@@ -275,6 +286,7 @@ func TestSet(t *testing.T) {
 			false,
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1), //nolint:lll
 					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)", "AAAA", "sub.test.org", record1),
 					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a stale %s record of %q (ID: %s)", "AAAA", "sub.test.org", record2),
 					m.EXPECT().Errorf(pp.EmojiError, "Failed to complete updating of %s records of %q; records might be inconsistent", "AAAA", "sub.test.org"), //nolint:lll
@@ -287,7 +299,7 @@ func TestSet(t *testing.T) {
 					m.EXPECT().DeleteRecord(ctx, ppfmt, domain, ipNetwork, record1).Return(true),
 					m.EXPECT().UpdateRecord(ctx, ppfmt, domain, ipNetwork, record2, ip1).Return(false),
 					m.EXPECT().DeleteRecord(ctx, ppfmt, domain, ipNetwork, record2).Return(true),
-					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false).Return(record3, false),
+					m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, ip1, api.TTL(300), false, "").Return(record3, false),
 				)
 			},
 		},
@@ -340,7 +352,7 @@ func TestSet(t *testing.T) {
 				tc.prepareMockHandle(ctx, mockPP, mockHandle)
 			}
 
-			s, ok := setter.New(mockPP, mockHandle)
+			s, ok := setter.New(mockPP, mockHandle, 1, 0, netip.Addr{}, "")
 			require.True(t, ok)
 
 			ok = s.Set(ctx, mockPP, domain, ipNetwork, tc.ip, tc.ttl, tc.proxied)
@@ -348,3 +360,308 @@ func TestSet(t *testing.T) {
 		})
 	}
 }
+
+//nolint:funlen
+func TestSetMaxRecordsPerDomain(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP6
+		record1   = "record1"
+		record2   = "record2"
+		record3   = "record3"
+	)
+	ip1 := netip.MustParseAddr("::1")
+
+	for name, tc := range map[string]struct {
+		maxRecords    int
+		records       map[string]netip.Addr
+		prepareMockPP func(m *mocks.MockPP)
+		deleted       []string
+	}{
+		"default/single": {
+			1,
+			map[string]netip.Addr{record1: ip1},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %q are already up to date", "AAAA", "sub.test.org")
+			},
+			nil,
+		},
+		"default/double": {
+			1,
+			map[string]netip.Addr{record1: ip1, record2: ip1},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 2, "AAAA", "sub.test.org", 1), //nolint:lll
+					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a duplicate %s record of %q (ID: %s)", "AAAA", "sub.test.org", record2),                                                      //nolint:lll
+				)
+			},
+			[]string{record2},
+		},
+		"single": {
+			2,
+			map[string]netip.Addr{record1: ip1},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %q are already up to date", "AAAA", "sub.test.org")
+			},
+			nil,
+		},
+		"double": {
+			2,
+			map[string]netip.Addr{record1: ip1, record2: ip1},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Warningf(pp.EmojiWarning, "Found %d %s records of %q; only one of them will be kept", 2, "AAAA", "sub.test.org"), //nolint:lll
+					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a duplicate %s record of %q (ID: %s)", "AAAA", "sub.test.org", record2),      //nolint:lll
+				)
+			},
+			[]string{record2},
+		},
+		"exceeding": {
+			2,
+			map[string]netip.Addr{record1: ip1, record2: ip1, record3: ip1},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Errorf(pp.EmojiError, "Found %d %s records of %q, more than MAX_RECORDS_PER_DOMAIN=%d; all but one of them will still be deleted", 3, "AAAA", "sub.test.org", 2), //nolint:lll
+					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a duplicate %s record of %q (ID: %s)", "AAAA", "sub.test.org", record2),                                                      //nolint:lll
+					m.EXPECT().Noticef(pp.EmojiDelRecord, "Deleted a duplicate %s record of %q (ID: %s)", "AAAA", "sub.test.org", record3),                                                      //nolint:lll
+				)
+			},
+			[]string{record2, record3},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx := context.Background()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			calls := []*gomock.Call{mockHandle.EXPECT().ListRecords(ctx, mockPP, domain, ipNetwork).Return(tc.records, true)}
+			for _, id := range tc.deleted {
+				calls = append(calls, mockHandle.EXPECT().DeleteRecord(ctx, mockPP, domain, ipNetwork, id).Return(true))
+			}
+			gomock.InOrder(calls...)
+
+			s, ok := setter.New(mockPP, mockHandle, tc.maxRecords, 0, netip.Addr{}, "")
+			require.True(t, ok)
+
+			ok = s.Set(ctx, mockPP, domain, ipNetwork, ip1, api.TTLAuto, false)
+			require.True(t, ok)
+		})
+	}
+}
+
+//nolint:funlen
+func TestSetIP6PrefixLength(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP6
+		record1   = "record1"
+	)
+	var (
+		detected = netip.MustParseAddr("2001:db8:1:2::5")
+		existing = netip.MustParseAddr("2001:db8:9:9::abcd")
+		combined = netip.MustParseAddr("2001:db8:1:2::abcd")
+		suffix   = netip.MustParseAddr("::1234")
+	)
+
+	for name, tc := range map[string]struct {
+		records           map[string]netip.Addr
+		hostSuffix        netip.Addr
+		prepareMockPP     func(m *mocks.MockPP)
+		prepareMockHandle func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle)
+	}{
+		"existing": {
+			map[string]netip.Addr{record1: existing},
+			suffix,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUpdateRecord, "Updated a stale %s record of %q (ID: %s)", "AAAA", "sub.test.org", record1) //nolint:lll
+			},
+			func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle) {
+				m.EXPECT().UpdateRecord(ctx, ppfmt, domain, ipNetwork, record1, combined).Return(true)
+			},
+		},
+		"up-to-date": {
+			map[string]netip.Addr{record1: combined},
+			netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %q are already up to date", "AAAA", "sub.test.org")
+			},
+			func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle) {},
+		},
+		"suffix": {
+			map[string]netip.Addr{},
+			suffix,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiAddRecord, "Added a new %s record of %q (ID: %s)", "AAAA", "sub.test.org", record1)
+			},
+			func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle) {
+				m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork,
+					netip.MustParseAddr("2001:db8:1:2::1234"), api.TTLAuto, false, "").Return(record1, true)
+			},
+		},
+		"no-suffix": {
+			map[string]netip.Addr{},
+			netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiAddRecord, "Added a new %s record of %q (ID: %s)", "AAAA", "sub.test.org", record1)
+			},
+			func(ctx context.Context, ppfmt pp.PP, m *mocks.MockHandle) {
+				m.EXPECT().CreateRecord(ctx, ppfmt, domain, ipNetwork, detected, api.TTLAuto, false, "").Return(record1, true)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx := context.Background()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			tc.prepareMockPP(mockPP)
+
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			mockHandle.EXPECT().ListRecords(ctx, mockPP, domain, ipNetwork).Return(tc.records, true)
+			tc.prepareMockHandle(ctx, mockPP, mockHandle)
+
+			s, ok := setter.New(mockPP, mockHandle, 1, 64, tc.hostSuffix, "")
+			require.True(t, ok)
+
+			ok = s.Set(ctx, mockPP, domain, ipNetwork, detected, api.TTLAuto, false)
+			require.True(t, ok)
+		})
+	}
+}
+
+// TestSetFake uses the hand-written FakeHandle instead of gomock, as only the outcomes matter.
+func TestSetFake(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP6
+	)
+	var (
+		ip1 = netip.MustParseAddr("::1")
+		ip2 = netip.MustParseAddr("::2")
+	)
+
+	for name, tc := range map[string]struct {
+		existing map[string]netip.Addr
+		ip       netip.Addr
+		ok       bool
+		check    func(t *testing.T, h *testapi.FakeHandle)
+	}{
+		"create": {
+			map[string]netip.Addr{}, ip1, true,
+			func(t *testing.T, h *testapi.FakeHandle) {
+				t.Helper()
+				h.AssertCreated(t, domain, ipNetwork, ip1)
+			},
+		},
+		"update": {
+			map[string]netip.Addr{"record1": ip1}, ip2, true,
+			func(t *testing.T, h *testapi.FakeHandle) {
+				t.Helper()
+				h.AssertUpdated(t, domain, ipNetwork, "record1", ip2)
+			},
+		},
+		"unchanged": {
+			map[string]netip.Addr{"record1": ip1}, ip1, true,
+			func(t *testing.T, h *testapi.FakeHandle) {
+				t.Helper()
+				h.AssertUnchanged(t)
+			},
+		},
+		"clear": {
+			map[string]netip.Addr{"record1": ip1}, netip.Addr{}, true,
+			func(t *testing.T, h *testapi.FakeHandle) {
+				t.Helper()
+				h.AssertDeleted(t, domain, ipNetwork, "record1")
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := testapi.NewFakeHandle()
+			for id, ip := range tc.existing {
+				h.AddRecord(domain, ipNetwork, id, ip)
+			}
+
+			ppfmt := pp.New(io.Discard)
+			s, ok := setter.New(ppfmt, h, 1, 0, netip.Addr{}, "")
+			require.True(t, ok)
+
+			require.Equal(t, tc.ok, s.Set(context.Background(), ppfmt, domain, ipNetwork, tc.ip, api.TTLAuto, false))
+			tc.check(t, h)
+		})
+	}
+}
+
+func TestSetComment(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ppfmt := pp.New(io.Discard)
+	d := domain.FQDN("sub.test.org")
+	ip1 := netip.MustParseAddr("::1")
+	ip2 := netip.MustParseAddr("::2")
+
+	h := testapi.NewFakeHandle()
+	h.AddRecord(d, ipnet.IP6, "record1", ip1)
+	s, ok := setter.New(ppfmt, h, 1, 0, netip.Addr{}, "managed for {domain} ({ipnet}) {unknown}")
+	require.True(t, ok)
+
+	// Updating an existing record does not touch its comment
+	require.True(t, s.Set(ctx, ppfmt, d, ipnet.IP6, ip2, api.TTLAuto, false))
+	require.Empty(t, h.CreatedRecords)
+
+	require.True(t, s.Set(ctx, ppfmt, domain.FQDN("new.test.org"), ipnet.IP4, netip.MustParseAddr("1.1.1.1"),
+		api.TTLAuto, false))
+	require.Len(t, h.CreatedRecords, 1)
+	require.Equal(t, "managed for new.test.org (IPv4) {unknown}", h.CreatedRecords[0].Comment)
+}
+
+func TestSetTakeStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ppfmt := pp.New(io.Discard)
+	ip1 := netip.MustParseAddr("::1")
+	ip2 := netip.MustParseAddr("::2")
+	created := domain.FQDN("created.test.org")
+	updated := domain.FQDN("updated.test.org")
+	duplicated := domain.FQDN("duplicated.test.org")
+
+	h := testapi.NewFakeHandle()
+	h.AddRecord(updated, ipnet.IP6, "record1", ip1)
+	h.AddRecord(duplicated, ipnet.IP6, "record2", ip2)
+	h.AddRecord(duplicated, ipnet.IP6, "record3", ip2)
+
+	s, ok := setter.New(ppfmt, h, 3, 0, netip.Addr{}, "")
+	require.True(t, ok)
+
+	require.True(t, s.Set(ctx, ppfmt, created, ipnet.IP6, ip2, api.TTLAuto, false))
+	require.True(t, s.Set(ctx, ppfmt, updated, ipnet.IP6, ip2, api.TTLAuto, false))
+	require.True(t, s.Set(ctx, ppfmt, duplicated, ipnet.IP6, ip2, api.TTLAuto, false))
+	require.Equal(t, setter.Stats{Created: 1, Updated: 1, Deleted: 1, Failed: 0}, s.TakeStats())
+
+	// The counters are reset.
+	require.Equal(t, setter.Stats{Created: 0, Updated: 0, Deleted: 0, Failed: 0}, s.TakeStats())
+
+	// A failed update falls back to deletion, and then a new record is created.
+	h.UpdateErr, h.CreateErr = true, true
+	require.False(t, s.Set(ctx, ppfmt, updated, ipnet.IP6, ip1, api.TTLAuto, false))
+	require.Equal(t, setter.Stats{Created: 0, Updated: 0, Deleted: 1, Failed: 2}, s.TakeStats())
+}
@@ -0,0 +1,223 @@
+// Package testapi provides a hand-written fake of api.Handle for simple tests.
+// The gomock-generated mocks in the package mocks should be used when the exact calls matter.
+package testapi
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// A CreatedRecord is a call of CreateRecord.
+type CreatedRecord struct {
+	Domain  domain.Domain
+	IPNet   ipnet.Type
+	IP      netip.Addr
+	TTL     api.TTL
+	Proxied bool
+	Comment string
+}
+
+// An UpdatedRecord is a call of UpdateRecord.
+type UpdatedRecord struct {
+	Domain domain.Domain
+	IPNet  ipnet.Type
+	ID     string
+	IP     netip.Addr
+}
+
+// A DeletedRecord is a call of DeleteRecord.
+type DeletedRecord struct {
+	Domain domain.Domain
+	IPNet  ipnet.Type
+	ID     string
+}
+
+// A FakeHandle is an in-memory api.Handle. It keeps the records in Records and remembers
+// all the calls that would change them, whether they succeed or not. Setting ListErr,
+// CreateErr, UpdateErr, or DeleteErr makes the corresponding operations fail.
+// A FakeHandle is not safe for concurrent use.
+type FakeHandle struct {
+	Records map[ipnet.Type]map[domain.Domain]map[string]netip.Addr
+
+	CreatedRecords []CreatedRecord
+	UpdatedRecords []UpdatedRecord
+	DeletedRecords []DeletedRecord
+
+	ListErr   bool
+	CreateErr bool
+	UpdateErr bool
+	DeleteErr bool
+
+	nextID int
+}
+
+var _ api.Handle = (*FakeHandle)(nil)
+
+// NewFakeHandle creates a FakeHandle with no records.
+func NewFakeHandle() *FakeHandle {
+	return &FakeHandle{ //nolint:exhaustruct // The zero values are ready to use
+		Records: map[ipnet.Type]map[domain.Domain]map[string]netip.Addr{},
+	}
+}
+
+// AddRecord adds an existing record without counting it as a call of CreateRecord.
+func (h *FakeHandle) AddRecord(d domain.Domain, ipNet ipnet.Type, id string, ip netip.Addr) {
+	if h.Records[ipNet] == nil {
+		h.Records[ipNet] = map[domain.Domain]map[string]netip.Addr{}
+	}
+	if h.Records[ipNet][d] == nil {
+		h.Records[ipNet][d] = map[string]netip.Addr{}
+	}
+	h.Records[ipNet][d][id] = ip
+}
+
+func (h *FakeHandle) ListRecords(_ context.Context, _ pp.PP,
+	domain domain.Domain, ipNet ipnet.Type,
+) (map[string]netip.Addr, bool) {
+	if h.ListErr {
+		return nil, false
+	}
+
+	rmap := map[string]netip.Addr{}
+	for id, ip := range h.Records[ipNet][domain] {
+		rmap[id] = ip
+	}
+	return rmap, true
+}
+
+func (h *FakeHandle) DeleteRecord(_ context.Context, _ pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string,
+) bool {
+	h.DeletedRecords = append(h.DeletedRecords, DeletedRecord{Domain: domain, IPNet: ipNet, ID: id})
+	if h.DeleteErr {
+		return false
+	}
+
+	delete(h.Records[ipNet][domain], id)
+	return true
+}
+
+func (h *FakeHandle) UpdateRecord(_ context.Context, _ pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, id string, ip netip.Addr,
+) bool {
+	h.UpdatedRecords = append(h.UpdatedRecords, UpdatedRecord{Domain: domain, IPNet: ipNet, ID: id, IP: ip})
+	if h.UpdateErr {
+		return false
+	}
+
+	h.AddRecord(domain, ipNet, id, ip)
+	return true
+}
+
+// CreateRecord gives the new records the IDs "fake1", "fake2", and so on.
+func (h *FakeHandle) CreateRecord(_ context.Context, _ pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl api.TTL, proxied bool, comment string,
+) (string, bool) {
+	h.CreatedRecords = append(h.CreatedRecords,
+		CreatedRecord{Domain: domain, IPNet: ipNet, IP: ip, TTL: ttl, Proxied: proxied, Comment: comment})
+	if h.CreateErr {
+		return "", false
+	}
+
+	h.nextID++
+	id := fmt.Sprintf("fake%d", h.nextID)
+	h.AddRecord(domain, ipNet, id, ip)
+	return id, true
+}
+
+// EnsureRecordExists calls CreateRecord only if no record of the domain has the IP address.
+func (h *FakeHandle) EnsureRecordExists(ctx context.Context, ppfmt pp.PP,
+	domain domain.Domain, ipNet ipnet.Type, ip netip.Addr, ttl api.TTL, proxied bool,
+) (string, bool, bool) {
+	rmap, ok := h.ListRecords(ctx, ppfmt, domain, ipNet)
+	if !ok {
+		return "", false, false
+	}
+
+	ids := make([]string, 0, len(rmap))
+	for id, rip := range rmap {
+		if rip == ip {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		sort.Strings(ids)
+		return ids[0], false, true
+	}
+
+	id, ok := h.CreateRecord(ctx, ppfmt, domain, ipNet, ip, ttl, proxied, "")
+	return id, ok, ok
+}
+
+func (h *FakeHandle) BatchUpdate(ctx context.Context, ppfmt pp.PP,
+	updates []api.RecordUpdate,
+) ([]api.RecordUpdateResult, bool) {
+	ok := true
+	results := make([]api.RecordUpdateResult, len(updates))
+	for i, u := range updates {
+		results[i] = api.RecordUpdateResult{Domain: u.Domain, Success: true, Err: nil}
+
+		if _, found := h.Records[u.IPNet][u.Domain][u.ID]; !found {
+			results[i] = api.RecordUpdateResult{Domain: u.Domain, Success: false, Err: api.ErrRecordNotFound}
+			ok = false
+			continue
+		}
+		if !h.UpdateRecord(ctx, ppfmt, u.Domain, u.IPNet, u.ID, u.IP) {
+			results[i] = api.RecordUpdateResult{Domain: u.Domain, Success: false, Err: api.ErrRecordUpdateFailed}
+			ok = false
+		}
+	}
+	return results, ok
+}
+
+func (h *FakeHandle) Describe(context.Context, pp.PP) (api.AccountInfo, bool) {
+	return api.AccountInfo{AccountID: "", AccountName: "", TokenID: "", TokenName: ""}, true
+}
+
+func (h *FakeHandle) FlushCache() int { return 0 }
+
+// AssertCreated checks that CreateRecord was called for the domain, the IP network, and the IP address.
+func (h *FakeHandle) AssertCreated(t *testing.T, domain domain.Domain, ipNet ipnet.Type, ip netip.Addr) {
+	t.Helper()
+
+	for _, r := range h.CreatedRecords {
+		if r.Domain == domain && r.IPNet == ipNet && r.IP == ip {
+			return
+		}
+	}
+	require.Failf(t, "record not created", "no %s record of %q was created with %v; created: %v",
+		ipNet.RecordType(), domain.Describe(), ip, h.CreatedRecords)
+}
+
+// AssertUpdated checks that UpdateRecord was called for the record and the IP address.
+func (h *FakeHandle) AssertUpdated(t *testing.T, domain domain.Domain, ipNet ipnet.Type, id string, ip netip.Addr) {
+	t.Helper()
+
+	require.Contains(t, h.UpdatedRecords, UpdatedRecord{Domain: domain, IPNet: ipNet, ID: id, IP: ip})
+}
+
+// AssertDeleted checks that DeleteRecord was called for the record.
+func (h *FakeHandle) AssertDeleted(t *testing.T, domain domain.Domain, ipNet ipnet.Type, id string) {
+	t.Helper()
+
+	require.Contains(t, h.DeletedRecords, DeletedRecord{Domain: domain, IPNet: ipNet, ID: id})
+}
+
+// AssertUnchanged checks that no calls were made to change the records.
+func (h *FakeHandle) AssertUnchanged(t *testing.T) {
+	t.Helper()
+
+	require.Empty(t, h.CreatedRecords)
+	require.Empty(t, h.UpdatedRecords)
+	require.Empty(t, h.DeletedRecords)
+}
@@ -0,0 +1,106 @@
+package testapi_test
+
+import (
+	"context"
+	"io"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/testapi"
+)
+
+func TestFakeHandle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ppfmt := pp.New(io.Discard)
+	d := domain.FQDN("sub.test.org")
+	ip1 := netip.MustParseAddr("::1")
+	ip2 := netip.MustParseAddr("::2")
+
+	h := testapi.NewFakeHandle()
+	h.AddRecord(d, ipnet.IP6, "record1", ip1)
+	h.AssertUnchanged(t)
+
+	id, ok := h.CreateRecord(ctx, ppfmt, d, ipnet.IP6, ip2, api.TTLAuto, false, "")
+	require.True(t, ok)
+	require.Equal(t, "fake1", id)
+	h.AssertCreated(t, d, ipnet.IP6, ip2)
+
+	require.True(t, h.UpdateRecord(ctx, ppfmt, d, ipnet.IP6, "record1", ip2))
+	h.AssertUpdated(t, d, ipnet.IP6, "record1", ip2)
+
+	require.True(t, h.DeleteRecord(ctx, ppfmt, d, ipnet.IP6, "fake1"))
+	h.AssertDeleted(t, d, ipnet.IP6, "fake1")
+
+	rmap, ok := h.ListRecords(ctx, ppfmt, d, ipnet.IP6)
+	require.True(t, ok)
+	require.Equal(t, map[string]netip.Addr{"record1": ip2}, rmap)
+
+	results, ok := h.BatchUpdate(ctx, ppfmt, []api.RecordUpdate{
+		{Domain: d, IPNet: ipnet.IP6, ID: "record1", IP: ip1},
+		{Domain: d, IPNet: ipnet.IP6, ID: "fake1", IP: ip1},
+	})
+	require.False(t, ok)
+	require.Equal(t, []api.RecordUpdateResult{
+		{Domain: d, Success: true, Err: nil},
+		{Domain: d, Success: false, Err: api.ErrRecordNotFound},
+	}, results)
+}
+
+func TestFakeHandleEnsureRecordExists(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ppfmt := pp.New(io.Discard)
+	d := domain.FQDN("sub.test.org")
+	ip1 := netip.MustParseAddr("::1")
+	ip2 := netip.MustParseAddr("::2")
+
+	h := testapi.NewFakeHandle()
+	h.AddRecord(d, ipnet.IP6, "record1", ip1)
+
+	id, created, ok := h.EnsureRecordExists(ctx, ppfmt, d, ipnet.IP6, ip1, api.TTLAuto, false)
+	require.True(t, ok)
+	require.False(t, created)
+	require.Equal(t, "record1", id)
+	h.AssertUnchanged(t)
+
+	id, created, ok = h.EnsureRecordExists(ctx, ppfmt, d, ipnet.IP6, ip2, api.TTLAuto, false)
+	require.True(t, ok)
+	require.True(t, created)
+	require.Equal(t, "fake1", id)
+	h.AssertCreated(t, d, ipnet.IP6, ip2)
+}
+
+func TestFakeHandleErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ppfmt := pp.New(io.Discard)
+	d := domain.FQDN("sub.test.org")
+	ip := netip.MustParseAddr("::1")
+
+	h := testapi.NewFakeHandle()
+	h.AddRecord(d, ipnet.IP6, "record1", ip)
+	h.ListErr, h.CreateErr, h.UpdateErr, h.DeleteErr = true, true, true, true
+
+	_, ok := h.ListRecords(ctx, ppfmt, d, ipnet.IP6)
+	require.False(t, ok)
+	_, ok = h.CreateRecord(ctx, ppfmt, d, ipnet.IP6, ip, api.TTLAuto, false, "")
+	require.False(t, ok)
+	require.False(t, h.UpdateRecord(ctx, ppfmt, d, ipnet.IP6, "record1", ip))
+	require.False(t, h.DeleteRecord(ctx, ppfmt, d, ipnet.IP6, "record1"))
+
+	// Failed calls are still recorded, but the records are untouched.
+	h.AssertCreated(t, d, ipnet.IP6, ip)
+	h.AssertUpdated(t, d, ipnet.IP6, "record1", ip)
+	h.AssertDeleted(t, d, ipnet.IP6, "record1")
+	require.Equal(t, map[string]netip.Addr{"record1": ip}, h.Records[ipnet.IP6][d])
+}
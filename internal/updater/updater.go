@@ -3,7 +3,9 @@ package updater
 import (
 	"context"
 	"net/netip"
+	"time"
 
+	"github.com/favonia/cloudflare-ddns/internal/clock"
 	"github.com/favonia/cloudflare-ddns/internal/config"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
@@ -11,32 +13,76 @@ import (
 	"github.com/favonia/cloudflare-ddns/internal/setter"
 )
 
-func getProxied(ppfmt pp.PP, c *config.Config, domain domain.Domain) bool {
-	if proxied, ok := c.Proxied[domain]; ok {
+// State is what the updater remembers between updates.
+type State struct {
+	LastUpdated map[ipnet.Type]map[domain.Domain]time.Time // the last successful updates of the domains
+	Clock       clock.Clock                                // the clock for the update intervals and the summaries
+}
+
+// NewState creates a State with no past updates, using the system clock.
+func NewState() *State {
+	return &State{
+		LastUpdated: map[ipnet.Type]map[domain.Domain]time.Time{ipnet.IP4: {}, ipnet.IP6: {}},
+		Clock:       clock.Real{},
+	}
+}
+
+func getProxied(ppfmt pp.PP, c *config.Config, ipNet ipnet.Type, domain domain.Domain) bool {
+	if proxied, ok := c.Proxied[ipNet][domain]; ok {
 		return proxied
 	}
 
 	ppfmt.Warningf(pp.EmojiImpossible,
-		"Proxied[%s] not initialized; please report the bug at https://github.com/favonia/cloudflare-ddns/issues/new",
-		domain.Describe(),
+		"Proxied[%s][%s] not initialized; please report the bug at https://github.com/favonia/cloudflare-ddns/issues/new",
+		ipNet.Describe(), domain.Describe(),
 	)
 	return false
 }
 
-func setIP(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Setter, ipNet ipnet.Type, ip netip.Addr) bool {
+// isDue checks whether the update interval of the domain (if any) has elapsed since its last successful update.
+func isDue(c *config.Config, st *State, ipNet ipnet.Type, domain domain.Domain) bool {
+	interval, ok := c.DomainInterval[domain]
+	if !ok {
+		return true
+	}
+
+	lastUpdated, ok := st.LastUpdated[ipNet][domain]
+	now := st.Clock.Now()
+	return !ok || now.Sub(lastUpdated) >= interval
+}
+
+// setIP sets the IP address of all domains. When ip is valid (that is, not clearing the records),
+// domains whose update intervals have not elapsed are skipped. It also returns the domains not skipped.
+// The state st is only used when ip is valid.
+func setIP(ctx context.Context, ppfmt pp.PP, c *config.Config, st *State, s setter.Setter,
+	ipNet ipnet.Type, ip netip.Addr,
+) (bool, []domain.Domain) {
 	ok := true
+	var attempted []domain.Domain
 
 	for _, domain := range c.Domains[ipNet] {
+		if ip.IsValid() && !isDue(c, st, ipNet, domain) {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "Skipped updating %q because its update interval (%v) has not elapsed",
+				domain.Describe(), c.DomainInterval[domain])
+			continue
+		}
+
 		ctx, cancel := context.WithTimeout(ctx, c.UpdateTimeout)
 		defer cancel()
 
+		attempted = append(attempted, domain)
 		if !s.Set(ctx, ppfmt, domain, ipNet, ip, c.TTL,
-			getProxied(ppfmt, c, domain)) {
+			getProxied(ppfmt, c, ipNet, domain)) {
 			ok = false
+			continue
+		}
+
+		if ip.IsValid() {
+			st.LastUpdated[ipNet][domain] = st.Clock.Now()
 		}
 	}
 
-	return ok
+	return ok, attempted
 }
 
 var MessageShouldDisplay = map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true} //nolint:gochecknoglobals
@@ -67,10 +113,14 @@ func detectIP(ctx context.Context, ppfmt pp.PP, c *config.Config, ipNet ipnet.Ty
 	return ip
 }
 
-func UpdateIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Setter) bool {
+// UpdateIPs detects the IP addresses and updates all the domains. At the end, it summarizes the changes
+// of DNS records unless no domains were updated. The state st is updated with the successful updates.
+func UpdateIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, st *State, s setter.Setter) bool {
 	ok := true
+	start := st.Clock.Now()
+	attempted := map[domain.Domain]bool{}
 
-	for _, ipNet := range [...]ipnet.Type{ipnet.IP4, ipnet.IP6} {
+	for _, ipNet := range ipnet.All() {
 		if c.Provider[ipNet] != nil {
 			ip := detectIP(ctx, ppfmt, c, ipNet)
 			if !ip.IsValid() {
@@ -78,21 +128,34 @@ func UpdateIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Sett
 				continue
 			}
 
-			if !setIP(ctx, ppfmt, c, s, ipNet, ip) {
+			setOk, domains := setIP(ctx, ppfmt, c, st, s, ipNet, ip)
+			if !setOk {
 				ok = false
 			}
+			for _, domain := range domains {
+				attempted[domain] = true
+			}
 		}
 	}
 
+	// A domain updated for both IPv4 and IPv6 is counted once.
+	if len(attempted) > 0 {
+		stats := s.TakeStats()
+		ppfmt.Infof(pp.EmojiUpdateRecord,
+			"Updated %d, created %d, deleted %d, failed %d records across %d domains (%v)",
+			stats.Updated, stats.Created, stats.Deleted, stats.Failed, len(attempted),
+			st.Clock.Now().Sub(start).Round(time.Millisecond))
+	}
+
 	return ok
 }
 
 func ClearIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Setter) bool {
 	ok := true
 
-	for _, ipNet := range [...]ipnet.Type{ipnet.IP4, ipnet.IP6} {
+	for _, ipNet := range ipnet.All() {
 		if c.Provider[ipNet] != nil {
-			if !setIP(ctx, ppfmt, c, s, ipNet, netip.Addr{}) {
+			if setOk, _ := setIP(ctx, ppfmt, c, nil, s, ipNet, netip.Addr{}); !setOk {
 				ok = false
 			}
 		}
@@ -4,16 +4,19 @@ import (
 	"context"
 	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/clock"
 	"github.com/favonia/cloudflare-ddns/internal/config"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/mocks"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/setter"
 	"github.com/favonia/cloudflare-ddns/internal/updater"
 )
 
@@ -42,26 +45,28 @@ func TestUpdateIPs(t *testing.T) {
 	provider4 := func(ppfmt pp.PP, m *mocks.MockProvider) { m.EXPECT().GetIP(gomock.Any(), ppfmt, ipnet.IP4).Return(ip4) }
 	provider6 := func(ppfmt pp.PP, m *mocks.MockProvider) { m.EXPECT().GetIP(gomock.Any(), ppfmt, ipnet.IP6).Return(ip6) }
 
-	type mockproxied = map[domain.Domain]bool
-	proxiedNone := mockproxied{domain4: false, domain6: false}
-	proxiedBoth := mockproxied{domain4: true, domain6: true}
+	type mockproxied = map[ipnet.Type]map[domain.Domain]bool
+	proxiedNone := mockproxied{ipnet.IP4: {domain4: false}, ipnet.IP6: {domain6: false}}
+	proxiedBoth := mockproxied{ipnet.IP4: {domain4: true}, ipnet.IP6: {domain6: true}}
 
 	for name, tc := range map[string]struct {
 		ttl                  api.TTL
 		proxied              mockproxied
 		ok                   bool
+		numDomains           int
 		MessageShouldDisplay map[ipnet.Type]bool
 		prepareMockPP        func(m *mocks.MockPP)
 		prepareMockProvider  mockproviders
 		prepareMockSetter    func(ppfmt pp.PP, m *mocks.MockSetter)
 	}{
 		"none": {
-			api.TTLAuto, proxiedBoth, true, map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true}, nil, mockproviders{}, nil,
+			api.TTLAuto, proxiedBoth, true, 0, map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true}, nil, mockproviders{}, nil,
 		},
 		"ip4only": {
 			api.TTLAuto,
 			proxiedNone,
 			true,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			pp4only,
 			mockproviders{ipnet.IP4: provider4},
@@ -73,6 +78,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedBoth,
 			false,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			pp4only,
 			mockproviders{ipnet.IP4: provider4},
@@ -84,6 +90,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedNone,
 			true,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			pp6only,
 			mockproviders{ipnet.IP6: provider6},
@@ -95,6 +102,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedBoth,
 			false,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			pp6only,
 			mockproviders{ipnet.IP6: provider6},
@@ -106,6 +114,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedNone,
 			true,
+			2,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			ppBoth,
 			mockproviders{ipnet.IP4: provider4, ipnet.IP6: provider6},
@@ -120,6 +129,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedBoth,
 			false,
+			2,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			ppBoth,
 			mockproviders{ipnet.IP4: provider4, ipnet.IP6: provider6},
@@ -134,6 +144,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedNone,
 			false,
+			2,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			ppBoth,
 			mockproviders{ipnet.IP4: provider4, ipnet.IP6: provider6},
@@ -148,6 +159,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedBoth,
 			false,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
@@ -170,6 +182,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedNone,
 			false,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
@@ -194,6 +207,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedBoth,
 			false,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: false},
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
@@ -215,6 +229,7 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			proxiedNone,
 			false,
+			0,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
@@ -240,13 +255,14 @@ func TestUpdateIPs(t *testing.T) {
 			api.TTLAuto,
 			mockproxied{},
 			true,
+			1,
 			map[ipnet.Type]bool{ipnet.IP4: true, ipnet.IP6: true},
 			func(m *mocks.MockPP) {
 				gomock.InOrder(
 					m.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address: %v", "IPv4", ip4),
 					m.EXPECT().Warningf(pp.EmojiImpossible,
-						"Proxied[%s] not initialized; please report the bug at https://github.com/favonia/cloudflare-ddns/issues/new",
-						"ip4.hello",
+						"Proxied[%s][%s] not initialized; please report the bug at https://github.com/favonia/cloudflare-ddns/issues/new",
+						"IPv4", "ip4.hello",
 					),
 				)
 			},
@@ -268,7 +284,7 @@ func TestUpdateIPs(t *testing.T) {
 			if tc.prepareMockPP != nil {
 				tc.prepareMockPP(mockPP)
 			}
-			for _, ipnet := range [...]ipnet.Type{ipnet.IP4, ipnet.IP6} {
+			for _, ipnet := range ipnet.All() {
 				updater.MessageShouldDisplay[ipnet] = tc.MessageShouldDisplay[ipnet]
 				if tc.prepareMockProvider[ipnet] == nil {
 					conf.Provider[ipnet] = nil
@@ -282,12 +298,127 @@ func TestUpdateIPs(t *testing.T) {
 			if tc.prepareMockSetter != nil {
 				tc.prepareMockSetter(mockPP, mockSetter)
 			}
-			ok := updater.UpdateIPs(ctx, mockPP, conf, mockSetter)
+			if tc.numDomains > 0 {
+				stats := setter.Stats{Created: 1, Updated: 2, Deleted: 3, Failed: 4}
+				mockSetter.EXPECT().TakeStats().Return(stats)
+				mockPP.EXPECT().Infof(pp.EmojiUpdateRecord,
+					"Updated %d, created %d, deleted %d, failed %d records across %d domains (%v)",
+					2, 1, 3, 4, tc.numDomains, gomock.Any())
+			}
+			ok := updater.UpdateIPs(ctx, mockPP, conf, updater.NewState(), mockSetter)
 			require.Equal(t, tc.ok, ok)
 		})
 	}
 }
 
+//nolint:funlen
+func TestUpdateIPsDomainInterval(t *testing.T) {
+	t.Parallel()
+
+	slow := domain.FQDN("slow.hello")
+	fast := domain.FQDN("fast.hello")
+	ip4 := netip.MustParseAddr("127.0.0.1")
+
+	mockClock := clock.NewMock(time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC))
+	st := updater.NewState()
+	st.Clock = mockClock
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+	conf := config.Default()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {slow, fast}}
+	conf.Proxied = map[ipnet.Type]map[domain.Domain]bool{ipnet.IP4: {slow: false, fast: false}}
+	conf.DomainInterval = map[domain.Domain]time.Duration{slow: 30 * time.Minute, fast: 5 * time.Minute}
+	conf.Provider[ipnet.IP6] = nil
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	conf.Provider[ipnet.IP4] = mockProvider
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+
+	// The first update touches every domain.
+	gomock.InOrder(
+		mockProvider.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).Return(ip4),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address: %v", "IPv4", ip4),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, slow, ipnet.IP4, ip4, api.TTLAuto, false).Return(true),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, fast, ipnet.IP4, ip4, api.TTLAuto, false).Return(true),
+		mockSetter.EXPECT().TakeStats().Return(setter.Stats{Created: 0, Updated: 0, Deleted: 0, Failed: 0}),
+		mockPP.EXPECT().Infof(pp.EmojiUpdateRecord,
+			"Updated %d, created %d, deleted %d, failed %d records across %d domains (%v)", 0, 0, 0, 0, 2, time.Duration(0)),
+	)
+	require.True(t, updater.UpdateIPs(ctx, mockPP, conf, st, mockSetter))
+
+	// Only 10 minutes later, the domain with the 30-minute interval is skipped.
+	mockClock.Advance(10 * time.Minute)
+	gomock.InOrder(
+		mockProvider.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).Return(ip4),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address: %v", "IPv4", ip4),
+		mockPP.EXPECT().Infof(pp.EmojiAlreadyDone,
+			"Skipped updating %q because its update interval (%v) has not elapsed", "slow.hello", 30*time.Minute),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, fast, ipnet.IP4, ip4, api.TTLAuto, false).Return(true),
+		mockSetter.EXPECT().TakeStats().Return(setter.Stats{Created: 0, Updated: 0, Deleted: 0, Failed: 0}),
+		mockPP.EXPECT().Infof(pp.EmojiUpdateRecord,
+			"Updated %d, created %d, deleted %d, failed %d records across %d domains (%v)", 0, 0, 0, 0, 1, time.Duration(0)),
+	)
+	require.True(t, updater.UpdateIPs(ctx, mockPP, conf, st, mockSetter))
+
+	// After 30 minutes in total, both domains are updated again.
+	mockClock.Advance(20 * time.Minute)
+	gomock.InOrder(
+		mockProvider.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).Return(ip4),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address: %v", "IPv4", ip4),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, slow, ipnet.IP4, ip4, api.TTLAuto, false).Return(true),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, fast, ipnet.IP4, ip4, api.TTLAuto, false).Return(true),
+		mockSetter.EXPECT().TakeStats().Return(setter.Stats{Created: 0, Updated: 0, Deleted: 0, Failed: 0}),
+		mockPP.EXPECT().Infof(pp.EmojiUpdateRecord,
+			"Updated %d, created %d, deleted %d, failed %d records across %d domains (%v)", 0, 0, 0, 0, 2, time.Duration(0)),
+	)
+	require.True(t, updater.UpdateIPs(ctx, mockPP, conf, st, mockSetter))
+}
+
+func TestUpdateIPsSummary(t *testing.T) {
+	t.Parallel()
+
+	shared := domain.FQDN("shared.hello")
+	ip4 := netip.MustParseAddr("127.0.0.1")
+	ip6 := netip.MustParseAddr("::1")
+
+	mockClock := clock.NewMock(time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC))
+	st := updater.NewState()
+	st.Clock = mockClock
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+	conf := config.Default()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {shared}, ipnet.IP6: {shared}}
+	conf.Proxied = map[ipnet.Type]map[domain.Domain]bool{ipnet.IP4: {shared: false}, ipnet.IP6: {shared: false}}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	conf.Provider[ipnet.IP4] = mockProvider
+	conf.Provider[ipnet.IP6] = mockProvider
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+
+	// The domain is counted once, and the duration is rounded to milliseconds.
+	gomock.InOrder(
+		mockProvider.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).Return(ip4),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address: %v", "IPv4", ip4),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, shared, ipnet.IP4, ip4, api.TTLAuto, false).
+			DoAndReturn(func(context.Context, pp.PP, domain.Domain, ipnet.Type, netip.Addr, api.TTL, bool) bool {
+				mockClock.Advance(1234567 * time.Microsecond)
+				return true
+			}),
+		mockProvider.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP6).Return(ip6),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address: %v", "IPv6", ip6),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, shared, ipnet.IP6, ip6, api.TTLAuto, false).Return(true),
+		mockSetter.EXPECT().TakeStats().Return(setter.Stats{Created: 0, Updated: 2, Deleted: 0, Failed: 0}),
+		mockPP.EXPECT().Infof(pp.EmojiUpdateRecord,
+			"Updated %d, created %d, deleted %d, failed %d records across %d domains (%v)",
+			2, 0, 0, 0, 1, 1235*time.Millisecond),
+	)
+	require.True(t, updater.UpdateIPs(ctx, mockPP, conf, st, mockSetter))
+}
+
 //nolint:funlen,paralleltest // updater.IPv6MessageDisplayed is a global variable
 func TestClearIPs(t *testing.T) {
 	domain4 := domain.FQDN("ip4.hello")
@@ -299,8 +430,8 @@ func TestClearIPs(t *testing.T) {
 
 	type mockproviders = map[ipnet.Type]bool
 
-	type mockproxied = map[domain.Domain]bool
-	proxiedNone := mockproxied{domain4: false, domain6: false}
+	type mockproxied = map[ipnet.Type]map[domain.Domain]bool
+	proxiedNone := mockproxied{ipnet.IP4: {domain4: false}, ipnet.IP6: {domain6: false}}
 
 	for name, tc := range map[string]struct {
 		ttl                  api.TTL
@@ -419,7 +550,7 @@ func TestClearIPs(t *testing.T) {
 			if tc.prepareMockPP != nil {
 				tc.prepareMockPP(mockPP)
 			}
-			for _, ipnet := range [...]ipnet.Type{ipnet.IP4, ipnet.IP6} {
+			for _, ipnet := range ipnet.All() {
 				updater.MessageShouldDisplay[ipnet] = tc.MessageShouldDisplay[ipnet]
 				if !tc.prepareMockProvider[ipnet] {
 					conf.Provider[ipnet] = nil